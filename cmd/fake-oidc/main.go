@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"rest-notes/internal/app/oidctest"
+)
+
+// Fake OIDC provider serves mock OpenID Connect issuer locally so that SSO login can be tried without real provider
+// Register it in application with OIDC_PROVIDERS=mock, OIDC_MOCK_ISSUER=http://localhost:8091,
+// OIDC_MOCK_CLIENT_ID and OIDC_MOCK_CLIENT_SECRET matching flags below
+func main() {
+	addr := flag.String("addr", ":8091", "address to listen on")
+	issuerURL := flag.String("issuer", "http://localhost:8091", "issuer URL, must be URL provider is reached at")
+	clientID := flag.String("client-id", "rest-notes", "client ID of application")
+	clientSecret := flag.String("client-secret", "secret", "client secret of application, empty for public client")
+	flag.Parse()
+
+	issuer, err := oidctest.New(*issuerURL, *clientID, *clientSecret)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	log.Printf("Фейковый OIDC-провайдер %s слушает %s", *issuerURL, *addr)
+	log.Fatal(http.ListenAndServe(*addr, issuer))
+}
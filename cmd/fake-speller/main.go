@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"rest-notes/internal/app/spellertest"
+)
+
+// defaultWords is word list used when no dictionary file is given
+//
+//go:embed words.txt
+var defaultWords string
+
+// Fake speller serves Yandex Speller API locally so that application can be run without internet access
+// Point application to it with SPELLER_URL=http://localhost:8090
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	wordsFile := flag.String("words", "", "file with known words, one per line (built-in list by default)")
+	flag.Parse()
+
+	words := strings.Split(defaultWords, "\n")
+	if *wordsFile != "" {
+		var err error
+		words, err = readWords(*wordsFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	log.Printf("Фейковый спеллер слушает %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, spellertest.New(words)))
+}
+
+// readWords reads word list from file
+func readWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		words = append(words, scanner.Text())
+	}
+	return words, scanner.Err()
+}
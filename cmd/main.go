@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"time"
 
 	"rest-notes/internal/app/api"
 	"rest-notes/internal/app/config"
 	httpHandler "rest-notes/internal/app/http"
+	"rest-notes/internal/app/ratelimit"
 	"rest-notes/internal/app/repository"
 	"rest-notes/internal/app/repository/database"
 )
@@ -35,11 +38,57 @@ func main() {
 	repo := repository.New(*db)
 
 	// Create a new service
-	service := api.New(repo)
+	service, err := api.New(repo, cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Create or appoint administrator configured for this installation
+	if cfg.AdminUsername != "" {
+		if err = service.BootstrapAdmin(cfg.AdminUsername, cfg.AdminPassword); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	// Start background purge of notes kept in trash longer than retention period
+	go purgeTrash(service, cfg.TrashRetention, cfg.TrashPurgeInterval)
+
+	// Start background spell checking of notes saved in async spell mode
+	service.StartSpellWorkers(cfg.SpellWorkers, cfg.SpellPollInterval, cfg.SpellJobBackoff, cfg.SpellJobAttempts)
+
+	// Create rate limiters of authentication and other routes
+	authLimiter := newRateLimiter(cfg.RateLimitBackend, repo, cfg.AuthRateLimit, cfg.AuthRateBurst)
+	apiLimiter := newRateLimiter(cfg.RateLimitBackend, repo, cfg.APIRateLimit, cfg.APIRateBurst)
 
 	// Create Http handler
-	handler := httpHandler.New(*service)
+	handler := httpHandler.New(*service, authLimiter, apiLimiter, cfg.TrustProxy, cfg.MetricsToken)
 
 	// Start server
 	handler.StartServer(cfg.HttpPort)
 }
+
+// purgeTrash periodically deletes notes that have been in trash longer than retention
+func purgeTrash(service *api.Service, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := service.PurgeTrash(retention); err != nil {
+			log.Printf("Не удалось очистить корзину: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// newRateLimiter creates rate limiter with buckets kept in memory or in database, it returns nil if limit is disabled
+func newRateLimiter(backend string, repo *repository.Repository, perMinute, burst int) ratelimit.Limiter {
+	if perMinute == 0 {
+		return nil
+	}
+	if backend == config.RateLimitPostgres {
+		return ratelimit.NewStoreLimiter(repo.RateLimitRepo, perMinute, burst)
+	}
+	return ratelimit.NewMemoryLimiter(perMinute, burst)
+}
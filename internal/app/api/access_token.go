@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dgrijalva/jwt-go"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrAccessTokenNotFound = errors.New("токен доступа не найден")
+	ErrInvalidAccessToken  = errors.New("неправильные параметры токена доступа")
+)
+
+// accessTokenPrefix marks personal access tokens so that they can be told apart from JWT
+const accessTokenPrefix = "rnpat_"
+
+// maxAccessTokenNameLength is maximum length of personal access token name in characters
+const maxAccessTokenNameLength = 100
+
+// CreateAccessToken issues new personal access token for user
+// Token must have name and at least one known scope, expiration time is optional but must be in future
+// Returned token is the only place where its plain value is available
+func (as *AuthService) CreateAccessToken(token models.PersonalAccessToken) (models.PersonalAccessToken, error) {
+	token.Name = strings.TrimSpace(token.Name)
+	if token.Name == "" || utf8.RuneCountInString(token.Name) > maxAccessTokenNameLength {
+		return models.PersonalAccessToken{}, ErrInvalidAccessToken
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return models.PersonalAccessToken{}, ErrInvalidAccessToken
+	}
+
+	scopes, err := normalizeScopes(token.Scopes)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+	token.Scopes = scopes
+
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+	plain := accessTokenPrefix + secret
+
+	created, err := as.accessTokens.Create(token, hashToken(plain))
+	if err != nil {
+		log.Printf("Ошибка при создании токена доступа: %v", err)
+		return models.PersonalAccessToken{}, err
+	}
+	created.Token = plain
+
+	log.Printf("Токен доступа ID %d создан для пользователя ID: %d", created.ID, created.UserID)
+	return created, nil
+}
+
+// GetAccessTokens retrieves active personal access tokens of user without their values
+func (as *AuthService) GetAccessTokens(userID int) ([]models.PersonalAccessToken, error) {
+	tokens, err := as.accessTokens.GetAll(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении токенов доступа: %v", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokeAccessToken revokes personal access token of user
+func (as *AuthService) RevokeAccessToken(userID, tokenID int) error {
+	err := as.accessTokens.Revoke(tokenID, userID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrAccessTokenNotFound) {
+			return ErrAccessTokenNotFound
+		}
+		log.Printf("Ошибка при отзыве токена доступа ID %d: %v", tokenID, err)
+		return err
+	}
+
+	log.Printf("Токен доступа ID %d отозван пользователем ID: %d", tokenID, userID)
+	return nil
+}
+
+// checkAccessToken validates personal access token and builds claims equivalent to JWT ones
+// Granted scopes are returned in space-separated scope claim
+func (as *AuthService) checkAccessToken(tokenString string) (bool, jwt.MapClaims, error) {
+	token, err := as.accessTokens.GetByHash(hashToken(tokenString))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrAccessTokenNotFound) {
+			log.Printf("Неизвестный токен доступа")
+			return false, nil, ErrInvalidToken
+		}
+		log.Printf("Ошибка при получении токена доступа: %v", err)
+		return false, nil, err
+	}
+
+	if token.RevokedAt != nil {
+		log.Printf("Токен доступа ID %d отозван", token.ID)
+		return false, nil, ErrInvalidToken
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		log.Printf("Токен доступа ID %d истек", token.ID)
+		return false, nil, ErrInvalidToken
+	}
+
+	if err = as.accessTokens.MarkUsed(token.ID); err != nil {
+		log.Printf("Ошибка при обновлении времени использования токена доступа ID %d: %v", token.ID, err)
+	}
+
+	claims := jwt.MapClaims{
+		"id":    float64(token.UserID),
+		"pat":   float64(token.ID),
+		"scope": strings.Join(token.Scopes, " "),
+	}
+	return true, claims, nil
+}
+
+// normalizeScopes checks that all scopes are known and removes duplicates
+// Scopes are returned in order of models.Scopes
+func normalizeScopes(scopes []string) ([]string, error) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[scope] = true
+	}
+
+	result := make([]string, 0, len(requested))
+	for _, known := range models.Scopes {
+		if requested[known] {
+			result = append(result, known)
+			delete(requested, known)
+		}
+	}
+
+	// Every requested scope must match one of known scopes
+	if len(result) == 0 || len(requested) > 0 {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return result, nil
+}
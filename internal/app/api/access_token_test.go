@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// memAccessTokenRepo keeps personal access tokens in memory by hash
+type memAccessTokenRepo struct {
+	repository.AccessTokenRepo
+	tokens map[string]*models.PersonalAccessToken
+}
+
+func (r *memAccessTokenRepo) Create(token models.PersonalAccessToken, tokenHash string) (models.PersonalAccessToken,
+	error) {
+	token.ID, token.CreatedAt = len(r.tokens)+1, time.Now()
+	r.tokens[tokenHash] = &token
+	return token, nil
+}
+
+func (r *memAccessTokenRepo) GetByHash(tokenHash string) (models.PersonalAccessToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return models.PersonalAccessToken{}, postgresql.ErrAccessTokenNotFound
+	}
+	return *token, nil
+}
+
+func (r *memAccessTokenRepo) MarkUsed(id int) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			now := time.Now()
+			token.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memAccessTokenRepo) Revoke(id, userID int) error {
+	for _, token := range r.tokens {
+		if token.ID == id && token.UserID == userID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			return nil
+		}
+	}
+	return postgresql.ErrAccessTokenNotFound
+}
+
+func newAccessTokenTestService() (*AuthService, *memAccessTokenRepo) {
+	tokens := &memAccessTokenRepo{tokens: make(map[string]*models.PersonalAccessToken)}
+	return &AuthService{accessTokens: tokens}, tokens
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr bool
+	}{
+		{"single scope", []string{models.ScopeNotesRead}, []string{models.ScopeNotesRead}, false},
+		{"order of models.Scopes", []string{models.ScopeAccountRead, models.ScopeNotesRead},
+			[]string{models.ScopeNotesRead, models.ScopeAccountRead}, false},
+		{"duplicates", []string{models.ScopeNotesWrite, models.ScopeNotesWrite}, []string{models.ScopeNotesWrite},
+			false},
+		{"no scopes", nil, nil, true},
+		{"unknown scope", []string{"notes:delete"}, nil, true},
+		{"unknown scope with known one", []string{models.ScopeNotesRead, "admin"}, nil, true},
+		{"scope in other case", []string{"Notes:Read"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeScopes(tt.scopes)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAccessToken) {
+					t.Errorf("normalizeScopes error = %v, want ErrInvalidAccessToken", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeScopes = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateAccessToken(t *testing.T) {
+	as, tokens := newAccessTokenTestService()
+
+	created, err := as.CreateAccessToken(models.PersonalAccessToken{UserID: 1, Name: " backup ",
+		Scopes: []string{models.ScopeNotesRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Token, accessTokenPrefix) || created.Name != "backup" {
+		t.Errorf("CreateAccessToken = %+v, want token with %s prefix named backup", created, accessTokenPrefix)
+	}
+
+	// Only hash of token is stored
+	for hash, stored := range tokens.tokens {
+		if hash != hashToken(created.Token) || stored.Token != "" {
+			t.Errorf("stored token %+v with hash %s, want only hash of returned token", stored, hash)
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	for _, token := range []models.PersonalAccessToken{
+		{Name: "", Scopes: []string{models.ScopeNotesRead}},
+		{Name: strings.Repeat("я", maxAccessTokenNameLength+1), Scopes: []string{models.ScopeNotesRead}},
+		{Name: "backup", Scopes: []string{"notes:delete"}},
+		{Name: "backup", Scopes: []string{models.ScopeNotesRead}, ExpiresAt: &past},
+	} {
+		if _, err = as.CreateAccessToken(token); !errors.Is(err, ErrInvalidAccessToken) {
+			t.Errorf("CreateAccessToken(%+v) error = %v, want ErrInvalidAccessToken", token, err)
+		}
+	}
+}
+
+func TestCheckAccessToken(t *testing.T) {
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name      string
+		token     string
+		change    func(token *models.PersonalAccessToken)
+		wantValid bool
+	}{
+		{"valid token", "", func(token *models.PersonalAccessToken) {}, true},
+		{"token with expiration time", "", func(token *models.PersonalAccessToken) { token.ExpiresAt = &future },
+			true},
+		{"expired token", "", func(token *models.PersonalAccessToken) { token.ExpiresAt = &past }, false},
+		{"revoked token", "", func(token *models.PersonalAccessToken) { token.RevokedAt = &past }, false},
+		{"unknown token", accessTokenPrefix + "unknown", func(token *models.PersonalAccessToken) {}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as, tokens := newAccessTokenTestService()
+			created, err := as.CreateAccessToken(models.PersonalAccessToken{UserID: 7, Name: "backup",
+				Scopes: []string{models.ScopeNotesRead, models.ScopeAccountRead}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.change(tokens.tokens[hashToken(created.Token)])
+			tokenString := created.Token
+			if tt.token != "" {
+				tokenString = tt.token
+			}
+
+			// Tokens with prefix are looked up by hash instead of being parsed as JWT
+			valid, claims, err := as.IsTokenValid(tokenString)
+			if !tt.wantValid {
+				if valid || !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("IsTokenValid = %v, %v, want ErrInvalidToken", valid, err)
+				}
+				return
+			}
+			if !valid || err != nil {
+				t.Fatalf("IsTokenValid = %v, %v, want valid", valid, err)
+			}
+			if claims["id"] != float64(7) || claims["pat"] != float64(created.ID) ||
+				claims["scope"] != "notes:read account:read" {
+				t.Errorf("claims = %v, want user 7 with notes:read and account:read scopes", claims)
+			}
+			if _, ok := claims["sid"]; ok {
+				t.Error("claims of access token have session ID")
+			}
+			if tokens.tokens[hashToken(created.Token)].LastUsedAt == nil {
+				t.Error("time of token use was not recorded")
+			}
+		})
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	as, _ := newAccessTokenTestService()
+	created, err := as.CreateAccessToken(models.PersonalAccessToken{UserID: 1, Name: "backup",
+		Scopes: []string{models.ScopeNotesRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other user can't revoke token
+	if err = as.RevokeAccessToken(2, created.ID); !errors.Is(err, ErrAccessTokenNotFound) {
+		t.Errorf("RevokeAccessToken by other user error = %v, want ErrAccessTokenNotFound", err)
+	}
+	if err = as.RevokeAccessToken(1, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if valid, _, err := as.IsTokenValid(created.Token); valid || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("IsTokenValid of revoked token = %v, %v, want ErrInvalidToken", valid, err)
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrUserNotFound     = errors.New("пользователь не найден")
+	ErrCannotManageSelf = errors.New("нельзя отключить, удалить или сбросить пароль собственной учетной записи")
+	ErrAdminNameTaken   = errors.New("имя администратора занято пользователем с другим паролем")
+)
+
+// temporaryPasswordBytes is minimum number of random bytes in password generated on reset
+const temporaryPasswordBytes = 12
+
+// AdminService provides administration of user accounts
+type AdminService struct {
+	repo     repository.UserRepo
+	sessions repository.SessionRepo
+	policy   *PasswordPolicy
+}
+
+// NewAdminService creates new instance of AdminService
+// Passwords generated on reset satisfy policy
+func NewAdminService(repo repository.UserRepo, sessions repository.SessionRepo, policy *PasswordPolicy) *AdminService {
+	return &AdminService{repo: repo, sessions: sessions, policy: policy}
+}
+
+// GetUsers retrieves all users
+func (a *AdminService) GetUsers() ([]models.User, error) {
+	users, err := a.repo.GetAll()
+	if err != nil {
+		log.Printf("Ошибка при получении списка пользователей: %v", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// DisableUser disables user's account and ends all their sessions
+// Disabled user can't log in and their personal access tokens stop working
+func (a *AdminService) DisableUser(adminID, userID int) (models.User, error) {
+	if adminID == userID {
+		return models.User{}, ErrCannotManageSelf
+	}
+
+	if err := a.repo.SetDisabled(userID, true); err != nil {
+		return models.User{}, userError(userID, err)
+	}
+
+	if err := a.sessions.RevokeAllForUser(userID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя ID %d: %v", userID, err)
+		return models.User{}, err
+	}
+
+	log.Printf("Администратор ID %d отключил пользователя ID %d", adminID, userID)
+	return a.getUser(userID)
+}
+
+// EnableUser enables disabled user's account
+func (a *AdminService) EnableUser(adminID, userID int) (models.User, error) {
+	if err := a.repo.SetDisabled(userID, false); err != nil {
+		return models.User{}, userError(userID, err)
+	}
+
+	log.Printf("Администратор ID %d включил пользователя ID %d", adminID, userID)
+	return a.getUser(userID)
+}
+
+// DeleteUser deletes user with all their data
+func (a *AdminService) DeleteUser(adminID, userID int) error {
+	if adminID == userID {
+		return ErrCannotManageSelf
+	}
+
+	if err := a.repo.Delete(userID); err != nil {
+		return userError(userID, err)
+	}
+
+	log.Printf("Администратор ID %d удалил пользователя ID %d", adminID, userID)
+	return nil
+}
+
+// ResetUserPassword replaces user's password with generated temporary one and ends all their sessions
+// Returns new password that has to be passed to user
+func (a *AdminService) ResetUserPassword(adminID, userID int) (string, error) {
+	if adminID == userID {
+		return "", ErrCannotManageSelf
+	}
+
+	password, err := a.policy.generatePassword()
+	if err != nil {
+		return "", err
+	}
+	hash, err := generatePasswordHash(password)
+	if err != nil {
+		return "", err
+	}
+
+	if err = a.repo.UpdatePassword(userID, hash); err != nil {
+		return "", userError(userID, err)
+	}
+
+	if err = a.sessions.RevokeAllForUser(userID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя ID %d: %v", userID, err)
+		return "", err
+	}
+
+	log.Printf("Администратор ID %d сбросил пароль пользователя ID %d", adminID, userID)
+	return password, nil
+}
+
+// BootstrapAdmin makes sure that administrator configured for installation exists
+// It is used on startup: missing user is created with given password and admin role,
+// existing user is promoted only if their password matches, so that account registered under
+// the name by someone else can't become administrator; ErrAdminNameTaken is returned then
+func (a *AdminService) BootstrapAdmin(name, password string) error {
+	user, err := a.repo.GetByName(name)
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return a.createAdmin(name, password)
+	}
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя %s: %v", name, err)
+		return err
+	}
+
+	if user.Role == models.RoleAdmin {
+		return nil
+	}
+
+	if _, err = a.repo.Get(models.User{Name: name, Password: password}); err != nil {
+		if errors.Is(err, postgresql.ErrInvalidPassword) {
+			log.Printf("ВНИМАНИЕ: пользователь %s существует, но его пароль не совпадает с ADMIN_PASSWORD; "+
+				"администратор не назначен, проверьте, кто зарегистрировал эту учетную запись", name)
+			return ErrAdminNameTaken
+		}
+		log.Printf("Ошибка при проверке пароля пользователя %s: %v", name, err)
+		return err
+	}
+
+	if err = a.repo.SetRole(name, models.RoleAdmin); err != nil {
+		log.Printf("Ошибка при назначении администратора %s: %v", name, err)
+		return err
+	}
+
+	log.Printf("Пользователь %s назначен администратором", name)
+	return nil
+}
+
+// createAdmin creates user with admin role, password must satisfy password policy
+func (a *AdminService) createAdmin(name, password string) error {
+	if err := a.policy.Validate(name, password); err != nil {
+		log.Printf("Пароль администратора %s не соответствует политике: %v", name, err)
+		return err
+	}
+
+	hash, err := generatePasswordHash(password)
+	if err != nil {
+		return err
+	}
+
+	if err = a.repo.Create(models.User{Name: name, Password: hash, Role: models.RoleAdmin}); err != nil {
+		log.Printf("Ошибка при создании администратора %s: %v", name, err)
+		return err
+	}
+
+	log.Printf("Создан администратор %s", name)
+	return nil
+}
+
+// getUser retrieves user by ID
+func (a *AdminService) getUser(userID int) (models.User, error) {
+	user, err := a.repo.GetByID(userID)
+	if err != nil {
+		return models.User{}, userError(userID, err)
+	}
+	return user, nil
+}
+
+// userError converts repository error about user to service error
+func userError(userID int, err error) error {
+	if errors.Is(err, postgresql.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	log.Printf("Ошибка при работе с пользователем ID %d: %v", userID, err)
+	return err
+}
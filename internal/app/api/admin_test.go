@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// memUserRepo keeps users in memory by name
+type memUserRepo struct {
+	repository.UserRepo
+	users map[string]models.User
+}
+
+// newMemUserRepo creates repository with users having given passwords
+func newMemUserRepo(t *testing.T, users ...models.User) *memUserRepo {
+	t.Helper()
+
+	repo := &memUserRepo{users: make(map[string]models.User)}
+	for i, user := range users {
+		hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.ID, user.Password = i+1, string(hash)
+		if user.Role == "" {
+			user.Role = models.RoleUser
+		}
+		repo.users[user.Name] = user
+	}
+	return repo
+}
+
+func (r *memUserRepo) Create(user models.User) error {
+	if _, ok := r.users[user.Name]; ok {
+		return errors.New("duplicate username")
+	}
+	user.ID = len(r.users) + 1
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	r.users[user.Name] = user
+	return nil
+}
+
+func (r *memUserRepo) Get(user models.User) (models.User, error) {
+	dbUser, ok := r.users[user.Name]
+	if !ok {
+		return models.User{}, postgresql.ErrNotFound
+	}
+	if bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(user.Password)) != nil {
+		return models.User{}, postgresql.ErrInvalidPassword
+	}
+	return dbUser, nil
+}
+
+func (r *memUserRepo) GetByID(id int) (models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return models.User{}, postgresql.ErrNotFound
+}
+
+func (r *memUserRepo) GetByName(name string) (models.User, error) {
+	if user, ok := r.users[name]; ok {
+		return user, nil
+	}
+	return models.User{}, postgresql.ErrNotFound
+}
+
+func (r *memUserRepo) SetRole(name, role string) error {
+	user, ok := r.users[name]
+	if !ok {
+		return postgresql.ErrNotFound
+	}
+	user.Role = role
+	r.users[name] = user
+	return nil
+}
+
+func (r *memUserRepo) UpdatePassword(id int, passwordHash string) error {
+	for name, user := range r.users {
+		if user.ID == id {
+			user.Password = passwordHash
+			r.users[name] = user
+			return nil
+		}
+	}
+	return postgresql.ErrNotFound
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	const password = "correct-horse-battery"
+
+	tests := []struct {
+		name     string
+		users    []models.User
+		password string
+		wantErr  error
+		wantRole string
+	}{
+		{"missing user is created", nil, password, nil, models.RoleAdmin},
+		{"weak password is rejected", nil, "short", ErrWeakPassword, ""},
+		{"existing admin is kept", []models.User{{Name: "admin", Password: "other-password", Role: models.RoleAdmin}},
+			password, nil, models.RoleAdmin},
+		{"owner of name is promoted", []models.User{{Name: "admin", Password: password}}, password, nil,
+			models.RoleAdmin},
+		{"user who took name is not promoted", []models.User{{Name: "admin", Password: "hijacker-password"}},
+			password, ErrAdminNameTaken, models.RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemUserRepo(t, tt.users...)
+			policy, err := NewPasswordPolicy(8, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			admin := NewAdminService(repo, nil, policy)
+
+			if err = admin.BootstrapAdmin("admin", tt.password); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BootstrapAdmin error = %v, want %v", err, tt.wantErr)
+			}
+
+			user, ok := repo.users["admin"]
+			if tt.wantRole == "" {
+				if ok {
+					t.Errorf("user %+v was created", user)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("user does not exist")
+			}
+			if user.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", user.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestBootstrapAdminCreatesUserWithPassword(t *testing.T) {
+	repo := newMemUserRepo(t)
+	policy, err := NewPasswordPolicy(8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = NewAdminService(repo, nil, policy).BootstrapAdmin("admin", "correct-horse-battery"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Get(models.User{Name: "admin", Password: "correct-horse-battery"}); err != nil {
+		t.Errorf("created administrator can't log in with configured password: %v", err)
+	}
+}
@@ -3,31 +3,80 @@ package api
 import (
 	"errors"
 	"log"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
+	"rest-notes/internal/app/mail"
 	"rest-notes/internal/app/models"
 	"rest-notes/internal/app/repository"
 	"rest-notes/internal/app/repository/postgresql"
 )
 
-var ErrUserAlreadyExists = errors.New("пользователь уже существует")
+var (
+	ErrUserAlreadyExists   = errors.New("пользователь уже существует")
+	ErrInvalidToken        = errors.New("недействительный токен")
+	ErrInvalidRefreshToken = errors.New("недействительный refresh-токен")
+	ErrUserDisabled        = errors.New("учетная запись отключена")
+)
 
-// AuthService provides authentication services using user repository
+// AuthService provides authentication services using user, session, access token and password reset repositories
 type AuthService struct {
-	repo repository.UserRepo
+	repo            repository.UserRepo
+	sessions        repository.SessionRepo
+	accessTokens    repository.AccessTokenRepo
+	resets          repository.PasswordResetRepo
+	mfa             repository.MFARepo
+	policy          *PasswordPolicy
+	guard           *LoginGuard
+	mailer          mail.Mailer
+	keys            *KeySet
+	jwtIssuer       string
+	jwtAudience     string
+	mfaIssuer       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	resetTokenTTL   time.Duration
 }
 
 // NewAuthService creates new instance of AuthService
-func NewAuthService(repo repository.UserRepo) *AuthService {
-	return &AuthService{repo: repo}
+// Access tokens live for accessTokenTTL, sessions are kept alive by refresh tokens for refreshTokenTTL
+// New passwords are checked with policy, password reset tokens are sent by mailer and live for resetTokenTTL
+// Login attempts are checked by guard, mfaIssuer names service in authenticator apps
+// JWTs are signed with keys and carry jwtIssuer and jwtAudience in iss and aud claims
+func NewAuthService(repo repository.UserRepo, sessions repository.SessionRepo, accessTokens repository.AccessTokenRepo,
+	resets repository.PasswordResetRepo, mfa repository.MFARepo, policy *PasswordPolicy, guard *LoginGuard,
+	mailer mail.Mailer, keys *KeySet, jwtIssuer, jwtAudience, mfaIssuer string,
+	accessTokenTTL, refreshTokenTTL, resetTokenTTL time.Duration) *AuthService {
+	return &AuthService{
+		repo:            repo,
+		sessions:        sessions,
+		accessTokens:    accessTokens,
+		resets:          resets,
+		mfa:             mfa,
+		policy:          policy,
+		guard:           guard,
+		mailer:          mailer,
+		keys:            keys,
+		jwtIssuer:       jwtIssuer,
+		jwtAudience:     jwtAudience,
+		mfaIssuer:       mfaIssuer,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		resetTokenTTL:   resetTokenTTL,
+	}
 }
 
 // CreateUser creates new user using repository and returns created user
+// Password must satisfy password policy
 func (as *AuthService) CreateUser(user models.User) error {
-	_, err := as.repo.Get(user)
+	if err := as.policy.Validate(user.Name, user.Password); err != nil {
+		log.Printf("Создание пользователя %s не удалось: %v", user.Name, err)
+		return err
+	}
+
+	_, err := as.repo.GetByName(user.Name)
 	if err == nil {
 		log.Printf("Создание пользователя не удалось: %s уже существует", user.Name)
 		return ErrUserAlreadyExists
@@ -56,50 +105,121 @@ func (as *AuthService) CreateUser(user models.User) error {
 	return nil
 }
 
-// GenerateToken generates JWT for authenticated user
-// It retrieves user from repository and creates signed JWT token
-func (as *AuthService) GenerateToken(user models.User) (string, error) {
+// GenerateToken authenticates user logging in from given IP address and starts new session for them
+// It retrieves user from repository and issues short-lived access JWT and long-lived refresh token
+// Returns ErrInvalidCredentials if name or password is wrong and *LoginBlockedError if login guard rejects attempt
+// If user has two-factor authentication enabled, only mfa_pending token is returned
+func (as *AuthService) GenerateToken(user models.User, ip string) (models.Tokens, error) {
+	if err := as.guard.Check(user.Name, ip); err != nil {
+		return models.Tokens{}, err
+	}
+
 	dbUser, err := as.repo.Get(user)
 	if err != nil {
+		if errors.Is(err, postgresql.ErrNotFound) || errors.Is(err, postgresql.ErrInvalidPassword) {
+			log.Printf("Неудачная попытка входа: пользователь %s, IP %s", user.Name, ip)
+			as.guard.Fail(user.Name, ip)
+			return models.Tokens{}, ErrInvalidCredentials
+		}
 		log.Printf("Ошибка при получении пользователя для генерации токена: %v", err)
-		return "", err
+		return models.Tokens{}, err
+	}
+	as.guard.Success(user.Name)
+
+	if dbUser.DisabledAt != nil {
+		log.Printf("Попытка входа в отключенную учетную запись: %s", dbUser.Name)
+		return models.Tokens{}, ErrUserDisabled
+	}
+
+	return as.issueTokens(dbUser)
+}
+
+// issueTokens starts session of authenticated user and returns its tokens
+// User with two-factor authentication gets only mfa_pending token to be exchanged with code in VerifyMFA
+func (as *AuthService) issueTokens(user models.User) (models.Tokens, error) {
+	if user.MFAEnabled {
+		mfaToken, err := as.generateMFAToken(user)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		log.Printf("Для входа пользователя %s требуется код 2FA", user.Name)
+		return models.Tokens{MFAToken: mfaToken, TokenType: mfaPendingTokenType,
+			ExpiresIn: int(mfaTokenTTL.Seconds())}, nil
 	}
 
-	// Generate JWT token
-	token, err := generateJWT(dbUser)
+	tokens, err := as.startSession(user)
 	if err != nil {
-		log.Printf("Ошибка при генерации JWT: %v", err)
-		return "", err
+		return models.Tokens{}, err
 	}
 
-	log.Printf("JWT успешно сгенерирован для пользователя: %s", dbUser.Name)
-	return token, nil
+	log.Printf("JWT успешно сгенерирован для пользователя: %s", user.Name)
+	return tokens, nil
 }
 
-// IsTokenValid validates given JWT
-// It checks token's signature, claims, and expiration time
+// IsTokenValid validates given JWT or personal access token
+// For JWT it checks token's signature, claims, expiration time and that session token was issued for is not revoked
+// Returns ErrInvalidToken if token must be rejected
 func (as *AuthService) IsTokenValid(tokenString string) (bool, jwt.MapClaims, error) {
+	if strings.HasPrefix(tokenString, accessTokenPrefix) {
+		return as.checkAccessToken(tokenString)
+	}
+
 	// Check token validity
-	validToken, claims, err := checkToken(tokenString)
+	validToken, claims, err := as.checkToken(tokenString)
 	if err != nil || !validToken {
 		log.Printf("Неверный токен: %v", err)
-		return false, nil, errors.New("invalid token")
+		return false, nil, ErrInvalidToken
+	}
+
+	// Token issued before two-factor check does not grant access
+	if tokenType, _ := claims["typ"].(string); tokenType == mfaPendingTokenType {
+		log.Printf("Попытка использовать токен 2FA как токен доступа")
+		return false, nil, ErrInvalidToken
+	}
+
+	// Check that session of token is still active
+	sessionID, ok := claims["sid"].(string)
+	if !ok {
+		log.Printf("В токене нет идентификатора сессии")
+		return false, nil, ErrInvalidToken
+	}
+
+	active, err := as.sessions.IsActive(sessionID)
+	if err != nil {
+		log.Printf("Ошибка при проверке сессии: %v", err)
+		return false, nil, err
+	}
+	if !active {
+		log.Printf("Сессия %s отозвана или истекла, jti: %v", sessionID, claims["jti"])
+		return false, nil, ErrInvalidToken
 	}
 
 	log.Printf("Токен валиден")
 	return true, claims, nil
 }
 
+// GetCurrentUser retrieves account of authenticated user
+func (as *AuthService) GetCurrentUser(userID int) (models.User, error) {
+	user, err := as.repo.GetByID(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d: %v", userID, err)
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetJWKS returns public keys that JWTs issued by service can be verified with
+func (as *AuthService) GetJWKS() models.JWKS {
+	return as.keys.JWKS()
+}
+
 // checkToken parses and validates JWT
-// It verifies token's signature and checks expiration claim
-func checkToken(tokenString string) (bool, jwt.MapClaims, error) {
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(os.Getenv("SECRET_KEY")), nil
-	})
+// It verifies token's signature with key named in kid header, checks iss and aud claims
+// and checks exp, nbf and iat claims against current time
+func (as *AuthService) checkToken(tokenString string) (bool, jwt.MapClaims, error) {
+	// Parse token, jwt-go validates exp, nbf and iat claims
+	token, err := as.keys.parse(tokenString)
 	if err != nil {
 		log.Printf("Ошибка при разборе токена: %v", err)
 		return false, nil, err
@@ -112,6 +232,12 @@ func checkToken(tokenString string) (bool, jwt.MapClaims, error) {
 		return false, nil, nil
 	}
 
+	// Check that token was issued by this service for its audience
+	if !claims.VerifyIssuer(as.jwtIssuer, true) || !claims.VerifyAudience(as.jwtAudience, true) {
+		log.Printf("Некорректный издатель или получатель токена: %v, %v", claims["iss"], claims["aud"])
+		return false, nil, nil
+	}
+
 	// Check if expiration claim exists and validate it
 	expiration, ok := claims["exp"].(float64)
 	if !ok {
@@ -127,20 +253,41 @@ func checkToken(tokenString string) (bool, jwt.MapClaims, error) {
 	return true, claims, nil
 }
 
-// generateJWT generates JWT for provided user with 24-hour expiration time
-func generateJWT(user models.User) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// newClaims returns standard claims of JWT for user with given lifetime, issued now
+// Each token gets unique jti
+func (as *AuthService) newClaims(user models.User, ttl time.Duration) (jwt.MapClaims, error) {
+	jti, err := generateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
 
-	// Set standard claims
-	claims := token.Claims.(jwt.MapClaims)
-	claims["id"] = user.ID
-	claims["sub"] = user.Name
+	now := time.Now()
+	return jwt.MapClaims{
+		"id":  user.ID,
+		"sub": user.Name,
+		"jti": jti,
+		"iss": as.jwtIssuer,
+		"aud": as.jwtAudience,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}, nil
+}
+
+// generateJWT generates access JWT for provided user and session with given lifetime
+// sid links token to session so that it can be revoked, role is user's role
+func (as *AuthService) generateJWT(user models.User, sessionID string, ttl time.Duration) (string, error) {
+	claims, err := as.newClaims(user, ttl)
+	if err != nil {
+		return "", err
+	}
 
 	// Add additional claims
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	claims["sid"] = sessionID
+	claims["role"] = user.Role
 
-	// Sign token with secret key
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+	// Sign token with current signing key
+	tokenString, err := as.keys.sign(claims)
 	if err != nil {
 		log.Printf("Ошибка при подписании токена: %v", err)
 		return "", err
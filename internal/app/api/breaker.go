@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"rest-notes/internal/app/metrics"
+	"rest-notes/internal/app/models"
+)
+
+var ErrSpellerUnavailable = errors.New("сервис проверки орфографии недоступен")
+
+// Circuit breaker states
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+var breakerTrips = metrics.NewCounter("speller_breaker_trips_total", "Times circuit breaker of speller opened")
+
+// CircuitBreakerSpellChecker stops calling spell checker after threshold consecutive failures
+// Only network errors and 5xx responses are failures: rejected request shows that speller is up
+// While circuit is open for cooldown, calls fail immediately: with ErrSpellerUnavailable,
+// or with nil issues and no error if failOpen is set so that text is accepted unchecked
+// After cooldown single trial call is let through, its result closes or reopens circuit
+type CircuitBreakerSpellChecker struct {
+	checker   SpellChecker
+	threshold int
+	cooldown  time.Duration
+	failOpen  bool
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreakerSpellChecker wraps checker with circuit breaker
+func NewCircuitBreakerSpellChecker(checker SpellChecker, threshold int, cooldown time.Duration, failOpen bool) *CircuitBreakerSpellChecker {
+	cb := &CircuitBreakerSpellChecker{
+		checker:   checker,
+		threshold: threshold,
+		cooldown:  cooldown,
+		failOpen:  failOpen,
+	}
+	metrics.NewGaugeFunc("speller_breaker_state", "Circuit breaker state of speller: 0 closed, 1 open, 2 half-open", func() float64 {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		return float64(cb.state)
+	})
+	return cb
+}
+
+// CheckText checks text with wrapped checker unless circuit is open
+func (cb *CircuitBreakerSpellChecker) CheckText(text, lang string, options int) ([]models.SpellingIssue, error) {
+	if !cb.allow() {
+		if cb.failOpen {
+			return nil, nil
+		}
+		return nil, ErrSpellerUnavailable
+	}
+
+	issues, err := cb.checker.CheckText(text, lang, options)
+	cb.record(err != nil && isRetryable(err))
+	if err != nil {
+		if cb.failOpen {
+			log.Printf("Текст сохраняется без проверки орфографии: %v", err)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// allow reports whether call may go to wrapped checker, moving open circuit to half-open after cooldown
+func (cb *CircuitBreakerSpellChecker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		log.Printf("Пробный запрос к сервису проверки орфографии")
+		cb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// Only one trial call at a time
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates circuit state with result of call, failed means that speller is unavailable
+func (cb *CircuitBreakerSpellChecker) record(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !failed {
+		if cb.state != breakerClosed {
+			log.Printf("Сервис проверки орфографии снова доступен")
+		}
+		cb.state = breakerClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		if cb.state != breakerOpen {
+			breakerTrips.Inc()
+		}
+		log.Printf("Сервис проверки орфографии недоступен после %d ошибок, запросы приостановлены на %v", cb.failures, cb.cooldown)
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOpen bool
+	}{
+		{"fail closed", false},
+		{"fail open", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, url := newFlakySpeller(t)
+			breaker := NewCircuitBreakerSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 2, time.Hour,
+				tt.failOpen)
+
+			fake.fail(2, http.StatusServiceUnavailable)
+			for i := 0; i < 2; i++ {
+				issues, err := breaker.CheckText("привет", "ru", 0)
+				if tt.failOpen && (issues != nil || err != nil) {
+					t.Fatalf("failed call returned %v, %v, want text let through unchecked", issues, err)
+				}
+				if !tt.failOpen && err == nil {
+					t.Fatalf("failed call returned no error")
+				}
+			}
+
+			// Circuit is open, speller is not called even though it is up again
+			issues, err := breaker.CheckText("привет", "ru", 0)
+			if tt.failOpen {
+				if issues != nil || err != nil {
+					t.Errorf("call with open circuit returned %v, %v, want text let through unchecked", issues, err)
+				}
+			} else if !errors.Is(err, ErrSpellerUnavailable) {
+				t.Errorf("call with open circuit returned error %v, want ErrSpellerUnavailable", err)
+			}
+			if requests := fake.requests.Load(); requests != 2 {
+				t.Errorf("speller got %d requests, want 2", requests)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresRejectedRequests(t *testing.T) {
+	fake, url := newFlakySpeller(t)
+	breaker := NewCircuitBreakerSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 2, time.Hour, false)
+
+	// Speller rejecting requests is up, so circuit stays closed
+	fake.fail(5, http.StatusBadRequest)
+	for i := 0; i < 5; i++ {
+		if _, err := breaker.CheckText("привет", "ru", 0); errors.Is(err, ErrSpellerUnavailable) {
+			t.Fatalf("call %d: circuit opened after rejected requests", i+1)
+		}
+	}
+	if requests := fake.requests.Load(); requests != 5 {
+		t.Errorf("speller got %d requests, want 5", requests)
+	}
+
+	// Rejected request between failures resets count of consecutive failures
+	fake.fail(1, http.StatusBadGateway)
+	breaker.CheckText("привет", "ru", 0)
+	fake.fail(1, http.StatusBadRequest)
+	breaker.CheckText("привет", "ru", 0)
+	fake.fail(1, http.StatusBadGateway)
+	breaker.CheckText("привет", "ru", 0)
+	if _, err := breaker.CheckText("привет", "ru", 0); err != nil {
+		t.Errorf("circuit opened after failures that are not consecutive: %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+
+	fake, url := newFlakySpeller(t)
+	breaker := NewCircuitBreakerSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 1, cooldown, false)
+
+	fake.fail(1, http.StatusServiceUnavailable)
+	if _, err := breaker.CheckText("привет", "ru", 0); err == nil {
+		t.Fatal("failed call returned no error")
+	}
+
+	// Failed trial call opens circuit again for whole cooldown
+	time.Sleep(cooldown + 10*time.Millisecond)
+	fake.fail(1, http.StatusServiceUnavailable)
+	if _, err := breaker.CheckText("привет", "ru", 0); err == nil || errors.Is(err, ErrSpellerUnavailable) {
+		t.Fatalf("trial call returned error %v, want error of speller", err)
+	}
+	if _, err := breaker.CheckText("привет", "ru", 0); !errors.Is(err, ErrSpellerUnavailable) {
+		t.Fatalf("call after failed trial returned error %v, want ErrSpellerUnavailable", err)
+	}
+
+	// Only one trial call goes to speller while circuit is half-open
+	time.Sleep(cooldown + 10*time.Millisecond)
+	fake.hold.Store(true)
+	trial := make(chan error)
+	go func() {
+		_, err := breaker.CheckText("привет", "ru", 0)
+		trial <- err
+	}()
+	for fake.requests.Load() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := breaker.CheckText("привет", "ru", 0); !errors.Is(err, ErrSpellerUnavailable) {
+		t.Errorf("call during trial returned error %v, want ErrSpellerUnavailable", err)
+	}
+
+	// Successful trial call closes circuit
+	fake.hold.Store(false)
+	close(fake.release)
+	if err := <-trial; err != nil {
+		t.Fatalf("trial call failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := breaker.CheckText("привет", "ru", 0); err != nil {
+			t.Fatalf("call after successful trial failed: %v", err)
+		}
+	}
+	if requests := fake.requests.Load(); requests != 6 {
+		t.Errorf("speller got %d requests, want 6", requests)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"rest-notes/internal/app/metrics"
+	"rest-notes/internal/app/models"
+)
+
+var (
+	spellerCacheHits   = metrics.NewCounter("speller_cache_hits_total", "Spell check results served from cache")
+	spellerCacheMisses = metrics.NewCounter("speller_cache_misses_total", "Spell check results missing in cache")
+)
+
+// CachedSpellChecker caches results of spell checker in LRU cache with limited size and entry lifetime
+// so that re-saving unchanged text doesn't call checker again
+type CachedSpellChecker struct {
+	checker SpellChecker
+	size    int
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// cacheEntry is cached result of checking text
+type cacheEntry struct {
+	key       string
+	issues    []models.SpellingIssue
+	expiresAt time.Time
+}
+
+// NewCachedSpellChecker wraps checker with cache holding up to size results for ttl
+func NewCachedSpellChecker(checker SpellChecker, size int, ttl time.Duration) *CachedSpellChecker {
+	c := &CachedSpellChecker{
+		checker: checker,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+	metrics.NewGaugeFunc("speller_cache_entries", "Spell check results in cache", func() float64 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return float64(c.order.Len())
+	})
+	return c
+}
+
+// CheckText returns cached result for text, language and options or checks text and caches result
+// Results of unchecked text are not cached
+func (c *CachedSpellChecker) CheckText(text, lang string, options int) ([]models.SpellingIssue, error) {
+	key := cacheKey(text, lang, options)
+	if issues, ok := c.get(key); ok {
+		spellerCacheHits.Inc()
+		return issues, nil
+	}
+	spellerCacheMisses.Inc()
+
+	issues, err := c.checker.CheckText(text, lang, options)
+	if err != nil {
+		return nil, err
+	}
+	if issues != nil {
+		c.put(key, issues)
+	}
+
+	return issues, nil
+}
+
+// get returns copy of cached issues, dropping expired entry
+func (c *CachedSpellChecker) get(key string) ([]models.SpellingIssue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return copyIssues(entry.issues), true
+}
+
+// put stores issues in cache, evicting least recently used entries over size
+func (c *CachedSpellChecker) put(key string, issues []models.SpellingIssue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, issues: copyIssues(issues), expiresAt: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey identifies check of text by hash of text, language and options
+func cacheKey(text, lang string, options int) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:]) + "|" + lang + "|" + strconv.Itoa(options)
+}
+
+// copyIssues copies issues so that callers can't modify cached result
+func copyIssues(issues []models.SpellingIssue) []models.SpellingIssue {
+	copied := make([]models.SpellingIssue, len(issues))
+	for i, issue := range issues {
+		issue.Suggestions = append([]string(nil), issue.Suggestions...)
+		copied[i] = issue
+	}
+	return copied
+}
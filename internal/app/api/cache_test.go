@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCachedSpellCheckerHits(t *testing.T) {
+	fake, url := newFlakySpeller(t)
+	cache := NewCachedSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 10, time.Hour)
+
+	first, err := cache.CheckText("превет как дела", "ru", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("CheckText returned %+v, want single issue", first)
+	}
+
+	// Changing returned result does not change cached one
+	first[0].Word = "изменено"
+	first[0].Suggestions = append(first[0].Suggestions, "изменено")
+
+	second, err := cache.CheckText("превет как дела", "ru", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second[0].Word != "превет" || reflect.DeepEqual(second[0].Suggestions, first[0].Suggestions) {
+		t.Errorf("cached result was changed by caller: %+v", second)
+	}
+	if requests := fake.requests.Load(); requests != 1 {
+		t.Errorf("speller got %d requests, want 1", requests)
+	}
+
+	// Language and options are part of cache key
+	cache.CheckText("превет как дела", "ru,en", 0)
+	cache.CheckText("превет как дела", "ru", 8)
+	if requests := fake.requests.Load(); requests != 3 {
+		t.Errorf("speller got %d requests, want 3", requests)
+	}
+}
+
+func TestCachedSpellCheckerExpiry(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+
+	fake, url := newFlakySpeller(t)
+	cache := NewCachedSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 10, ttl)
+
+	cache.CheckText("привет", "ru", 0)
+	cache.CheckText("привет", "ru", 0)
+	if requests := fake.requests.Load(); requests != 1 {
+		t.Fatalf("speller got %d requests before expiry, want 1", requests)
+	}
+
+	time.Sleep(ttl + 10*time.Millisecond)
+	cache.CheckText("привет", "ru", 0)
+	cache.CheckText("привет", "ru", 0)
+	if requests := fake.requests.Load(); requests != 2 {
+		t.Errorf("speller got %d requests after expiry, want 2", requests)
+	}
+}
+
+func TestCachedSpellCheckerEviction(t *testing.T) {
+	fake, url := newFlakySpeller(t)
+	cache := NewCachedSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 2, time.Hour)
+
+	// "как" is used recently, so "дела" is least recently used when "привет" is added
+	for _, text := range []string{"как", "дела", "как", "привет"} {
+		cache.CheckText(text, "ru", 0)
+	}
+	if requests := fake.requests.Load(); requests != 3 {
+		t.Fatalf("speller got %d requests, want 3", requests)
+	}
+
+	cache.CheckText("как", "ru", 0)
+	cache.CheckText("привет", "ru", 0)
+	if requests := fake.requests.Load(); requests != 3 {
+		t.Errorf("cached texts were checked again, speller got %d requests", requests)
+	}
+	cache.CheckText("дела", "ru", 0)
+	if requests := fake.requests.Load(); requests != 4 {
+		t.Errorf("evicted text was not checked again, speller got %d requests", requests)
+	}
+}
+
+func TestCachedSpellCheckerSkipsUncheckedText(t *testing.T) {
+	fake, url := newFlakySpeller(t)
+	breaker := NewCircuitBreakerSpellChecker(NewSpellerService(url, 5*time.Second, 0, 0, 1), 5, time.Hour, true)
+	cache := NewCachedSpellChecker(breaker, 10, time.Hour)
+
+	// Text let through unchecked by failing-open breaker is checked again next time
+	fake.fail(1, http.StatusServiceUnavailable)
+	if issues, err := cache.CheckText("превет", "ru", 0); issues != nil || err != nil {
+		t.Fatalf("CheckText returned %v, %v, want text let through unchecked", issues, err)
+	}
+	issues, err := cache.CheckText("превет", "ru", 0)
+	if err != nil || len(issues) != 1 {
+		t.Fatalf("CheckText returned %v, %v, want single issue", issues, err)
+	}
+	if requests := fake.requests.Load(); requests != 2 {
+		t.Errorf("speller got %d requests, want 2", requests)
+	}
+}
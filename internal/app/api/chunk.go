@@ -0,0 +1,172 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"rest-notes/internal/app/models"
+)
+
+// maxSpellerTextLength is maximum number of characters Speller API checks in one request
+const maxSpellerTextLength = 10000
+
+// textChunk is part of long text checked separately
+// pos is offset of chunk in characters, row and col are line and column where it starts
+type textChunk struct {
+	text string
+	pos  int
+	row  int
+	col  int
+}
+
+// remap converts position of issue found in chunk to position in whole text
+func (c textChunk) remap(issue models.SpellingIssue) models.SpellingIssue {
+	if issue.Row == 0 {
+		issue.Col += c.col
+	}
+	issue.Pos += c.pos
+	issue.Row += c.row
+	return issue
+}
+
+// checkChunked splits long text into chunks, packs them into batches that fit into single request
+// and checks batches concurrently with at most concurrency requests at a time
+func (s *SpellerService) checkChunked(text, lang string, options int) ([]models.SpellingIssue, error) {
+	chunks := splitText(text, maxSpellerTextLength)
+	batches := batchChunks(chunks, maxSpellerTextLength)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		issues   = make([]models.SpellingIssue, 0)
+		firstErr error
+	)
+	jobs := make(chan []textChunk)
+	for i := 0; i < s.concurrency && i < len(batches); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range jobs {
+				texts := make([]string, len(batch))
+				for j, chunk := range batch {
+					texts[j] = chunk.text
+				}
+
+				results, err := s.request(texts, lang, options)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					for j, chunk := range batch {
+						issues = append(issues, toSpellingIssues(results[j], chunk)...)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, batch := range batches {
+		jobs <- batch
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Pos < issues[j].Pos
+	})
+	return issues, nil
+}
+
+// splitText splits text into paragraphs, paragraphs longer than limit are split on sentence ends,
+// then on spaces, and only words longer than limit are cut
+func splitText(text string, limit int) []textChunk {
+	runes := []rune(text)
+
+	var chunks []textChunk
+	row, col := 0, 0
+	for start := 0; start < len(runes); {
+		end := chunkEnd(runes, start, limit)
+		chunks = append(chunks, textChunk{text: string(runes[start:end]), pos: start, row: row, col: col})
+
+		for _, r := range runes[start:end] {
+			if r == '\n' {
+				row++
+				col = 0
+			} else {
+				col++
+			}
+		}
+		start = end
+	}
+
+	return chunks
+}
+
+// chunkEnd finds where chunk starting at start ends: after end of paragraph if it fits into limit,
+// otherwise after last sentence end or space before limit
+func chunkEnd(runes []rune, start, limit int) int {
+	end := start + limit
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	for i := start; i < end; i++ {
+		if runes[i] == '\n' {
+			return i + 1
+		}
+	}
+	if end == len(runes) {
+		return end
+	}
+
+	lastSpace := -1
+	for i := end - 1; i > start; i-- {
+		if !unicode.IsSpace(runes[i]) {
+			continue
+		}
+		if strings.ContainsRune(".!?…", runes[i-1]) {
+			return i + 1
+		}
+		if lastSpace < 0 {
+			lastSpace = i + 1
+		}
+	}
+	if lastSpace > 0 {
+		return lastSpace
+	}
+
+	return end
+}
+
+// batchChunks groups consecutive chunks into batches with total length within limit,
+// so that short paragraphs are checked together in one request
+func batchChunks(chunks []textChunk, limit int) [][]textChunk {
+	var batches [][]textChunk
+	var batch []textChunk
+	length := 0
+	for _, chunk := range chunks {
+		chunkLength := utf8.RuneCountInString(chunk.text)
+		if len(batch) > 0 && length+chunkLength > limit {
+			batches = append(batches, batch)
+			batch, length = nil, 0
+		}
+		batch = append(batch, chunk)
+		length += chunkLength
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/spellertest"
+)
+
+// wordIssues reports every word of text as issue, positions are counted the way Speller API counts them
+func wordIssues(text string) []models.SpellingIssue {
+	issues := make([]models.SpellingIssue, 0)
+	for _, token := range tokenizeWords(text) {
+		issues = append(issues, token.issue(models.SpellingUnknownWord, []string{}))
+	}
+	return issues
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		limit     int
+		cutsWords bool
+	}{
+		{"paragraphs", "one two\nthree four\n\nfive", 100, false},
+		{"line longer than limit", "alpha beta. gamma delta epsilon\nzeta eta theta iota kappa", 12, false},
+		{"multi-byte runes at boundaries", "привет мир. ещё одна строка здесь\nи ещё", 7, false},
+		{"CRLF line breaks", "первая строка\r\nвторая строка\r\n\r\nтретья строка", 10, false},
+		{"runes outside BMP", "🦔ёж 🦔ёж 🦔ёж\n🦔 ёж", 5, false},
+		{"word longer than limit", "abcdefghij klm", 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitText(tt.text, tt.limit)
+
+			var joined strings.Builder
+			for _, chunk := range chunks {
+				if length := utf8.RuneCountInString(chunk.text); length == 0 || length > tt.limit {
+					t.Errorf("chunk %q has %d characters, limit is %d", chunk.text, length, tt.limit)
+				}
+				if pos := utf8.RuneCountInString(joined.String()); chunk.pos != pos {
+					t.Errorf("chunk %q has pos %d, want %d", chunk.text, chunk.pos, pos)
+				}
+				joined.WriteString(chunk.text)
+			}
+			if joined.String() != tt.text {
+				t.Fatalf("chunks join into %q, want %q", joined.String(), tt.text)
+			}
+			if tt.cutsWords {
+				return
+			}
+
+			// Issues found in chunks and moved to whole text must be the same as issues found in whole text
+			chunked := make([]models.SpellingIssue, 0)
+			for _, chunk := range chunks {
+				for _, issue := range wordIssues(chunk.text) {
+					chunked = append(chunked, chunk.remap(issue))
+				}
+			}
+			if want := wordIssues(tt.text); !reflect.DeepEqual(chunked, want) {
+				t.Errorf("chunked issues = %+v\nwant %+v", chunked, want)
+			}
+		})
+	}
+}
+
+func TestCheckChunkedPositions(t *testing.T) {
+	server := spellertest.NewServer([]string{"как", "всё", "хорошо", "слово", "word", "ёжик"})
+	defer server.Close()
+	speller := NewSpellerService(server.URL, 5*time.Second, 0, 0, 4)
+
+	var text strings.Builder
+	text.WriteString(strings.Repeat("Превет, как дила? Всё хорошо.\r\n", 200))
+	text.WriteString(strings.Repeat("слово ашибка word ёжик 🦔ежик ", 500) + "\n")
+	text.WriteString(strings.Repeat("как дила\n\n", 300))
+	text.WriteString("последняя строка без перевода")
+
+	tests := []struct {
+		name    string
+		options int
+	}{
+		{"default options", 0},
+		{"repeat words", models.SpellerFindRepeatWords},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if chunks := splitText(text.String(), maxSpellerTextLength); len(chunks) < 2 {
+				t.Fatalf("text is not long enough to be chunked")
+			}
+
+			chunked, err := speller.CheckText(text.String(), "ru", tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Fake speller has no length limit, so whole text can be checked in one request
+			results, err := speller.request([]string{text.String()}, "ru", tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			whole := toSpellingIssues(results[0], textChunk{})
+
+			if len(whole) == 0 {
+				t.Fatalf("text has no mistakes to compare")
+			}
+			if len(chunked) != len(whole) {
+				t.Fatalf("chunked check found %d issues, whole text has %d", len(chunked), len(whole))
+			}
+			for i := range whole {
+				if !reflect.DeepEqual(chunked[i], whole[i]) {
+					t.Fatalf("issue %d of chunked check is %+v, want %+v", i, chunked[i], whole[i])
+				}
+			}
+		})
+	}
+}
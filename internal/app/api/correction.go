@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"rest-notes/internal/app/models"
+)
+
+var ErrEmptyText = errors.New("текст для проверки пуст")
+
+// CheckSpelling checks arbitrary text with user's speller options and dictionary
+// Language is detected from text if lang is empty
+func (ss *SpellingService) CheckSpelling(userID int, text, lang string) ([]models.SpellingIssue, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyText
+	}
+
+	lang, err := normalizeLang(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := ss.GetSpellerSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.checkText(userID, settings, text, lang)
+}
+
+// CorrectSpelling replaces each mistake in text with its first suggestion
+// Returns corrected text and list of applied replacements
+func (ss *SpellingService) CorrectSpelling(userID int, text, lang string) (models.CorrectedText, error) {
+	issues, err := ss.CheckSpelling(userID, text, lang)
+	if err != nil {
+		return models.CorrectedText{}, err
+	}
+
+	corrected, corrections := applyCorrections(text, issues)
+
+	log.Printf("Исправлено %d ошибок в тексте пользователя ID: %d", len(corrections), userID)
+	return models.CorrectedText{Text: corrected, Corrections: corrections}, nil
+}
+
+// correctNote replaces mistakes in note title and description with first suggestions
+// Autocorrection is best effort: if spell checker fails, note is returned unchanged
+func (ss *SpellingService) correctNote(note models.Note) (models.Note, []models.SpellingCorrection) {
+	settings, err := ss.GetSpellerSettings(note.UserID)
+	if err != nil {
+		return note, nil
+	}
+
+	lang := note.Lang
+	if lang == "" {
+		lang = detectLang(note.Title + "\n" + note.Description)
+	}
+
+	fields := []struct {
+		name string
+		text *string
+	}{
+		{name: "title", text: &note.Title},
+		{name: "description", text: &note.Description},
+	}
+
+	corrections := make([]models.SpellingCorrection, 0)
+	for _, field := range fields {
+		if strings.TrimSpace(*field.text) == "" {
+			continue
+		}
+
+		issues, err := ss.checkText(note.UserID, settings, *field.text, lang)
+		if err != nil {
+			return note, nil
+		}
+
+		var fieldCorrections []models.SpellingCorrection
+		*field.text, fieldCorrections = applyCorrections(*field.text, issues)
+		for _, correction := range fieldCorrections {
+			correction.Field = field.name
+			corrections = append(corrections, correction)
+		}
+	}
+
+	return note, corrections
+}
+
+// applyCorrections replaces misspelled words in text with their first suggestions
+// Issues without suggestions, overlapping ones and ones whose position does not match the word are skipped
+func applyCorrections(text string, issues []models.SpellingIssue) (string, []models.SpellingCorrection) {
+	runes := []rune(text)
+
+	applicable := make([]models.SpellingIssue, 0, len(issues))
+	for _, issue := range issues {
+		if len(issue.Suggestions) == 0 || issue.Pos < 0 || issue.Pos+issue.Len > len(runes) {
+			continue
+		}
+		if string(runes[issue.Pos:issue.Pos+issue.Len]) != issue.Word {
+			continue
+		}
+		applicable = append(applicable, issue)
+	}
+	sort.SliceStable(applicable, func(i, j int) bool {
+		return applicable[i].Pos < applicable[j].Pos
+	})
+
+	var builder strings.Builder
+	corrections := make([]models.SpellingCorrection, 0, len(applicable))
+	last := 0
+	for _, issue := range applicable {
+		if issue.Pos < last {
+			continue
+		}
+		builder.WriteString(string(runes[last:issue.Pos]))
+		builder.WriteString(issue.Suggestions[0])
+		last = issue.Pos + issue.Len
+
+		corrections = append(corrections, models.SpellingCorrection{
+			Word:        issue.Word,
+			Replacement: issue.Suggestions[0],
+			Pos:         issue.Pos,
+		})
+	}
+	builder.WriteString(string(runes[last:]))
+
+	return builder.String(), corrections
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"rest-notes/internal/app/models"
+)
+
+func TestApplyCorrections(t *testing.T) {
+	issue := func(word string, pos int, suggestions ...string) models.SpellingIssue {
+		return models.SpellingIssue{Word: word, Pos: pos, Len: len([]rune(word)), Suggestions: suggestions}
+	}
+	correction := func(word, replacement string, pos int) models.SpellingCorrection {
+		return models.SpellingCorrection{Word: word, Replacement: replacement, Pos: pos}
+	}
+
+	tests := []struct {
+		name            string
+		text            string
+		issues          []models.SpellingIssue
+		wantText        string
+		wantCorrections []models.SpellingCorrection
+	}{
+		{
+			name:            "no issues",
+			text:            "всё верно",
+			wantText:        "всё верно",
+			wantCorrections: []models.SpellingCorrection{},
+		},
+		{
+			name:     "several issues in one line",
+			text:     "превет, как дила?",
+			issues:   []models.SpellingIssue{issue("превет", 0, "привет"), issue("дила", 12, "дела", "дыла")},
+			wantText: "привет, как дела?",
+			wantCorrections: []models.SpellingCorrection{
+				correction("превет", "привет", 0), correction("дила", "дела", 12),
+			},
+		},
+		{
+			name: "issues on several lines",
+			text: "Превет\nкак дила\nпака",
+			issues: []models.SpellingIssue{
+				issue("пака", 16, "пока"), issue("дила", 11, "дела"), issue("Превет", 0, "Привет"),
+			},
+			wantText: "Привет\nкак дела\nпока",
+			wantCorrections: []models.SpellingCorrection{
+				correction("Превет", "Привет", 0), correction("дила", "дела", 11), correction("пака", "пока", 16),
+			},
+		},
+		{
+			name:     "replacement of different length",
+			text:     "в обшем всё хорошо, в обшем",
+			issues:   []models.SpellingIssue{issue("обшем", 2, "общем целом"), issue("обшем", 22, "общем")},
+			wantText: "в общем целом всё хорошо, в общем",
+			wantCorrections: []models.SpellingCorrection{
+				correction("обшем", "общем целом", 2), correction("обшем", "общем", 22),
+			},
+		},
+		{
+			name:     "adjacent issues",
+			text:     "абвгд",
+			issues:   []models.SpellingIssue{issue("вгд", 2, "ВГД"), issue("аб", 0, "АБ")},
+			wantText: "АБВГД",
+			wantCorrections: []models.SpellingCorrection{
+				correction("аб", "АБ", 0), correction("вгд", "ВГД", 2),
+			},
+		},
+		{
+			name:            "overlapping issues",
+			text:            "абвгд",
+			issues:          []models.SpellingIssue{issue("абв", 0, "x"), issue("бвг", 1, "y"), issue("вгд", 2, "z")},
+			wantText:        "xгд",
+			wantCorrections: []models.SpellingCorrection{correction("абв", "x", 0)},
+		},
+		{
+			name:            "same issue twice",
+			text:            "малако",
+			issues:          []models.SpellingIssue{issue("малако", 0, "молоко"), issue("малако", 0, "малолетка")},
+			wantText:        "молоко",
+			wantCorrections: []models.SpellingCorrection{correction("малако", "молоко", 0)},
+		},
+		{
+			name:            "issue without suggestions",
+			text:            "абырвалг и превет",
+			issues:          []models.SpellingIssue{issue("абырвалг", 0), issue("превет", 11, "привет")},
+			wantText:        "абырвалг и привет",
+			wantCorrections: []models.SpellingCorrection{correction("превет", "привет", 11)},
+		},
+		{
+			name: "issue position does not match word",
+			text: "превет",
+			issues: []models.SpellingIssue{
+				issue("превет", 1, "привет"), issue("превет", -1, "привет"), issue("превет", 3, "привет"),
+			},
+			wantText:        "превет",
+			wantCorrections: []models.SpellingCorrection{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, corrections := applyCorrections(tt.text, tt.issues)
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(corrections, tt.wantCorrections) {
+				t.Errorf("corrections = %+v, want %+v", corrections, tt.wantCorrections)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+
+	"rest-notes/internal/app/models"
+)
+
+// maxDiffEdits limits number of edits diff algorithm searches for;
+// texts that differ more are reported as deleted and inserted entirely
+const maxDiffEdits = 2000
+
+// wordTokenRegexp splits text into words and whitespace runs so that tokens join back into original text
+var wordTokenRegexp = regexp.MustCompile(`\s+|\S+`)
+
+// diffText computes diff between two texts at line or word granularity
+func diffText(from, to, mode string) []models.DiffOp {
+	var a, b []string
+	if mode == models.DiffModeWord {
+		a, b = wordTokenRegexp.FindAllString(from, -1), wordTokenRegexp.FindAllString(to, -1)
+	} else {
+		a, b = splitLines(from), splitLines(to)
+	}
+	return diffTokens(a, b)
+}
+
+// splitLines splits text into lines keeping line breaks
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.SplitAfter(text, "\n")
+}
+
+// diffTokens computes shortest edit script between token sequences using Myers algorithm
+// Adjacent tokens with the same operation are joined into single fragment
+func diffTokens(a, b []string) []models.DiffOp {
+	n, m := len(a), len(b)
+
+	// trace[d] holds furthest x reached on diagonals -d..d after d edits
+	var trace [][]int
+	found := false
+	for d := 0; d <= n+m && d <= maxDiffEdits && !found; d++ {
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else if k == -d || (k != d && furthest(trace[d-1], d-1, k-1) < furthest(trace[d-1], d-1, k+1)) {
+				x = furthest(trace[d-1], d-1, k+1)
+			} else {
+				x = furthest(trace[d-1], d-1, k-1) + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+d] = x
+			if x >= n && y >= m {
+				found = true
+			}
+		}
+		trace = append(trace, v)
+	}
+
+	if !found {
+		var ops []models.DiffOp
+		ops = appendDiffOp(ops, models.DiffDelete, strings.Join(a, ""))
+		return appendDiffOp(ops, models.DiffInsert, strings.Join(b, ""))
+	}
+
+	// Walk trace backwards collecting operations in reverse order
+	var reversed []models.DiffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && furthest(trace[d-1], d-1, k-1) < furthest(trace[d-1], d-1, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := furthest(trace[d-1], d-1, prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, models.DiffOp{Op: models.DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, models.DiffOp{Op: models.DiffInsert, Text: b[y-1]})
+		} else {
+			reversed = append(reversed, models.DiffOp{Op: models.DiffDelete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, models.DiffOp{Op: models.DiffEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	ops := []models.DiffOp{}
+	for i := len(reversed) - 1; i >= 0; i-- {
+		ops = appendDiffOp(ops, reversed[i].Op, reversed[i].Text)
+	}
+	return ops
+}
+
+// furthest returns furthest x on diagonal k stored in trace row of step d
+func furthest(row []int, d, k int) int {
+	return row[k+d]
+}
+
+// appendDiffOp appends text to diff merging it with previous fragment of the same operation
+func appendDiffOp(ops []models.DiffOp, op, text string) []models.DiffOp {
+	if text == "" {
+		return ops
+	}
+	if len(ops) > 0 && ops[len(ops)-1].Op == op {
+		ops[len(ops)-1].Text += text
+		return ops
+	}
+	return append(ops, models.DiffOp{Op: op, Text: text})
+}
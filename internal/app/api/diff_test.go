@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rest-notes/internal/app/models"
+)
+
+// applyDiff rebuilds both texts from diff: old text from equal and deleted fragments, new one from equal and inserted
+func applyDiff(ops []models.DiffOp) (string, string) {
+	var from, to strings.Builder
+	for _, op := range ops {
+		if op.Op != models.DiffInsert {
+			from.WriteString(op.Text)
+		}
+		if op.Op != models.DiffDelete {
+			to.WriteString(op.Text)
+		}
+	}
+	return from.String(), to.String()
+}
+
+func TestDiffText(t *testing.T) {
+	equal := func(text string) models.DiffOp { return models.DiffOp{Op: models.DiffEqual, Text: text} }
+	insert := func(text string) models.DiffOp { return models.DiffOp{Op: models.DiffInsert, Text: text} }
+	remove := func(text string) models.DiffOp { return models.DiffOp{Op: models.DiffDelete, Text: text} }
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		mode string
+		want []models.DiffOp
+	}{
+		{"both empty", "", "", models.DiffModeLine, []models.DiffOp{}},
+		{"empty to non-empty", "", "a\nb\n", models.DiffModeLine, []models.DiffOp{insert("a\nb\n")}},
+		{"non-empty to empty", "a\nb\n", "", models.DiffModeLine, []models.DiffOp{remove("a\nb\n")}},
+		{"identical lines", "a\nb\nc", "a\nb\nc", models.DiffModeLine, []models.DiffOp{equal("a\nb\nc")}},
+		{"identical words", "one two", "one two", models.DiffModeWord, []models.DiffOp{equal("one two")}},
+		{"changed line", "a\nb\nc\n", "a\nx\nc\n", models.DiffModeLine,
+			[]models.DiffOp{equal("a\n"), remove("b\n"), insert("x\n"), equal("c\n")}},
+		{"inserted line", "a\nc\n", "a\nb\nc\n", models.DiffModeLine,
+			[]models.DiffOp{equal("a\n"), insert("b\n"), equal("c\n")}},
+		{"last line without break", "a\nb", "a\nb\n", models.DiffModeLine,
+			[]models.DiffOp{equal("a\n"), remove("b"), insert("b\n")}},
+		{"word in line mode replaces whole line", "встреча в понедельник", "встреча во вторник", models.DiffModeLine,
+			[]models.DiffOp{remove("встреча в понедельник"), insert("встреча во вторник")}},
+		{"word mode keeps unchanged words", "встреча в понедельник", "встреча во вторник", models.DiffModeWord,
+			[]models.DiffOp{equal("встреча "), remove("в"), insert("во"), equal(" "), remove("понедельник"),
+				insert("вторник")}},
+		{"word mode whitespace change", "a b", "a  b", models.DiffModeWord,
+			[]models.DiffOp{equal("a"), remove(" "), insert("  "), equal("b")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffText(tt.from, tt.to, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffText(%q, %q, %s) = %v, want %v", tt.from, tt.to, tt.mode, got, tt.want)
+			}
+			if from, to := applyDiff(got); from != tt.from || to != tt.to {
+				t.Errorf("diff rebuilds %q and %q, want %q and %q", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestDiffTokensShortestScript(t *testing.T) {
+	a := strings.Split("abcabba", "")
+	b := strings.Split("cbabac", "")
+
+	ops := diffTokens(a, b)
+	from, to := applyDiff(ops)
+	if from != "abcabba" || to != "cbabac" {
+		t.Fatalf("diff rebuilds %q and %q", from, to)
+	}
+
+	// Classic example of Myers paper has shortest edit script of 5 edits
+	edits := 0
+	for _, op := range ops {
+		if op.Op != models.DiffEqual {
+			edits += len(op.Text)
+		}
+	}
+	if edits != 5 {
+		t.Errorf("diff has %d edits, want 5: %v", edits, ops)
+	}
+}
+
+func TestDiffTokensEditLimit(t *testing.T) {
+	// Every line differs, so shortest script needs more edits than maxDiffEdits
+	lines := maxDiffEdits/2 + 1
+	var a, b []string
+	for i := 0; i < lines; i++ {
+		a = append(a, fmt.Sprintf("old %d\n", i))
+		b = append(b, fmt.Sprintf("new %d\n", i))
+	}
+
+	want := []models.DiffOp{
+		{Op: models.DiffDelete, Text: strings.Join(a, "")},
+		{Op: models.DiffInsert, Text: strings.Join(b, "")},
+	}
+	if got := diffTokens(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("diff over edit limit has %d fragments, want whole texts deleted and inserted", len(got))
+	}
+
+	// Texts just within limit still get real diff
+	a, b = a[:lines-1], b[:lines-1]
+	a = append(a, "same\n")
+	b = append(b, "same\n")
+	got := diffTokens(a, b)
+	if last := got[len(got)-1]; last.Op != models.DiffEqual || last.Text != "same\n" {
+		t.Errorf("diff within edit limit ends with %v, want equal line", last)
+	}
+}
@@ -0,0 +1,722 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"rest-notes/internal/app/models"
+)
+
+// maxSuggestions is maximum number of suggestions returned for misspelled word
+const maxSuggestions = 5
+
+// hunspellEncodings maps values of SET directive to decoders of 8-bit encodings
+var hunspellEncodings = map[string]encoding.Encoding{
+	"KOI8-R":           charmap.KOI8R,
+	"KOI8-U":           charmap.KOI8U,
+	"CP1251":           charmap.Windows1251,
+	"MICROSOFT-CP1251": charmap.Windows1251,
+	"ISO8859-1":        charmap.ISO8859_1,
+	"ISO8859-5":        charmap.ISO8859_5,
+	"ISO8859-15":       charmap.ISO8859_15,
+}
+
+// HunspellChecker is offline SpellChecker backed by dictionary in Hunspell format (.dic and .aff files)
+// It supports prefix and suffix rules with cross product, FLAG types, AF aliases, NEEDAFFIX, FORBIDDENWORD,
+// TRY and REP; compounding and morphological analysis are not supported
+// Dictionary is for single language, so lang passed to CheckText is ignored
+type HunspellChecker struct {
+	words        map[string][][]uint32
+	prefixes     map[string][]*affixRule
+	suffixes     map[string][]*affixRule
+	maxPrefixLen int
+	maxSuffixLen int
+	needAffix    uint32
+	forbidden    uint32
+	try          []rune
+	rep          [][2]string
+}
+
+// affixRule is single PFX or SFX rule of affix file
+type affixRule struct {
+	flag   uint32
+	cross  bool
+	strip  string
+	add    string
+	cond   []affixCondition
+	suffix bool
+}
+
+// affixCondition is single character position of affix rule condition
+type affixCondition struct {
+	any    bool
+	negate bool
+	chars  string
+}
+
+// affixParser holds state needed while reading affix and dictionary files
+type affixParser struct {
+	flagType string
+	aliases  [][]uint32
+	cross    map[string]bool
+}
+
+// NewHunspellChecker loads Hunspell dictionary from given .dic and .aff files
+func NewHunspellChecker(dicPath, affPath string) (*HunspellChecker, error) {
+	affData, err := os.ReadFile(affPath)
+	if err != nil {
+		return nil, err
+	}
+	dicData, err := os.ReadFile(dicPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both files use encoding declared in affix file
+	if enc, ok := hunspellEncodings[strings.ToUpper(affixEncoding(affData))]; ok {
+		if affData, err = enc.NewDecoder().Bytes(affData); err != nil {
+			return nil, err
+		}
+		if dicData, err = enc.NewDecoder().Bytes(dicData); err != nil {
+			return nil, err
+		}
+	}
+
+	h := &HunspellChecker{
+		words:    make(map[string][][]uint32),
+		prefixes: make(map[string][]*affixRule),
+		suffixes: make(map[string][]*affixRule),
+	}
+	p := &affixParser{cross: make(map[string]bool)}
+
+	if err = h.loadAffixes(p, affData); err != nil {
+		return nil, fmt.Errorf("%s: %w", affPath, err)
+	}
+	if err = h.loadWords(p, dicData); err != nil {
+		return nil, fmt.Errorf("%s: %w", dicPath, err)
+	}
+
+	return h, nil
+}
+
+// CheckText checks every word of text against dictionary
+// Hyphenated word is accepted if it is in dictionary as a whole or all of its parts are; words with digits are skipped
+// Options SpellerIgnoreURLs, SpellerFindRepeatWords and SpellerIgnoreCapitalization are supported
+// lang is ignored: text is always checked against loaded dictionary, words of other languages are reported unknown
+func (h *HunspellChecker) CheckText(text, lang string, options int) ([]models.SpellingIssue, error) {
+	if options&models.SpellerIgnoreURLs != 0 {
+		text = maskURLs(text)
+	}
+	ignoreCase := options&models.SpellerIgnoreCapitalization != 0
+	findRepeats := options&models.SpellerFindRepeatWords != 0
+
+	runes := []rune(text)
+	issues := make([]models.SpellingIssue, 0)
+	tokens := tokenizeWords(text)
+	for i, token := range tokens {
+		// Same word repeated with only spaces between is reported as repeat
+		if findRepeats && i > 0 {
+			previous := tokens[i-1]
+			between := string(runes[previous.pos+previous.length : token.pos])
+			if strings.EqualFold(previous.word, token.word) && strings.TrimSpace(between) == "" {
+				issues = append(issues, token.issue(models.SpellingRepeatWord, []string{}))
+			}
+		}
+
+		if h.checkToken(token.word, ignoreCase) {
+			continue
+		}
+		issues = append(issues, token.issue(models.SpellingUnknownWord, h.suggest(token.word)))
+	}
+	return issues, nil
+}
+
+// checkToken checks word found in text
+func (h *HunspellChecker) checkToken(word string, ignoreCase bool) bool {
+	for _, r := range word {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	if h.checkWord(word, ignoreCase) {
+		return true
+	}
+
+	parts := strings.Split(word, "-")
+	if len(parts) == 1 {
+		return false
+	}
+	for _, part := range parts {
+		if !h.checkWord(part, ignoreCase) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWord checks single word taking capitalization into account
+// Capitalized and upper case words are also accepted when dictionary has them in lower or title case;
+// if ignoreCase is set, lower case words are also accepted when dictionary has them capitalized
+func (h *HunspellChecker) checkWord(word string, ignoreCase bool) bool {
+	if h.lookup(word) {
+		return true
+	}
+
+	lower := strings.ToLower(word)
+	if lower != word && h.lookup(lower) {
+		return true
+	}
+
+	title := toTitle(lower)
+	if title != word && (lower != word || ignoreCase) && h.lookup(title) {
+		return true
+	}
+
+	upper := strings.ToUpper(word)
+	return ignoreCase && upper != word && h.lookup(upper)
+}
+
+// lookup checks whether word is dictionary root or can be produced from root by affix rules
+func (h *HunspellChecker) lookup(word string) bool {
+	for _, flags := range h.words[word] {
+		if hasFlag(flags, h.forbidden) {
+			return false
+		}
+		if !hasFlag(flags, h.needAffix) {
+			return true
+		}
+	}
+
+	// Strip suffix, optionally together with cross product prefix
+	for i := len(word); i >= 0 && len(word)-i <= h.maxSuffixLen; i-- {
+		if i < len(word) && !utf8.RuneStart(word[i]) {
+			continue
+		}
+		for _, rule := range h.suffixes[word[i:]] {
+			stem := word[:i] + rule.strip
+			if !rule.matches(stem) {
+				continue
+			}
+			if h.hasRoot(stem, rule.flag, 0) {
+				return true
+			}
+			if rule.cross && h.lookupPrefixed(stem, rule.flag) {
+				return true
+			}
+		}
+	}
+
+	return h.lookupPrefixed(word, 0)
+}
+
+// lookupPrefixed strips prefix from word and checks that root has prefix flag
+// If suffixFlag is not zero, only cross product prefixes are used and root must also have suffixFlag
+func (h *HunspellChecker) lookupPrefixed(word string, suffixFlag uint32) bool {
+	for i := 0; i <= len(word) && i <= h.maxPrefixLen; i++ {
+		if i < len(word) && !utf8.RuneStart(word[i]) {
+			continue
+		}
+		for _, rule := range h.prefixes[word[:i]] {
+			if suffixFlag != 0 && !rule.cross {
+				continue
+			}
+			stem := rule.strip + word[i:]
+			if rule.matches(stem) && h.hasRoot(stem, rule.flag, suffixFlag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasRoot reports whether stem is dictionary word with given flags
+func (h *HunspellChecker) hasRoot(stem string, flag, otherFlag uint32) bool {
+	for _, flags := range h.words[stem] {
+		if hasFlag(flags, h.forbidden) {
+			continue
+		}
+		if hasFlag(flags, flag) && (otherFlag == 0 || hasFlag(flags, otherFlag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns up to maxSuggestions correct words close to misspelled one
+// Candidates are built from REP replacements and single edits with TRY characters
+func (h *HunspellChecker) suggest(word string) []string {
+	suggestions := make([]string, 0, maxSuggestions)
+	seen := map[string]bool{word: true}
+
+	lower := strings.ToLower(word)
+	capitalized := lower != word
+
+	add := func(candidate string) bool {
+		if seen[candidate] {
+			return false
+		}
+		seen[candidate] = true
+		if !h.checkWord(candidate, false) {
+			return false
+		}
+		if capitalized {
+			candidate = toTitle(candidate)
+		}
+		suggestions = append(suggestions, candidate)
+		return len(suggestions) == maxSuggestions
+	}
+
+	for _, rep := range h.rep {
+		for i := strings.Index(lower, rep[0]); i >= 0; {
+			if add(lower[:i] + rep[1] + lower[i+len(rep[0]):]) {
+				return suggestions
+			}
+			next := strings.Index(lower[i+1:], rep[0])
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+
+	runes := []rune(lower)
+
+	// Swap adjacent characters
+	for i := 0; i+1 < len(runes); i++ {
+		candidate := append([]rune{}, runes...)
+		candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+		if add(string(candidate)) {
+			return suggestions
+		}
+	}
+
+	// Delete one character
+	for i := range runes {
+		if add(string(runes[:i]) + string(runes[i+1:])) {
+			return suggestions
+		}
+	}
+
+	// Replace one character
+	for i := range runes {
+		for _, r := range h.try {
+			if r == runes[i] {
+				continue
+			}
+			if add(string(runes[:i]) + string(r) + string(runes[i+1:])) {
+				return suggestions
+			}
+		}
+	}
+
+	// Insert one character
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range h.try {
+			if add(string(runes[:i]) + string(r) + string(runes[i:])) {
+				return suggestions
+			}
+		}
+	}
+
+	return suggestions
+}
+
+// loadAffixes parses affix file
+func (h *HunspellChecker) loadAffixes(p *affixParser, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "FLAG":
+			p.flagType = fields[1]
+		case "TRY":
+			h.try = []rune(fields[1])
+		case "NEEDAFFIX", "PSEUDOROOT":
+			h.needAffix = p.parseFlag(fields[1])
+		case "FORBIDDENWORD":
+			h.forbidden = p.parseFlag(fields[1])
+		case "REP":
+			// First REP line holds number of replacements
+			if len(fields) >= 3 {
+				from := strings.ReplaceAll(fields[1], "_", " ")
+				to := strings.ReplaceAll(fields[2], "_", " ")
+				h.rep = append(h.rep, [2]string{from, to})
+			}
+		case "AF":
+			if len(p.aliases) == 0 {
+				// First AF line holds number of aliases, aliases are numbered from 1
+				p.aliases = append(p.aliases, nil)
+				if _, err := strconv.Atoi(fields[1]); err == nil {
+					continue
+				}
+			}
+			p.aliases = append(p.aliases, p.decodeFlags(fields[1]))
+		case "PFX", "SFX":
+			if err := h.parseAffixLine(p, fields); err != nil {
+				return fmt.Errorf("строка %d: %w", line, err)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseAffixLine parses PFX or SFX header or rule
+func (h *HunspellChecker) parseAffixLine(p *affixParser, fields []string) error {
+	suffix := fields[0] == "SFX"
+
+	// Header: PFX flag cross_product count
+	if len(fields) == 4 && (fields[2] == "Y" || fields[2] == "N") {
+		if _, err := strconv.Atoi(fields[3]); err == nil {
+			p.cross[fields[0]+fields[1]] = fields[2] == "Y"
+			return nil
+		}
+	}
+
+	// Rule: PFX flag strip add[/flags] [condition]
+	if len(fields) < 4 {
+		return fmt.Errorf("неправильное правило %s", strings.Join(fields, " "))
+	}
+
+	rule := &affixRule{
+		flag:   p.parseFlag(fields[1]),
+		cross:  p.cross[fields[0]+fields[1]],
+		strip:  fields[2],
+		add:    fields[3],
+		suffix: suffix,
+	}
+	if rule.strip == "0" {
+		rule.strip = ""
+	}
+	if i := strings.IndexByte(rule.add, '/'); i >= 0 {
+		rule.add = rule.add[:i]
+	}
+	if rule.add == "0" {
+		rule.add = ""
+	}
+
+	condition := "."
+	if len(fields) > 4 {
+		condition = fields[4]
+	}
+	cond, err := parseAffixCondition(condition)
+	if err != nil {
+		return err
+	}
+	rule.cond = cond
+
+	if suffix {
+		h.suffixes[rule.add] = append(h.suffixes[rule.add], rule)
+		if len(rule.add) > h.maxSuffixLen {
+			h.maxSuffixLen = len(rule.add)
+		}
+	} else {
+		h.prefixes[rule.add] = append(h.prefixes[rule.add], rule)
+		if len(rule.add) > h.maxPrefixLen {
+			h.maxPrefixLen = len(rule.add)
+		}
+	}
+
+	return nil
+}
+
+// loadWords parses dictionary file
+// If affix file declares no TRY characters, most frequent letters of dictionary are used for suggestions
+func (h *HunspellChecker) loadWords(p *affixParser, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	letters := make(map[rune]int)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// First line holds approximate number of words
+		if first {
+			first = false
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+
+		// Morphological fields follow entry after whitespace
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = line[:i]
+		}
+
+		word, flagList := line, ""
+		if i := strings.IndexByte(line, '/'); i > 0 {
+			word, flagList = line[:i], line[i+1:]
+		}
+
+		h.words[word] = append(h.words[word], p.parseFlagList(flagList))
+		if h.try == nil {
+			for _, r := range strings.ToLower(word) {
+				if unicode.IsLetter(r) {
+					letters[r]++
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if h.try == nil {
+		for r := range letters {
+			h.try = append(h.try, r)
+		}
+		sort.Slice(h.try, func(i, j int) bool {
+			return letters[h.try[i]] > letters[h.try[j]]
+		})
+		if len(h.try) > 40 {
+			h.try = h.try[:40]
+		}
+	}
+
+	return nil
+}
+
+// parseFlagList decodes flags of dictionary word, resolving AF alias if aliases are declared
+func (p *affixParser) parseFlagList(s string) []uint32 {
+	if len(p.aliases) > 0 {
+		if n, err := strconv.Atoi(s); err == nil {
+			if n > 0 && n < len(p.aliases) {
+				return p.aliases[n]
+			}
+			return nil
+		}
+	}
+	return p.decodeFlags(s)
+}
+
+// decodeFlags decodes list of flags according to FLAG type
+func (p *affixParser) decodeFlags(s string) []uint32 {
+	var flags []uint32
+	switch p.flagType {
+	case "long":
+		runes := []rune(s)
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags = append(flags, uint32(runes[i])<<16|uint32(runes[i+1]))
+		}
+	case "num":
+		for _, part := range strings.Split(s, ",") {
+			if n, err := strconv.Atoi(part); err == nil {
+				flags = append(flags, uint32(n))
+			}
+		}
+	default:
+		for _, r := range s {
+			flags = append(flags, uint32(r))
+		}
+	}
+	return flags
+}
+
+// parseFlag decodes single flag
+func (p *affixParser) parseFlag(s string) uint32 {
+	flags := p.decodeFlags(s)
+	if len(flags) == 0 {
+		return 0
+	}
+	return flags[0]
+}
+
+// matches checks affix condition against stem: suffix conditions apply to its end, prefix ones to its start
+func (r *affixRule) matches(stem string) bool {
+	if len(r.cond) == 0 {
+		return true
+	}
+
+	runes := []rune(stem)
+	if len(runes) < len(r.cond) {
+		return false
+	}
+	if r.suffix {
+		runes = runes[len(runes)-len(r.cond):]
+	}
+
+	for i, c := range r.cond {
+		if c.any {
+			continue
+		}
+		if strings.ContainsRune(c.chars, runes[i]) == c.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAffixCondition parses condition of affix rule like "[^aeiou]y"
+func parseAffixCondition(s string) ([]affixCondition, error) {
+	if s == "." {
+		return nil, nil
+	}
+
+	var cond []affixCondition
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.':
+			cond = append(cond, affixCondition{any: true})
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("неправильное условие %s", s)
+			}
+			chars := runes[i+1 : end]
+			negate := len(chars) > 0 && chars[0] == '^'
+			if negate {
+				chars = chars[1:]
+			}
+			cond = append(cond, affixCondition{negate: negate, chars: string(chars)})
+			i = end
+		default:
+			cond = append(cond, affixCondition{chars: string(runes[i])})
+		}
+	}
+	return cond, nil
+}
+
+// affixEncoding returns value of SET directive of affix file
+func affixEncoding(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "SET" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// stripComment removes comment starting with # from line of affix file
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// hasFlag reports whether flag is in list
+func hasFlag(flags []uint32, flag uint32) bool {
+	if flag == 0 {
+		return false
+	}
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// toTitle returns word with first letter in upper case
+func toTitle(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// urlPattern matches links and e-mail addresses
+var urlPattern = regexp.MustCompile(`(?i)(?:https?://|ftp://|www\.)\S+|[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+
+// maskURLs replaces characters of links and e-mail addresses with spaces, keeping positions of other words
+func maskURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return strings.Repeat(" ", utf8.RuneCountInString(match))
+	})
+}
+
+// wordToken is word found in text with its position measured in characters
+type wordToken struct {
+	word   string
+	pos    int
+	length int
+	row    int
+	col    int
+}
+
+// issue builds spelling issue about token
+func (t wordToken) issue(code int, suggestions []string) models.SpellingIssue {
+	return models.SpellingIssue{
+		Word:        t.word,
+		Pos:         t.pos,
+		Len:         t.length,
+		Row:         t.row,
+		Col:         t.col,
+		Suggestions: suggestions,
+		Code:        code,
+	}
+}
+
+// tokenizeWords splits text into words made of letters and digits
+// Hyphens and apostrophes between letters are kept inside word
+func tokenizeWords(text string) []wordToken {
+	var tokens []wordToken
+	runes := []rune(text)
+
+	row, col := 0, 0
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if r == '\n' {
+				row++
+				col = 0
+			} else {
+				col++
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) {
+			if unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) {
+				i++
+				continue
+			}
+			isJoiner := runes[i] == '-' || runes[i] == '\'' || runes[i] == '’'
+			if isJoiner && i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+				i++
+				continue
+			}
+			break
+		}
+
+		tokens = append(tokens, wordToken{
+			word:   string(runes[start:i]),
+			pos:    start,
+			length: i - start,
+			row:    row,
+			col:    col,
+		})
+		col += i - start
+	}
+
+	return tokens
+}
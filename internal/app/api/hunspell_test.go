@@ -0,0 +1,285 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// englishAff declares prefix and suffix rules with and without cross product, conditions, NEEDAFFIX,
+// FORBIDDENWORD, TRY and REP
+const englishAff = `SET UTF-8
+TRY yesianrtolcdugmphbfvkwz
+REP 1
+REP f ph
+NEEDAFFIX X
+FORBIDDENWORD F
+
+# Prefixes
+PFX U Y 1
+PFX U 0 un .
+
+PFX R N 1
+PFX R 0 re .
+
+# Suffixes
+SFX S Y 2
+SFX S y ies [^aeiou]y
+SFX S 0 s [aeiou]y
+
+SFX D N 1
+SFX D 0 ed .
+`
+
+const englishDic = `6
+try/SU
+play/SDUR
+kind/XU
+bad/FS
+phone
+Paris
+`
+
+// newTestHunspell loads dictionary from given affix and dictionary files, encoded with enc if it is not nil
+func newTestHunspell(t *testing.T, aff, dic string, enc encoding.Encoding) *HunspellChecker {
+	t.Helper()
+
+	affData, dicData := []byte(aff), []byte(dic)
+	if enc != nil {
+		var err error
+		if affData, err = enc.NewEncoder().Bytes(affData); err != nil {
+			t.Fatalf("encoding affix file: %v", err)
+		}
+		if dicData, err = enc.NewEncoder().Bytes(dicData); err != nil {
+			t.Fatalf("encoding dictionary: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	affPath, dicPath := filepath.Join(dir, "test.aff"), filepath.Join(dir, "test.dic")
+	if err := os.WriteFile(affPath, affData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dicPath, dicData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHunspellChecker(dicPath, affPath)
+	if err != nil {
+		t.Fatalf("NewHunspellChecker: %v", err)
+	}
+	return h
+}
+
+func TestHunspellCheckWord(t *testing.T) {
+	type check struct {
+		word string
+		want bool
+	}
+
+	tests := []struct {
+		name   string
+		aff    string
+		dic    string
+		enc    encoding.Encoding
+		checks []check
+	}{
+		{
+			name: "affix rules",
+			aff:  englishAff,
+			dic:  englishDic,
+			checks: []check{
+				{"try", true},
+				{"tries", true},
+				{"trys", false}, // condition [aeiou]y does not match
+				{"untry", true},
+				{"untries", true}, // both rules allow cross product
+				{"play", true},
+				{"plays", true},
+				{"played", true},
+				{"unplays", true},
+				{"replay", true},
+				{"replays", false},  // prefix R does not allow cross product
+				{"unplayed", false}, // suffix D does not allow cross product
+				{"reunplay", false},
+				{"Play", true},
+				{"PLAYS", true},
+				{"paris", false},
+				{"Paris", true},
+			},
+		},
+		{
+			name: "needaffix and forbidden word",
+			aff:  englishAff,
+			dic:  englishDic,
+			checks: []check{
+				{"kind", false},
+				{"Kind", false},
+				{"unkind", true},
+				{"bad", false},
+				{"bads", false},
+			},
+		},
+		{
+			name: "long flags",
+			aff: `FLAG long
+SFX Aa Y 1
+SFX Aa 0 s .
+PFX Bb Y 1
+PFX Bb 0 re .
+`,
+			dic: `2
+cat/AaBb
+dog/Ab
+`,
+			checks: []check{
+				{"cats", true},
+				{"recat", true},
+				{"recats", true},
+				{"dog", true},
+				{"dogs", false}, // Ab is not Aa
+			},
+		},
+		{
+			name: "numeric flags",
+			aff: `FLAG num
+SFX 101 Y 1
+SFX 101 0 s .
+SFX 7 Y 1
+SFX 7 0 ed .
+`,
+			dic: `2
+walk/101,7
+jump/7
+`,
+			checks: []check{
+				{"walks", true},
+				{"walked", true},
+				{"jumped", true},
+				{"jumps", false},
+			},
+		},
+		{
+			name: "flag aliases",
+			aff: `AF 2
+AF SD # 1
+AF S  # 2
+SFX S Y 1
+SFX S 0 s .
+SFX D Y 1
+SFX D 0 ed .
+`,
+			dic: `2
+walk/1
+cat/2
+`,
+			checks: []check{
+				{"walks", true},
+				{"walked", true},
+				{"cats", true},
+				{"cated", false},
+			},
+		},
+		{
+			name: "KOI8-R encoding",
+			aff: `SET KOI8-R
+SFX A Y 1
+SFX A а и а
+PFX P Y 1
+PFX P 0 пере .
+`,
+			dic: `2
+книга/A
+писать/P
+`,
+			enc: charmap.KOI8R,
+			checks: []check{
+				{"книга", true},
+				{"книги", true},
+				{"Книги", true},
+				{"книгы", false},
+				{"переписать", true},
+				{"переписат", false},
+			},
+		},
+		{
+			name: "CP1251 encoding",
+			aff: `SET CP1251
+SFX Ж Y 1
+SFX Ж 0 ів .
+`,
+			dic: `1
+дім/Ж
+`,
+			enc: charmap.Windows1251,
+			checks: []check{
+				{"дім", true},
+				{"дімів", true},
+				{"дімив", false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHunspell(t, tt.aff, tt.dic, tt.enc)
+			for _, c := range tt.checks {
+				if got := h.checkWord(c.word, false); got != c.want {
+					t.Errorf("checkWord(%q) = %v, want %v", c.word, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHunspellSuggest(t *testing.T) {
+	h := newTestHunspell(t, englishAff, englishDic, nil)
+
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"fone", "phone"}, // REP
+		{"tyr", "try"},    // swap
+		{"plai", "play"},  // TRY replacement
+		{"Plai", "Play"},  // capitalization is kept
+		{"ply", "play"},   // TRY insertion
+	}
+
+	for _, tt := range tests {
+		suggestions := h.suggest(tt.word)
+		if len(suggestions) == 0 || suggestions[0] != tt.want {
+			t.Errorf("suggest(%q) = %v, want %q first", tt.word, suggestions, tt.want)
+		}
+	}
+
+	if suggestions := h.suggest("qqqqqq"); len(suggestions) != 0 {
+		t.Errorf("suggest(%q) = %v, want none", "qqqqqq", suggestions)
+	}
+}
+
+func TestHunspellCheckTextIgnoresLang(t *testing.T) {
+	h := newTestHunspell(t, englishAff, englishDic, nil)
+
+	issues, err := h.CheckText("try tyr", "en", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Word != "tyr" || issues[0].Pos != 4 || issues[0].Len != 3 {
+		t.Fatalf("CheckText returned %+v, want single issue about tyr", issues)
+	}
+
+	for _, lang := range []string{"ru", "uk", ""} {
+		got, err := h.CheckText("try tyr", lang, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, issues) {
+			t.Errorf("CheckText with lang %q = %+v, want %+v", lang, got, issues)
+		}
+	}
+}
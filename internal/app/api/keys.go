@@ -0,0 +1,254 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+	"rest-notes/internal/app/models"
+)
+
+// minRSAKeyBits is minimum size of RSA key accepted for signing tokens
+const minRSAKeyBits = 2048
+
+// SigningMethodEdDSA signs tokens with Ed25519 keys (RFC 8037), jwt-go supports only RSA, ECDSA and HMAC
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// signingMethodEdDSA implements jwt.SigningMethod for Ed25519
+type signingMethodEdDSA struct{}
+
+// Alg returns name of algorithm in JWT header
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Sign signs string with ed25519.PrivateKey
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// Verify checks signature of string with ed25519.PublicKey
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// verificationKey is public key that tokens are verified with
+type verificationKey struct {
+	id        string
+	method    jwt.SigningMethod
+	publicKey crypto.PublicKey
+}
+
+// KeySet holds private key that new tokens are signed with and public keys that tokens are verified with
+// To rotate keys, new key becomes signing key while previous one stays among verification keys
+// until tokens signed with it expire
+type KeySet struct {
+	signingKey   crypto.Signer
+	signing      verificationKey
+	verification map[string]verificationKey
+	order        []string
+}
+
+// LoadKeySet loads signing key and additional verification keys from PEM files
+// Signing key must be RSA or Ed25519 private key in PKCS#8 or PKCS#1 format,
+// verification keys may be either private or public keys; ID of each key is its JWK thumbprint (RFC 7638)
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	key, err := readPEMKey(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("ключ подписи %s: %w", signingKeyFile, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("ключ подписи %s должен быть закрытым ключом", signingKeyFile)
+	}
+
+	ks := &KeySet{signingKey: signer, verification: make(map[string]verificationKey)}
+	if ks.signing, err = ks.add(signer.Public()); err != nil {
+		return nil, fmt.Errorf("ключ подписи %s: %w", signingKeyFile, err)
+	}
+
+	for _, file := range verificationKeyFiles {
+		key, err := readPEMKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("ключ проверки %s: %w", file, err)
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		if _, err = ks.add(key); err != nil {
+			return nil, fmt.Errorf("ключ проверки %s: %w", file, err)
+		}
+	}
+
+	return ks, nil
+}
+
+// KeyID returns ID of key new tokens are signed with
+func (ks *KeySet) KeyID() string {
+	return ks.signing.id
+}
+
+// sign signs token with signing key, ID of key is put into kid header
+func (ks *KeySet) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signingKey)
+}
+
+// parse parses token and verifies its signature with verification key named in kid header
+// Algorithm of token must be algorithm of that key
+func (ks *KeySet) parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.verification[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+		}
+		return key.publicKey, nil
+	})
+}
+
+// JWKS returns all verification keys as JSON Web Key Set, so that other services can verify tokens
+func (ks *KeySet) JWKS() models.JWKS {
+	set := models.JWKS{Keys: make([]models.JWK, 0, len(ks.order))}
+	for _, id := range ks.order {
+		key := ks.verification[id]
+		jwk := publicJWK(key.publicKey)
+		jwk.Kid, jwk.Use, jwk.Alg = key.id, "sig", key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// add adds public key to verification keys, key that is already there is not added again
+func (ks *KeySet) add(publicKey crypto.PublicKey) (verificationKey, error) {
+	var method jwt.SigningMethod
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return verificationKey{}, fmt.Errorf("длина ключа RSA должна быть не меньше %d бит", minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = SigningMethodEdDSA
+	default:
+		return verificationKey{}, errors.New("поддерживаются только ключи RSA и Ed25519")
+	}
+
+	id, err := thumbprint(publicJWK(publicKey))
+	if err != nil {
+		return verificationKey{}, err
+	}
+
+	key := verificationKey{id: id, method: method, publicKey: publicKey}
+	if _, ok := ks.verification[id]; !ok {
+		ks.verification[id] = key
+		ks.order = append(ks.order, id)
+	}
+	return key, nil
+}
+
+// readPEMKey reads private or public key from PEM file
+func readPEMKey(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("файл не содержит ключа в формате PEM")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа PEM %q", block.Type)
+	}
+}
+
+// publicJWK returns public parameters of RSA or Ed25519 key in JWK format
+func publicJWK(publicKey crypto.PublicKey) models.JWK {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return models.JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return models.JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(key)}
+	default:
+		return models.JWK{}
+	}
+}
+
+// thumbprint calculates JWK thumbprint (RFC 7638): SHA-256 of required members of key in lexicographic order
+func thumbprint(jwk models.JWK) (string, error) {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", errors.New("неподдерживаемый тип ключа")
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
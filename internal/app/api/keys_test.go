@@ -0,0 +1,323 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// writePEMKey writes key to PEM file of given type in temporary directory of test and returns path of file
+func writePEMKey(t *testing.T, key interface{}, pemType string) string {
+	t.Helper()
+
+	var der []byte
+	var err error
+	switch pemType {
+	case "PRIVATE KEY":
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	case "RSA PRIVATE KEY":
+		der = x509.MarshalPKCS1PrivateKey(key.(*rsa.PrivateKey))
+	case "PUBLIC KEY":
+		der, err = x509.MarshalPKIXPublicKey(key)
+	default:
+		t.Fatalf("unknown PEM type %q", pemType)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.CreateTemp(t.TempDir(), "*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err = pem.Encode(file, &pem.Block{Type: pemType, Bytes: der}); err != nil {
+		t.Fatal(err)
+	}
+	return file.Name()
+}
+
+// newRSAKey generates RSA key of given size
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newEd25519Key generates Ed25519 key
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newTestKeySet creates key set with Ed25519 signing key generated for test
+func newTestKeySet(t *testing.T) *KeySet {
+	t.Helper()
+
+	keys, err := LoadKeySet(writePEMKey(t, newEd25519Key(t), "PRIVATE KEY"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// testClaims returns claims of token valid for minute
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	edKey := newEd25519Key(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	if err = os.WriteFile(notPEM, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantAlg string
+	}{
+		{"RSA key in PKCS#8", writePEMKey(t, rsaKey, "PRIVATE KEY"), "RS256"},
+		{"RSA key in PKCS#1", writePEMKey(t, rsaKey, "RSA PRIVATE KEY"), "RS256"},
+		{"Ed25519 key", writePEMKey(t, edKey, "PRIVATE KEY"), "EdDSA"},
+		{"short RSA key", writePEMKey(t, newRSAKey(t, 1024), "PRIVATE KEY"), ""},
+		{"ECDSA key", writePEMKey(t, ecKey, "PRIVATE KEY"), ""},
+		{"public key", writePEMKey(t, edKey.Public(), "PUBLIC KEY"), ""},
+		{"file without PEM", notPEM, ""},
+		{"missing file", filepath.Join(t.TempDir(), "missing.pem"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadKeySet(tt.file, nil)
+			if tt.wantAlg == "" {
+				if err == nil {
+					t.Error("LoadKeySet returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Token is signed with loaded key under its ID and is verified with it
+			tokenString, err := keys.sign(testClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := keys.parse(tokenString)
+			if err != nil {
+				t.Fatalf("parse of signed token error = %v", err)
+			}
+			if !token.Valid || token.Header["kid"] != keys.KeyID() || token.Header["alg"] != tt.wantAlg {
+				t.Errorf("token header = %v, want kid %s and alg %s", token.Header, keys.KeyID(), tt.wantAlg)
+			}
+			if claims := token.Claims.(jwt.MapClaims); claims["sub"] != "alice" {
+				t.Errorf("claims = %v", claims)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, newKey, otherKey := newRSAKey(t, 2048), newEd25519Key(t), newEd25519Key(t)
+	oldFile := writePEMKey(t, oldKey, "PRIVATE KEY")
+
+	oldKeys, err := LoadKeySet(oldFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeys, err := LoadKeySet(writePEMKey(t, otherKey, "PRIVATE KEY"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Retired key is still published as public key, new key signs tokens
+	keys, err := LoadKeySet(writePEMKey(t, newKey, "PRIVATE KEY"),
+		[]string{writePEMKey(t, oldKey.Public(), "PUBLIC KEY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.KeyID() == oldKeys.KeyID() {
+		t.Fatal("new key has ID of retired key")
+	}
+
+	oldToken, err := oldKeys.sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keys.parse(oldToken); err != nil {
+		t.Errorf("token signed with retired key is rejected: %v", err)
+	}
+
+	otherToken, err := otherKeys.sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keys.parse(otherToken); err == nil {
+		t.Error("token signed with unknown key is accepted")
+	}
+
+	// Signing key given again as verification key is published once
+	keys, err = LoadKeySet(oldFile, []string{oldFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.JWKS().Keys) != 1 {
+		t.Errorf("JWKS has %d keys, want 1", len(keys.JWKS().Keys))
+	}
+}
+
+func TestKeySetRejectsForgedTokens(t *testing.T) {
+	rsaKey, edKey := newRSAKey(t, 2048), newEd25519Key(t)
+	keys, err := LoadKeySet(writePEMKey(t, rsaKey, "PRIVATE KEY"),
+		[]string{writePEMKey(t, edKey.Public(), "PUBLIC KEY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaID, edID := keys.order[0], keys.order[1]
+	rsaPublic, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, testClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no kid", sign(jwt.SigningMethodRS256, "", rsaKey)},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "unknown", rsaKey)},
+		{"EdDSA with RSA kid", sign(SigningMethodEdDSA, rsaID, edKey)},
+		{"alg none", sign(jwt.SigningMethodNone, rsaID, jwt.UnsafeAllowNoneSignatureType)},
+		{"HS256 with public key as secret", sign(jwt.SigningMethodHS256, rsaID, rsaPublic)},
+		{"RS256 with Ed25519 kid", sign(jwt.SigningMethodRS256, edID, rsaKey)},
+		{"signature of other key", sign(SigningMethodEdDSA, edID, newEd25519Key(t))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if token, err := keys.parse(tt.token); err == nil {
+				t.Errorf("forged token is accepted, header %v", token.Header)
+			}
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// Keys and thumbprints from RFC 7638, section 3.1 and RFC 8037, appendix A.3
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu" +
+		"1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0" +
+		"_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2Nc" +
+		"Rwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  interface{}
+		want string
+	}{
+		{"RSA", &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		{"Ed25519", ed25519.PublicKey(x), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := thumbprint(publicJWK(tt.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.want {
+				t.Errorf("thumbprint = %s, want %s", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, edKey := newRSAKey(t, 2048), newEd25519Key(t)
+	keys, err := LoadKeySet(writePEMKey(t, rsaKey, "PRIVATE KEY"),
+		[]string{writePEMKey(t, edKey.Public(), "PUBLIC KEY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(keys.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS = %s, want two keys", data)
+	}
+
+	// Signing key goes first, kid of every key is its thumbprint
+	want := []map[string]string{
+		{"kty": "RSA", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), "e": "AQAB"},
+		{"kty": "OKP", "use": "sig", "alg": "EdDSA", "crv": "Ed25519",
+			"x": base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))},
+	}
+	for i, key := range []interface{}{rsaKey.Public(), edKey.Public()} {
+		if want[i]["kid"], err = thumbprint(publicJWK(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(set.Keys, want) {
+		t.Errorf("JWKS = %v, want %v", set.Keys, want)
+	}
+	if set.Keys[0]["kid"] != keys.KeyID() {
+		t.Errorf("kid of first key = %s, want ID of signing key %s", set.Keys[0]["kid"], keys.KeyID())
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"rest-notes/internal/app/metrics"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("неверное имя пользователя или пароль")
+	ErrTooManyLoginAttempts = errors.New("слишком много неудачных попыток входа")
+)
+
+var blockedLogins = metrics.NewCounter("login_blocked_total", "Login attempts rejected by brute-force protection")
+
+// guardSweepInterval is how often failure counters that have expired are dropped
+const guardSweepInterval = time.Minute
+
+// LoginBlockedError is returned when login attempt is rejected because of previous failures
+type LoginBlockedError struct {
+	RetryAfter time.Duration
+	Reason     string
+}
+
+// Error returns message with reason of rejection
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrTooManyLoginAttempts, e.Reason)
+}
+
+// Unwrap returns ErrTooManyLoginAttempts, so that error can be checked with errors.Is
+func (e *LoginBlockedError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// loginFailures is counter of failed logins for single username or IP address
+type loginFailures struct {
+	count       int
+	last        time.Time
+	nextAttempt time.Time
+	lockedUntil time.Time
+}
+
+// LoginGuard protects login from password guessing
+// It counts failed logins per username and per IP address: after freeAttempts failures every next attempt
+// has to wait for delay doubling with each failure up to maxDelay, after lockout limit failures further attempts
+// are rejected for lockoutDuration. Failures are forgotten lockoutDuration after the last one, successful login
+// resets counter of username
+type LoginGuard struct {
+	freeAttempts    int
+	delay           time.Duration
+	maxDelay        time.Duration
+	userLockout     int
+	ipLockout       int
+	lockoutDuration time.Duration
+
+	mu        sync.Mutex
+	failures  map[string]*loginFailures
+	lastSweep time.Time
+}
+
+// NewLoginGuard creates new LoginGuard, zero userLockout or ipLockout disables lockout of usernames or IP addresses
+func NewLoginGuard(freeAttempts int, delay, maxDelay time.Duration, userLockout, ipLockout int,
+	lockoutDuration time.Duration) *LoginGuard {
+	return &LoginGuard{
+		freeAttempts:    freeAttempts,
+		delay:           delay,
+		maxDelay:        maxDelay,
+		userLockout:     userLockout,
+		ipLockout:       ipLockout,
+		lockoutDuration: lockoutDuration,
+		failures:        make(map[string]*loginFailures),
+		lastSweep:       time.Now(),
+	}
+}
+
+// Check rejects login attempt of user from IP address if username or address is locked out
+// or has to wait after recent failures
+// Returns *LoginBlockedError with time to wait
+func (lg *LoginGuard) Check(name, ip string) error {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+
+	now := time.Now()
+	var blocked *LoginBlockedError
+	for _, key := range guardKeys(name, ip) {
+		f, ok := lg.failures[key]
+		if !ok {
+			continue
+		}
+
+		var retryAfter time.Duration
+		var reason string
+		switch {
+		case now.Before(f.lockedUntil):
+			retryAfter, reason = f.lockedUntil.Sub(now), "временная блокировка "+guardSubject(key)
+		case now.Before(f.nextAttempt):
+			retryAfter, reason = f.nextAttempt.Sub(now), "задержка после неудачных попыток "+guardSubject(key)
+		default:
+			continue
+		}
+		if blocked == nil || retryAfter > blocked.RetryAfter {
+			blocked = &LoginBlockedError{RetryAfter: retryAfter, Reason: reason}
+		}
+	}
+
+	if blocked == nil {
+		return nil
+	}
+	blockedLogins.Inc()
+	log.Printf("Попытка входа отклонена: пользователь %s, IP %s, причина: %s, повтор через %v",
+		name, ip, blocked.Reason, blocked.RetryAfter.Round(time.Second))
+	return blocked
+}
+
+// Fail records failed login of user from IP address
+func (lg *LoginGuard) Fail(name, ip string) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(lg.lastSweep) >= guardSweepInterval {
+		lg.sweep(now)
+	}
+
+	for _, key := range guardKeys(name, ip) {
+		f, ok := lg.failures[key]
+		if !ok || now.Sub(f.last) >= lg.lockoutDuration {
+			f = &loginFailures{}
+			lg.failures[key] = f
+		}
+		f.count++
+		f.last = now
+
+		if limit := lg.lockoutLimit(key); limit > 0 && f.count >= limit {
+			f.count = 0
+			f.lockedUntil = now.Add(lg.lockoutDuration)
+			log.Printf("Временная блокировка входа: пользователь %s, IP %s, причина: %d неудачных попыток %s, до %s",
+				name, ip, limit, guardSubject(key), f.lockedUntil.Format(time.RFC3339))
+			continue
+		}
+
+		if f.count >= lg.freeAttempts {
+			f.nextAttempt = now.Add(min(lg.maxDelay, lg.delay<<min(f.count-lg.freeAttempts, 30)))
+		}
+	}
+}
+
+// Success resets failure counter of username after successful login
+// Counter of IP address is kept, so that attacker can't reset it by logging into their own account
+func (lg *LoginGuard) Success(name string) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+
+	delete(lg.failures, userGuardKey(name))
+}
+
+// lockoutLimit returns number of failures that locks out username or IP address of key
+func (lg *LoginGuard) lockoutLimit(key string) int {
+	if strings.HasPrefix(key, "user:") {
+		return lg.userLockout
+	}
+	return lg.ipLockout
+}
+
+// sweep drops counters whose failures are forgotten and lockout is over
+func (lg *LoginGuard) sweep(now time.Time) {
+	for key, f := range lg.failures {
+		if now.Sub(f.last) >= lg.lockoutDuration && now.After(f.lockedUntil) {
+			delete(lg.failures, key)
+		}
+	}
+	lg.lastSweep = now
+}
+
+// guardKeys returns keys of failure counters of username and IP address
+func guardKeys(name, ip string) []string {
+	return []string{userGuardKey(name), "ip:" + ip}
+}
+
+// userGuardKey returns key of failure counter of username, usernames differing in case share counter
+func userGuardKey(name string) string {
+	return "user:" + strings.ToLower(name)
+}
+
+// guardSubject describes what failure counter with given key belongs to
+func guardSubject(key string) string {
+	if strings.HasPrefix(key, "user:") {
+		return "для пользователя"
+	}
+	return "с IP-адреса"
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// guardEvent is failed or successful login recorded by login guard
+type guardEvent struct {
+	name, ip string
+	success  bool
+}
+
+func guardFail(name, ip string) guardEvent {
+	return guardEvent{name: name, ip: ip}
+}
+
+func guardSuccess(name string) guardEvent {
+	return guardEvent{name: name, success: true}
+}
+
+func TestLoginGuard(t *testing.T) {
+	tests := []struct {
+		name           string
+		freeAttempts   int
+		userLockout    int
+		ipLockout      int
+		events         []guardEvent
+		checkName      string
+		checkIP        string
+		wantRetryAfter time.Duration
+		wantReason     string
+	}{
+		{"no failures", 2, 0, 0, nil, "alice", "10.0.0.1", 0, ""},
+		{"free attempts", 2, 0, 0, []guardEvent{guardFail("alice", "10.0.0.1")}, "alice", "10.0.0.1", 0, ""},
+		{"delay after free attempts", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1")},
+			"alice", "10.0.0.1", time.Minute, "задержка"},
+		{"delay doubles", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1"),
+				guardFail("alice", "10.0.0.1")},
+			"alice", "10.0.0.1", 2 * time.Minute, "задержка"},
+		{"delay is capped", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1"),
+				guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1")},
+			"alice", "10.0.0.1", 3 * time.Minute, "задержка"},
+		{"user is delayed from any address", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("Alice", "10.0.0.2")},
+			"ALICE", "10.0.0.3", time.Minute, "задержка после неудачных попыток для пользователя"},
+		{"address is delayed for any user", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("bob", "10.0.0.1")},
+			"carol", "10.0.0.1", time.Minute, "задержка после неудачных попыток с IP-адреса"},
+		{"user lockout", 10, 3, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.2"),
+				guardFail("alice", "10.0.0.3")},
+			"alice", "10.0.0.4", time.Hour, "временная блокировка для пользователя"},
+		{"user lockout does not block others", 10, 3, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.2"),
+				guardFail("alice", "10.0.0.3")},
+			"bob", "10.0.0.1", 0, ""},
+		{"address lockout", 10, 0, 3,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("bob", "10.0.0.1"),
+				guardFail("carol", "10.0.0.1")},
+			"dave", "10.0.0.1", time.Hour, "временная блокировка с IP-адреса"},
+		{"success resets user", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.2"), guardSuccess("alice")},
+			"alice", "10.0.0.3", 0, ""},
+		{"success does not reset address", 2, 0, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.1"), guardSuccess("alice")},
+			"alice", "10.0.0.1", time.Minute, "задержка после неудачных попыток с IP-адреса"},
+		{"success ends user lockout", 10, 3, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.2"),
+				guardFail("alice", "10.0.0.3"), guardSuccess("alice")},
+			"alice", "10.0.0.4", 0, ""},
+		{"success counts failures from zero", 2, 3, 0,
+			[]guardEvent{guardFail("alice", "10.0.0.1"), guardFail("alice", "10.0.0.2"), guardSuccess("alice"),
+				guardFail("alice", "10.0.0.3")},
+			"alice", "10.0.0.4", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewLoginGuard(tt.freeAttempts, time.Minute, 3*time.Minute, tt.userLockout, tt.ipLockout,
+				time.Hour)
+			for _, event := range tt.events {
+				if event.success {
+					guard.Success(event.name)
+				} else {
+					guard.Fail(event.name, event.ip)
+				}
+			}
+
+			err := guard.Check(tt.checkName, tt.checkIP)
+			if tt.wantRetryAfter == 0 {
+				if err != nil {
+					t.Errorf("Check error = %v, want nil", err)
+				}
+				return
+			}
+
+			var blocked *LoginBlockedError
+			if !errors.As(err, &blocked) || !errors.Is(err, ErrTooManyLoginAttempts) {
+				t.Fatalf("Check error = %v, want *LoginBlockedError", err)
+			}
+			if blocked.RetryAfter > tt.wantRetryAfter || blocked.RetryAfter < tt.wantRetryAfter-time.Second {
+				t.Errorf("retry after = %v, want %v", blocked.RetryAfter, tt.wantRetryAfter)
+			}
+			if !strings.HasPrefix(blocked.Reason, tt.wantReason) {
+				t.Errorf("reason = %q, want %q", blocked.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestLoginGuardForgetsFailures(t *testing.T) {
+	const lockoutDuration = 20 * time.Millisecond
+
+	guard := NewLoginGuard(2, time.Millisecond, time.Millisecond, 3, 0, lockoutDuration)
+	guard.Fail("alice", "10.0.0.1")
+	guard.Fail("alice", "10.0.0.1")
+
+	// Failures older than lockout duration are not counted towards lockout
+	time.Sleep(lockoutDuration + 10*time.Millisecond)
+	guard.Fail("alice", "10.0.0.1")
+	if err := guard.Check("alice", "10.0.0.1"); err != nil {
+		t.Errorf("Check after forgotten failures error = %v, want nil", err)
+	}
+
+	// Lockout ends after lockout duration
+	guard.Fail("alice", "10.0.0.1")
+	guard.Fail("alice", "10.0.0.1")
+	if err := guard.Check("alice", "10.0.0.1"); err == nil {
+		t.Fatal("user was not locked out")
+	}
+	time.Sleep(lockoutDuration + 10*time.Millisecond)
+	if err := guard.Check("alice", "10.0.0.1"); err != nil {
+		t.Errorf("Check after lockout error = %v, want nil", err)
+	}
+}
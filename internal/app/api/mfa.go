@@ -0,0 +1,274 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("двухфакторная аутентификация уже включена")
+	ErrMFANotEnabled     = errors.New("двухфакторная аутентификация не включена")
+	ErrMFANotEnrolled    = errors.New("подключение двухфакторной аутентификации не начато")
+	ErrInvalidMFACode    = errors.New("неверный код")
+	ErrInvalidMFAToken   = errors.New("недействительный токен двухфакторной аутентификации")
+)
+
+const (
+	// mfaPendingTokenType is type of token issued after password check to user with two-factor authentication
+	mfaPendingTokenType = "mfa_pending"
+	mfaTokenTTL         = 5 * time.Minute
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+	qrCodeSize        = 256
+)
+
+// EnrollMFA starts enrolment of user in TOTP two-factor authentication
+// It generates new secret and returns it with otpauth URI and its QR code for authenticator app;
+// two-factor authentication is enabled only after enrolment is confirmed with ConfirmMFA
+func (as *AuthService) EnrollMFA(userID int) (models.MFAEnrollment, error) {
+	user, err := as.repo.GetByID(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для подключения 2FA: %v", userID, err)
+		return models.MFAEnrollment{}, err
+	}
+	if user.MFAEnabled {
+		return models.MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+	if err = as.mfa.SetPendingTOTP(userID, secret); err != nil {
+		if errors.Is(err, postgresql.ErrMFAEnabled) {
+			return models.MFAEnrollment{}, ErrMFAAlreadyEnabled
+		}
+		log.Printf("Ошибка при сохранении секрета 2FA пользователя ID %d: %v", userID, err)
+		return models.MFAEnrollment{}, err
+	}
+
+	uri := totpURI(as.mfaIssuer, user.Name, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		log.Printf("Ошибка при создании QR-кода 2FA: %v", err)
+		return models.MFAEnrollment{}, err
+	}
+
+	log.Printf("Пользователь ID %d начал подключение 2FA", userID)
+	return models.MFAEnrollment{Secret: secret, URI: uri, QRCode: png}, nil
+}
+
+// ConfirmMFA enables two-factor authentication of user after checking first code from authenticator app
+// Returns one-time recovery codes, they are shown only once and only their hashes are stored
+func (as *AuthService) ConfirmMFA(userID int, code string) ([]string, error) {
+	totp, err := as.mfa.GetTOTP(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении 2FA пользователя ID %d: %v", userID, err)
+		return nil, err
+	}
+	if totp.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if totp.Secret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := verifyTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		log.Printf("Неверный код при подтверждении 2FA пользователя ID %d", userID)
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = generateRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err = as.mfa.EnableTOTP(userID, step, hashes); err != nil {
+		if errors.Is(err, postgresql.ErrMFAEnabled) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		log.Printf("Ошибка при включении 2FA пользователя ID %d: %v", userID, err)
+		return nil, err
+	}
+
+	log.Printf("Пользователь ID %d включил 2FA", userID)
+	return codes, nil
+}
+
+// VerifyMFA completes login of user with two-factor authentication
+// It exchanges mfa_pending token issued by GenerateToken and valid TOTP or recovery code for pair of tokens
+// Wrong codes are counted by login guard like wrong passwords
+func (as *AuthService) VerifyMFA(mfaToken, code, ip string) (models.Tokens, error) {
+	valid, claims, err := as.checkToken(mfaToken)
+	if err != nil || !valid {
+		return models.Tokens{}, ErrInvalidMFAToken
+	}
+	tokenType, _ := claims["typ"].(string)
+	userID, ok := claims["id"].(float64)
+	if tokenType != mfaPendingTokenType || !ok {
+		return models.Tokens{}, ErrInvalidMFAToken
+	}
+
+	user, err := as.repo.GetByID(int(userID))
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для проверки 2FA: %v", int(userID), err)
+		return models.Tokens{}, err
+	}
+	if user.DisabledAt != nil {
+		return models.Tokens{}, ErrUserDisabled
+	}
+
+	if err = as.guard.Check(user.Name, ip); err != nil {
+		return models.Tokens{}, err
+	}
+
+	ok, err = as.checkMFACode(user.ID, code)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if !ok {
+		log.Printf("Неверный код 2FA: пользователь %s, IP %s", user.Name, ip)
+		as.guard.Fail(user.Name, ip)
+		return models.Tokens{}, ErrInvalidMFACode
+	}
+	as.guard.Success(user.Name)
+
+	tokens, err := as.startSession(user)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	log.Printf("JWT успешно сгенерирован для пользователя %s после проверки 2FA", user.Name)
+	return tokens, nil
+}
+
+// DisableMFA turns two-factor authentication of user off, it requires password and TOTP or recovery code
+// Wrong passwords and codes are counted by login guard like wrong passwords on login
+func (as *AuthService) DisableMFA(userID int, password, code, ip string) error {
+	user, err := as.repo.GetByID(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для отключения 2FA: %v", userID, err)
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err = as.guard.Check(user.Name, ip); err != nil {
+		return err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		log.Printf("Неверный пароль при отключении 2FA пользователя ID %d, IP %s", userID, ip)
+		as.guard.Fail(user.Name, ip)
+		return ErrInvalidPassword
+	}
+
+	ok, err := as.checkMFACode(userID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("Неверный код при отключении 2FA пользователя ID %d, IP %s", userID, ip)
+		as.guard.Fail(user.Name, ip)
+		return ErrInvalidMFACode
+	}
+	as.guard.Success(user.Name)
+
+	if err = as.mfa.DisableTOTP(userID); err != nil {
+		log.Printf("Ошибка при отключении 2FA пользователя ID %d: %v", userID, err)
+		return err
+	}
+
+	log.Printf("Пользователь ID %d отключил 2FA", userID)
+	return nil
+}
+
+// checkMFACode checks TOTP code or unused recovery code of user with enabled two-factor authentication
+// Each TOTP code and recovery code is accepted only once
+func (as *AuthService) checkMFACode(userID int, code string) (bool, error) {
+	totp, err := as.mfa.GetTOTP(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении 2FA пользователя ID %d: %v", userID, err)
+		return false, err
+	}
+	if totp.EnabledAt == nil {
+		return false, nil
+	}
+
+	if step, ok := verifyTOTP(totp.Secret, code, time.Now()); ok {
+		fresh, err := as.mfa.UseTOTPStep(userID, step)
+		if err != nil {
+			log.Printf("Ошибка при сохранении использованного кода 2FA пользователя ID %d: %v", userID, err)
+			return false, err
+		}
+		if !fresh {
+			log.Printf("Повторное использование кода 2FA пользователя ID %d", userID)
+		}
+		return fresh, nil
+	}
+
+	used, err := as.mfa.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		log.Printf("Ошибка при проверке кода восстановления пользователя ID %d: %v", userID, err)
+		return false, err
+	}
+	if used {
+		log.Printf("Пользователь ID %d использовал код восстановления 2FA", userID)
+	}
+	return used, nil
+}
+
+// generateMFAToken generates short-lived mfa_pending JWT for user who has passed password check
+// Token has no session, so it is not accepted as access token
+func (as *AuthService) generateMFAToken(user models.User) (string, error) {
+	claims, err := as.newClaims(user, mfaTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	claims["typ"] = mfaPendingTokenType
+
+	tokenString, err := as.keys.sign(claims)
+	if err != nil {
+		log.Printf("Ошибка при подписании токена 2FA: %v", err)
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// generateRecoveryCode returns random recovery code formatted as groups of four characters, like ABCD-EFGH-IJKL-MNOP
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := totpEncoding.EncodeToString(buf)
+
+	groups := make([]string, 0, len(raw)/4+1)
+	for len(raw) > 4 {
+		groups = append(groups, raw[:4])
+		raw = raw[4:]
+	}
+	groups = append(groups, raw)
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeRecoveryCode brings recovery code typed by user to form whose hash is stored
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
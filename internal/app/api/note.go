@@ -1,81 +1,491 @@
 package api
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"log"
+	"strings"
+	"time"
 
 	"rest-notes/internal/app/models"
 	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
 )
 
-var ErrSpell = errors.New("обнаружены орфографические ошибки")
+var (
+	ErrSpell           = errors.New("обнаружены орфографические ошибки")
+	ErrNoteNotFound    = errors.New("заметка не найдена")
+	ErrForbidden       = errors.New("нет доступа к заметке")
+	ErrInvalidList     = errors.New("неправильные параметры списка заметок")
+	ErrEmptyQuery      = errors.New("пустой поисковый запрос")
+	ErrNoteNotInTrash  = errors.New("заметка не находится в корзине")
+	ErrVersionConflict = errors.New("заметка была изменена другим клиентом")
+)
+
+const (
+	defaultNoteListLimit = 20
+	maxNoteListLimit     = 100
+)
+
+// defaultNoteSortOrder holds direction used when client specifies sort field without order
+var defaultNoteSortOrder = map[string]string{
+	models.NoteSortDueDate:   models.SortAsc,
+	models.NoteSortCreatedAt: models.SortDesc,
+	models.NoteSortUpdatedAt: models.SortDesc,
+	models.NoteSortTitle:     models.SortAsc,
+}
+
+// noteCursor is content of opaque cursor handed out to clients
+// Sort and order are kept inside so cursor can not be reused with different ordering
+type noteCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
 
 // NoteService represents service for handling notes and checking spelling
 type NoteService struct {
-	repo           repository.NoteRepo
-	spellerService *SpellerService
+	repo         repository.NoteRepo
+	notebookRepo repository.NotebookRepo
+	spelling     *SpellingService
 }
 
-// NewNoteService creates new instance of NoteService with repository and spelling service
-func NewNoteService(repo repository.NoteRepo, spellerService *SpellerService) *NoteService {
+// NewNoteService creates new instance of NoteService with repositories and spelling service
+func NewNoteService(repo repository.NoteRepo, notebookRepo repository.NotebookRepo,
+	spelling *SpellingService) *NoteService {
 	return &NoteService{
-		repo:           repo,
-		spellerService: spellerService,
+		repo:         repo,
+		notebookRepo: notebookRepo,
+		spelling:     spelling,
 	}
 }
 
 // CreateNote creates new note using repository and returns created note
-func (n *NoteService) CreateNote(note models.Note) (models.Note, error) {
+// Spelling is checked in mode from spell options or user's settings, in warn mode found mistakes
+// are returned in SpellingIssues of created note, in async mode note is saved with pending spell status
+// and checked in background; with autocorrect mistakes are fixed first and applied replacements are returned in Corrections
+func (n *NoteService) CreateNote(note models.Note, spell models.SpellOptions) (models.Note, error) {
+	var err error
+	if note.Tags, err = normalizeTags(note.Tags); err != nil {
+		return models.Note{}, err
+	}
 
-	// Check spelling of note's description using SpellerService
-	spellingResult, err := n.spellerService.CheckText(note.Description, "rus", 0)
-	if err != nil {
-		log.Printf("Ошибка при проверке орфографии: %v", err)
+	if err = n.checkNotebook(note.UserID, note.NotebookID); err != nil {
 		return models.Note{}, err
 	}
 
-	// If there are spelling errors, format error message and return it
-	if len(spellingResult) > 0 {
-		log.Printf("Обнаружены орфографические ошибки в тексте: %v", spellingResult)
+	if note.Lang, err = normalizeLang(note.Lang); err != nil {
+		return models.Note{}, err
+	}
 
-		errorDetails := formatSpellingErrors(spellingResult)
+	var corrections []models.SpellingCorrection
+	if spell.Autocorrect {
+		note, corrections = n.spelling.correctNote(note)
+	}
 
-		// Return formatted error with details
-		return models.Note{}, fmt.Errorf("%w: %s", ErrSpell, errorDetails)
+	// Check spelling of note's title and description using spelling service
+	if note, err = n.spelling.checkNote(note, spell); err != nil {
+		return models.Note{}, err
 	}
 
-	// If no mistakes found, create note in repository
+	// If no mistakes found or they are only reported, create note in repository
 	createdNote, err := n.repo.Create(note)
 	if err != nil {
 		log.Printf("Ошибка при создании заметки: %v", err)
 		return models.Note{}, err
 	}
+	createdNote.Corrections = corrections
 
 	log.Printf("Заметка успешно создана: %v", createdNote)
 	return createdNote, nil
 }
 
-// GetNoteList retrieves list of all notes from repository
-func (n *NoteService) GetNoteList(userID int) ([]models.Note, error) {
-	notes, err := n.repo.GetAll(userID)
+// GetNoteList retrieves one page of notes from repository
+// It validates filter, decodes cursor and returns cursor for the next page if there is one
+func (n *NoteService) GetNoteList(userID int, filter models.NoteFilter) (models.NoteList, error) {
+	if filter.Cursor != "" {
+		after, err := decodeNoteCursor(&filter)
+		if err != nil {
+			return models.NoteList{}, err
+		}
+		filter.After = after
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = models.NoteSortCreatedAt
+	}
+	defaultOrder, ok := defaultNoteSortOrder[filter.Sort]
+	if !ok {
+		return models.NoteList{}, fmt.Errorf("%w: неизвестное поле сортировки %q", ErrInvalidList, filter.Sort)
+	}
+	if filter.Order == "" {
+		filter.Order = defaultOrder
+	}
+	if filter.Order != models.SortAsc && filter.Order != models.SortDesc {
+		return models.NoteList{}, fmt.Errorf("%w: неизвестное направление сортировки %q", ErrInvalidList, filter.Order)
+	}
+
+	if filter.TagMode == "" {
+		filter.TagMode = models.TagModeAnd
+	}
+	if filter.TagMode != models.TagModeAnd && filter.TagMode != models.TagModeOr {
+		return models.NoteList{}, fmt.Errorf("%w: неизвестный режим фильтра тегов %q", ErrInvalidList, filter.TagMode)
+	}
+	var err error
+	if filter.Tags, err = normalizeTags(filter.Tags); err != nil {
+		return models.NoteList{}, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultNoteListLimit
+	}
+	if filter.Limit > maxNoteListLimit {
+		filter.Limit = maxNoteListLimit
+	}
+
+	// Fetch one extra note to find out whether there is next page
+	limit := filter.Limit
+	filter.Limit++
+	notes, err := n.repo.GetAll(userID, filter)
 	if err != nil {
 		log.Printf("Ошибка при получении списка заметок: %v", err)
-		return nil, err
+		return models.NoteList{}, err
+	}
+
+	list := models.NoteList{Items: notes}
+	if len(notes) > limit {
+		list.Items = notes[:limit]
+		list.NextCursor = encodeNoteCursor(filter, list.Items[limit-1])
 	}
 
 	log.Printf("Список заметок успешно получен для пользователя ID: %d", userID)
+	return list, nil
+}
+
+// decodeNoteCursor parses cursor received from client and checks it was issued for the same ordering
+// Request without explicit sort inherits ordering cursor was issued for
+func decodeNoteCursor(filter *models.NoteFilter) (*models.NoteCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: неправильный курсор", ErrInvalidList)
+	}
+
+	var c noteCursor
+	if err = json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: неправильный курсор", ErrInvalidList)
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = c.Sort
+	}
+	if filter.Order == "" {
+		filter.Order = c.Order
+	}
+	if c.Sort != filter.Sort || c.Order != filter.Order {
+		return nil, fmt.Errorf("%w: курсор выдан для другой сортировки", ErrInvalidList)
+	}
+
+	return &models.NoteCursor{Value: c.Value, ID: c.ID}, nil
+}
+
+// encodeNoteCursor builds opaque cursor pointing right after given note
+func encodeNoteCursor(filter models.NoteFilter, note models.Note) string {
+	c := noteCursor{Sort: filter.Sort, Order: filter.Order, ID: note.ID}
+	switch filter.Sort {
+	case models.NoteSortDueDate:
+		c.Value = "infinity"
+		if note.DueDate != nil {
+			c.Value = note.DueDate.Format(time.RFC3339Nano)
+		}
+	case models.NoteSortCreatedAt:
+		c.Value = note.CreatedAt.Format(time.RFC3339Nano)
+	case models.NoteSortUpdatedAt:
+		c.Value = note.UpdatedAt.Format(time.RFC3339Nano)
+	case models.NoteSortTitle:
+		c.Value = note.Title
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// GetNote retrieves single note and makes sure it belongs to given user
+// Returns ErrNoteNotFound if note does not exist or is in trash and ErrForbidden if it belongs to someone else
+func (n *NoteService) GetNote(userID, noteID int) (models.Note, error) {
+	note, err := n.getOwnedNote(userID, noteID)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	if note.DeletedAt != nil {
+		return models.Note{}, ErrNoteNotFound
+	}
+	return note, nil
+}
+
+// GetNoteSpelling retrieves spell check status and mistakes found in user's note
+func (n *NoteService) GetNoteSpelling(userID, noteID int) (models.NoteSpelling, error) {
+	if _, err := n.GetNote(userID, noteID); err != nil {
+		return models.NoteSpelling{}, err
+	}
+
+	spelling, err := n.repo.GetSpelling(noteID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.NoteSpelling{}, ErrNoteNotFound
+		}
+		log.Printf("Ошибка при получении результатов проверки орфографии заметки ID %d: %v", noteID, err)
+		return models.NoteSpelling{}, err
+	}
+
+	return spelling, nil
+}
+
+// getOwnedNote retrieves note whether it is in trash or not and makes sure it belongs to given user
+func (n *NoteService) getOwnedNote(userID, noteID int) (models.Note, error) {
+	note, err := n.repo.GetByID(noteID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.Note{}, ErrNoteNotFound
+		}
+		log.Printf("Ошибка при получении заметки ID %d: %v", noteID, err)
+		return models.Note{}, err
+	}
+
+	if note.UserID != userID {
+		log.Printf("Пользователь ID %d пытался получить доступ к чужой заметке ID %d", userID, noteID)
+		return models.Note{}, ErrForbidden
+	}
+
+	return note, nil
+}
+
+// UpdateNote replaces title, description and due date of existing note
+// Note's UserID must match owner of the stored note; title and description go through same spell check as on creation
+// Note's Version must match stored version, otherwise ErrVersionConflict is returned
+func (n *NoteService) UpdateNote(note models.Note, spell models.SpellOptions) (models.Note, error) {
+	// Make sure note exists and belongs to user
+	if _, err := n.GetNote(note.UserID, note.ID); err != nil {
+		return models.Note{}, err
+	}
+
+	// Nil tags mean tags of note stay unchanged
+	var err error
+	if note.Tags, err = normalizeTags(note.Tags); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = n.checkNotebook(note.UserID, note.NotebookID); err != nil {
+		return models.Note{}, err
+	}
+
+	if note.Lang, err = normalizeLang(note.Lang); err != nil {
+		return models.Note{}, err
+	}
+
+	if note, err = n.spelling.checkNote(note, spell); err != nil {
+		return models.Note{}, err
+	}
+
+	updatedNote, err := n.repo.Update(note)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.Note{}, ErrNoteNotFound
+		}
+		if errors.Is(err, postgresql.ErrVersionConflict) {
+			log.Printf("Конфликт версий при обновлении заметки ID %d", note.ID)
+			return models.Note{}, ErrVersionConflict
+		}
+		log.Printf("Ошибка при обновлении заметки ID %d: %v", note.ID, err)
+		return models.Note{}, err
+	}
+
+	log.Printf("Заметка успешно обновлена: %v", updatedNote)
+	return updatedNote, nil
+}
+
+// MoveNote puts user's note into notebook, nil notebookID moves note out of any notebook
+// Note must have given version, otherwise ErrVersionConflict is returned
+// Revisions keep only title, description and due date, so moving note does not create revision
+func (n *NoteService) MoveNote(userID, noteID int, notebookID *int, version int) (models.Note, error) {
+	if _, err := n.GetNote(userID, noteID); err != nil {
+		return models.Note{}, err
+	}
+
+	if err := n.checkNotebook(userID, notebookID); err != nil {
+		return models.Note{}, err
+	}
+
+	if err := n.repo.Move(noteID, notebookID, version); err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.Note{}, ErrNoteNotFound
+		}
+		if errors.Is(err, postgresql.ErrVersionConflict) {
+			log.Printf("Конфликт версий при перемещении заметки ID %d", noteID)
+			return models.Note{}, ErrVersionConflict
+		}
+		log.Printf("Ошибка при перемещении заметки ID %d: %v", noteID, err)
+		return models.Note{}, err
+	}
+
+	log.Printf("Заметка ID %d перемещена в блокнот %v", noteID, notebookID)
+	return n.GetNote(userID, noteID)
+}
+
+// DeleteNote moves user's note to trash, or deletes it from database if permanent is set
+// Permanent deletion also works for notes that are already in trash
+// Note must have given version, otherwise ErrVersionConflict is returned
+func (n *NoteService) DeleteNote(userID, noteID, version int, permanent bool) error {
+	// Make sure note exists and belongs to user
+	note, err := n.getOwnedNote(userID, noteID)
+	if err != nil {
+		return err
+	}
+
+	if permanent {
+		err = n.repo.DeletePermanently(noteID, version)
+	} else if note.DeletedAt != nil {
+		return ErrNoteNotFound
+	} else {
+		err = n.repo.Delete(noteID, version)
+	}
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return ErrNoteNotFound
+		}
+		if errors.Is(err, postgresql.ErrVersionConflict) {
+			log.Printf("Конфликт версий при удалении заметки ID %d", noteID)
+			return ErrVersionConflict
+		}
+		log.Printf("Ошибка при удалении заметки ID %d: %v", noteID, err)
+		return err
+	}
+
+	if permanent {
+		log.Printf("Заметка ID %d удалена безвозвратно", noteID)
+	} else {
+		log.Printf("Заметка ID %d перемещена в корзину", noteID)
+	}
+	return nil
+}
+
+// RestoreNote moves user's note out of trash
+// Unlike other changes restoring does not require note version, note in trash can't be changed by anyone
+// Returns ErrNoteNotInTrash if note is not in trash
+func (n *NoteService) RestoreNote(userID, noteID int) (models.Note, error) {
+	note, err := n.getOwnedNote(userID, noteID)
+	if err != nil {
+		return models.Note{}, err
+	}
+	if note.DeletedAt == nil {
+		return models.Note{}, ErrNoteNotInTrash
+	}
+
+	if err = n.repo.Restore(noteID); err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.Note{}, ErrNoteNotInTrash
+		}
+		log.Printf("Ошибка при восстановлении заметки ID %d: %v", noteID, err)
+		return models.Note{}, err
+	}
+
+	log.Printf("Заметка ID %d восстановлена из корзины", noteID)
+	return n.GetNote(userID, noteID)
+}
+
+// GetTrash retrieves notes of user that are in trash
+func (n *NoteService) GetTrash(userID int) ([]models.Note, error) {
+	notes, err := n.repo.GetTrash(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении корзины: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Корзина успешно получена для пользователя ID: %d", userID)
 	return notes, nil
 }
 
+// PurgeTrash permanently deletes notes that have been in trash longer than retention period
+// Returns number of deleted notes
+func (n *NoteService) PurgeTrash(retention time.Duration) (int64, error) {
+	purged, err := n.repo.PurgeTrash(time.Now().Add(-retention))
+	if err != nil {
+		log.Printf("Ошибка при очистке корзины: %v", err)
+		return 0, err
+	}
+
+	if purged > 0 {
+		log.Printf("Из корзины безвозвратно удалено заметок: %d", purged)
+	}
+	return purged, nil
+}
+
+// checkNotebook makes sure notebook note is placed into exists and belongs to user
+func (n *NoteService) checkNotebook(userID int, notebookID *int) error {
+	if notebookID == nil {
+		return nil
+	}
+
+	notebook, err := n.notebookRepo.GetByID(*notebookID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNotebookNotFound) {
+			return ErrNotebookNotFound
+		}
+		log.Printf("Ошибка при получении блокнота ID %d: %v", *notebookID, err)
+		return err
+	}
+
+	if notebook.UserID != userID {
+		return ErrNotebookNotFound
+	}
+	return nil
+}
+
+// SearchNotes performs full-text search over title and description of user's notes
+// Results are ordered by relevance and contain highlighted fragments
+func (n *NoteService) SearchNotes(userID int, query string, limit int) ([]models.NoteSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	if limit <= 0 {
+		limit = defaultNoteListLimit
+	}
+	if limit > maxNoteListLimit {
+		limit = maxNoteListLimit
+	}
+
+	results, err := n.repo.Search(userID, query, limit)
+	if err != nil {
+		log.Printf("Ошибка при поиске заметок: %v", err)
+		return nil, err
+	}
+	for i := range results {
+		results[i].TitleHighlight = highlightHTML(results[i].TitleHighlight)
+		results[i].Snippet = highlightHTML(results[i].Snippet)
+	}
+
+	log.Printf("Найдено %d заметок для пользователя ID %d", len(results), userID)
+	return results, nil
+}
+
+// highlightHTML turns fragment highlighted by repository into safe HTML
+// Text of note is escaped and only markers of matched words become <b> tags
+func highlightHTML(fragment string) string {
+	fragment = html.EscapeString(fragment)
+	return strings.NewReplacer(postgresql.HighlightStart, "<b>", postgresql.HighlightStop, "</b>").Replace(fragment)
+}
+
 // formatSpellingErrors formats the list of spelling errors
 // Takes spelling check result and returns string with errors and suggestions
-func formatSpellingErrors(spellingResult []map[string]interface{}) string {
+func formatSpellingErrors(spellingResult []models.SpellingIssue) string {
 	var errorDetails string
-	for _, err := range spellingResult {
-		word := err["word"].(string)
-		suggestions := err["s"].([]interface{})
-		errorDetails += fmt.Sprintf("Слово: %s, предложения: %v\n", word, suggestions)
+	for _, issue := range spellingResult {
+		errorDetails += fmt.Sprintf("Слово: %s, предложения: %v\n", issue.Word, issue.Suggestions)
 	}
 	return errorDetails
 }
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+func TestHighlightHTML(t *testing.T) {
+	start, stop := postgresql.HighlightStart, postgresql.HighlightStop
+
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"plain text", "Встреча с командой", "Встреча с командой"},
+		{"matched word", "Встреча с " + start + "командой" + stop, "Встреча с <b>командой</b>"},
+		{"user markup is escaped", `<img src=x onerror="alert(1)"> ` + start + "план" + stop,
+			`&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <b>план</b>`},
+		{"user bold tag is escaped", "<b>" + start + "plan" + stop + "</b>", "&lt;b&gt;<b>plan</b>&lt;/b&gt;"},
+		{"several fragments", start + "a" + stop + " & " + start + "b" + stop, "<b>a</b> &amp; <b>b</b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightHTML(tt.fragment); got != tt.want {
+				t.Errorf("highlightHTML(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
+
+// listNoteRepo returns count notes from GetAll and remembers filter passed to it
+type listNoteRepo struct {
+	repository.NoteRepo
+	count  int
+	filter models.NoteFilter
+}
+
+func (r *listNoteRepo) GetAll(userID int, filter models.NoteFilter) ([]models.Note, error) {
+	r.filter = filter
+	notes := make([]models.Note, min(r.count, filter.Limit))
+	for i := range notes {
+		notes[i] = models.Note{ID: i + 1, UserID: userID, Title: "Заметка", CreatedAt: time.Unix(int64(i), 0)}
+	}
+	return notes, nil
+}
+
+func TestNoteCursorRoundTrip(t *testing.T) {
+	due := time.Date(2026, 10, 17, 9, 30, 0, 123, time.UTC)
+	created, updated := due.Add(-time.Hour), due.Add(-time.Minute)
+	note := models.Note{ID: 42, Title: "План \"Б\"", DueDate: &due, CreatedAt: created, UpdatedAt: updated}
+	noDueDate := note
+	noDueDate.DueDate = nil
+
+	tests := []struct {
+		name      string
+		sort      string
+		note      models.Note
+		wantValue string
+	}{
+		{"due date", models.NoteSortDueDate, note, due.Format(time.RFC3339Nano)},
+		{"no due date", models.NoteSortDueDate, noDueDate, "infinity"},
+		{"created at", models.NoteSortCreatedAt, note, created.Format(time.RFC3339Nano)},
+		{"updated at", models.NoteSortUpdatedAt, note, updated.Format(time.RFC3339Nano)},
+		{"title", models.NoteSortTitle, note, note.Title},
+	}
+
+	for _, tt := range tests {
+		for _, order := range []string{models.SortAsc, models.SortDesc} {
+			t.Run(tt.name+" "+order, func(t *testing.T) {
+				cursor := encodeNoteCursor(models.NoteFilter{Sort: tt.sort, Order: order}, tt.note)
+
+				// Request with cursor only inherits ordering from it
+				filter := models.NoteFilter{Cursor: cursor}
+				after, err := decodeNoteCursor(&filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if filter.Sort != tt.sort || filter.Order != order {
+					t.Errorf("ordering = %s %s, want %s %s", filter.Sort, filter.Order, tt.sort, order)
+				}
+				if after.Value != tt.wantValue || after.ID != tt.note.ID {
+					t.Errorf("cursor = %+v, want value %q and ID %d", *after, tt.wantValue, tt.note.ID)
+				}
+			})
+		}
+	}
+}
+
+func TestGetNoteListRejectsInvalidFilter(t *testing.T) {
+	titleCursor := encodeNoteCursor(models.NoteFilter{Sort: models.NoteSortTitle, Order: models.SortAsc},
+		models.Note{ID: 1, Title: "Заметка"})
+
+	tests := []struct {
+		name   string
+		filter models.NoteFilter
+	}{
+		{"unknown sort field", models.NoteFilter{Sort: "priority"}},
+		{"sort field in other case", models.NoteFilter{Sort: "Title"}},
+		{"unknown order", models.NoteFilter{Sort: models.NoteSortTitle, Order: "up"}},
+		{"order in other case", models.NoteFilter{Order: "DESC"}},
+		{"cursor is not base64", models.NoteFilter{Cursor: "not a cursor!"}},
+		{"cursor with padding", models.NoteFilter{Cursor: base64.URLEncoding.EncodeToString([]byte(`{"s":"title"}`))}},
+		{"cursor is not JSON", models.NoteFilter{Cursor: base64.RawURLEncoding.EncodeToString([]byte("title:1"))}},
+		{"cursor with unknown sort field", models.NoteFilter{
+			Cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"priority","o":"asc","v":"1","id":1}`))}},
+		{"cursor for other sort field", models.NoteFilter{Cursor: titleCursor, Sort: models.NoteSortCreatedAt}},
+		{"cursor for other order", models.NoteFilter{Cursor: titleCursor, Order: models.SortDesc}},
+		{"unknown tag mode", models.NoteFilter{TagMode: "xor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listNoteRepo{}
+			_, err := NewNoteService(repo, nil, nil).GetNoteList(7, tt.filter)
+			if !errors.Is(err, ErrInvalidList) {
+				t.Errorf("GetNoteList error = %v, want ErrInvalidList", err)
+			}
+		})
+	}
+}
+
+func TestGetNoteList(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     models.NoteFilter
+		count      int
+		wantFilter models.NoteFilter
+		wantItems  int
+		wantNext   bool
+	}{
+		{"defaults", models.NoteFilter{}, 3,
+			models.NoteFilter{Sort: models.NoteSortCreatedAt, Order: models.SortDesc, TagMode: models.TagModeAnd,
+				Limit: defaultNoteListLimit + 1}, 3, false},
+		{"default order of due date", models.NoteFilter{Sort: models.NoteSortDueDate}, 0,
+			models.NoteFilter{Sort: models.NoteSortDueDate, Order: models.SortAsc, TagMode: models.TagModeAnd,
+				Limit: defaultNoteListLimit + 1}, 0, false},
+		{"explicit order", models.NoteFilter{Sort: models.NoteSortTitle, Order: models.SortDesc, Limit: 5}, 5,
+			models.NoteFilter{Sort: models.NoteSortTitle, Order: models.SortDesc, TagMode: models.TagModeAnd,
+				Limit: 6}, 5, false},
+		{"limit is capped", models.NoteFilter{Limit: maxNoteListLimit + 1}, 0,
+			models.NoteFilter{Sort: models.NoteSortCreatedAt, Order: models.SortDesc, TagMode: models.TagModeAnd,
+				Limit: maxNoteListLimit + 1}, 0, false},
+		{"next page", models.NoteFilter{Limit: 2}, 3,
+			models.NoteFilter{Sort: models.NoteSortCreatedAt, Order: models.SortDesc, TagMode: models.TagModeAnd,
+				Limit: 3}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listNoteRepo{count: tt.count}
+			list, err := NewNoteService(repo, nil, nil).GetNoteList(7, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := repo.filter
+			if got.Sort != tt.wantFilter.Sort || got.Order != tt.wantFilter.Order ||
+				got.TagMode != tt.wantFilter.TagMode || got.Limit != tt.wantFilter.Limit || got.After != nil {
+				t.Errorf("repository filter = %+v, want %+v", got, tt.wantFilter)
+			}
+			if len(list.Items) != tt.wantItems || (list.NextCursor != "") != tt.wantNext {
+				t.Errorf("list has %d items and next cursor %q, want %d items, next cursor %v", len(list.Items),
+					list.NextCursor, tt.wantItems, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestGetNoteListNextPage(t *testing.T) {
+	repo := &listNoteRepo{count: 3}
+	service := NewNoteService(repo, nil, nil)
+	first, err := service.GetNoteList(7, models.NoteFilter{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cursor of next page points after the last returned note and keeps ordering of the first page
+	if _, err = service.GetNoteList(7, models.NoteFilter{Limit: 2, Cursor: first.NextCursor}); err != nil {
+		t.Fatal(err)
+	}
+	last := first.Items[len(first.Items)-1]
+	want := models.NoteCursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+	if repo.filter.After == nil || *repo.filter.After != want || repo.filter.Sort != models.NoteSortCreatedAt ||
+		repo.filter.Order != models.SortDesc {
+		t.Errorf("filter of next page = %+v, want created_at desc after %+v", repo.filter, want)
+	}
+}
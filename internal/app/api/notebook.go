@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrNotebookNotFound  = errors.New("блокнот не найден")
+	ErrInvalidNotebook   = errors.New("неправильные данные блокнота")
+	ErrNotebookCycle     = errors.New("блокнот нельзя вложить в самого себя или в свой дочерний блокнот")
+	ErrInvalidDeleteMode = errors.New("неизвестный режим удаления блокнота")
+)
+
+// NotebookService provides management of notebooks organised into tree
+type NotebookService struct {
+	repo repository.NotebookRepo
+}
+
+// NewNotebookService creates new instance of NotebookService
+func NewNotebookService(repo repository.NotebookRepo) *NotebookService {
+	return &NotebookService{repo: repo}
+}
+
+// CreateNotebook creates new notebook, parent notebook if given must belong to the same user
+func (ns *NotebookService) CreateNotebook(notebook models.Notebook) (models.Notebook, error) {
+	notebook.Name = strings.TrimSpace(notebook.Name)
+	if notebook.Name == "" {
+		return models.Notebook{}, fmt.Errorf("%w: пустое название", ErrInvalidNotebook)
+	}
+
+	if notebook.ParentID != nil {
+		if _, err := ns.GetNotebook(notebook.UserID, *notebook.ParentID); err != nil {
+			return models.Notebook{}, err
+		}
+	}
+
+	createdNotebook, err := ns.repo.Create(notebook)
+	if err != nil {
+		log.Printf("Ошибка при создании блокнота: %v", err)
+		return models.Notebook{}, err
+	}
+
+	log.Printf("Блокнот успешно создан: %v", createdNotebook)
+	return createdNotebook, nil
+}
+
+// GetNotebookTree retrieves all notebooks of user arranged into tree
+// Returned slice contains top-level notebooks with nested notebooks in Children
+func (ns *NotebookService) GetNotebookTree(userID int) ([]models.Notebook, error) {
+	notebooks, err := ns.repo.GetAll(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении списка блокнотов: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Список блокнотов успешно получен для пользователя ID: %d", userID)
+	return buildNotebookTree(notebooks), nil
+}
+
+// GetNotebook retrieves single notebook and makes sure it belongs to given user
+// Notebooks of other users are reported as not found
+func (ns *NotebookService) GetNotebook(userID, notebookID int) (models.Notebook, error) {
+	notebook, err := ns.repo.GetByID(notebookID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNotebookNotFound) {
+			return models.Notebook{}, ErrNotebookNotFound
+		}
+		log.Printf("Ошибка при получении блокнота ID %d: %v", notebookID, err)
+		return models.Notebook{}, err
+	}
+
+	if notebook.UserID != userID {
+		return models.Notebook{}, ErrNotebookNotFound
+	}
+	return notebook, nil
+}
+
+// UpdateNotebook renames notebook and moves it under another parent
+// Notebook can not be moved into itself or any of its nested notebooks
+func (ns *NotebookService) UpdateNotebook(notebook models.Notebook) (models.Notebook, error) {
+	if _, err := ns.GetNotebook(notebook.UserID, notebook.ID); err != nil {
+		return models.Notebook{}, err
+	}
+
+	notebook.Name = strings.TrimSpace(notebook.Name)
+	if notebook.Name == "" {
+		return models.Notebook{}, fmt.Errorf("%w: пустое название", ErrInvalidNotebook)
+	}
+
+	if notebook.ParentID != nil {
+		if _, err := ns.GetNotebook(notebook.UserID, *notebook.ParentID); err != nil {
+			return models.Notebook{}, err
+		}
+
+		cycle, err := ns.repo.IsDescendant(notebook.ID, *notebook.ParentID)
+		if err != nil {
+			log.Printf("Ошибка при проверке вложенности блокнота ID %d: %v", notebook.ID, err)
+			return models.Notebook{}, err
+		}
+		if cycle {
+			return models.Notebook{}, ErrNotebookCycle
+		}
+	}
+
+	updatedNotebook, err := ns.repo.Update(notebook)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNotebookNotFound) {
+			return models.Notebook{}, ErrNotebookNotFound
+		}
+		log.Printf("Ошибка при обновлении блокнота ID %d: %v", notebook.ID, err)
+		return models.Notebook{}, err
+	}
+
+	log.Printf("Блокнот успешно обновлен: %v", updatedNotebook)
+	return updatedNotebook, nil
+}
+
+// DeleteNotebook deletes user's notebook
+// Mode selects what happens to nested notebooks and notes, see models.NotebookDeleteCascade
+// and models.NotebookDeleteMoveToParent
+func (ns *NotebookService) DeleteNotebook(userID, notebookID int, mode string) error {
+	if mode != models.NotebookDeleteCascade && mode != models.NotebookDeleteMoveToParent {
+		return fmt.Errorf("%w: %q", ErrInvalidDeleteMode, mode)
+	}
+
+	if _, err := ns.GetNotebook(userID, notebookID); err != nil {
+		return err
+	}
+
+	if err := ns.repo.Delete(notebookID, mode); err != nil {
+		if errors.Is(err, postgresql.ErrNotebookNotFound) {
+			return ErrNotebookNotFound
+		}
+		log.Printf("Ошибка при удалении блокнота ID %d: %v", notebookID, err)
+		return err
+	}
+
+	log.Printf("Блокнот ID %d успешно удален в режиме %s", notebookID, mode)
+	return nil
+}
+
+// buildNotebookTree arranges flat list of notebooks into tree keeping original order of siblings
+func buildNotebookTree(notebooks []models.Notebook) []models.Notebook {
+	children := make(map[int][]models.Notebook)
+	var roots []models.Notebook
+	for _, notebook := range notebooks {
+		if notebook.ParentID == nil {
+			roots = append(roots, notebook)
+			continue
+		}
+		children[*notebook.ParentID] = append(children[*notebook.ParentID], notebook)
+	}
+
+	var attach func(nodes []models.Notebook) []models.Notebook
+	attach = func(nodes []models.Notebook) []models.Notebook {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	tree := attach(roots)
+	if tree == nil {
+		tree = []models.Notebook{}
+	}
+	return tree
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/oidc"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("неизвестный провайдер OIDC")
+	ErrInvalidOIDCState    = errors.New("недействительное или истекшее состояние входа через OIDC")
+	ErrOIDCLoginFailed     = errors.New("не удалось войти через провайдера OIDC")
+	ErrOIDCUnavailable     = errors.New("провайдер OIDC недоступен")
+	ErrIdentityLinked      = errors.New("учетная запись провайдера уже привязана")
+	ErrIdentityNotFound    = errors.New("учетная запись провайдера не привязана")
+)
+
+const (
+	// oidcStateTTL is time user has to log in at provider after login is started
+	oidcStateTTL = 10 * time.Minute
+
+	// oidcRequestTimeout limits requests to discovery, token and JWKS endpoints of providers
+	oidcRequestTimeout = 10 * time.Second
+
+	// maxUsernameAttempts is number of numbered variants of username tried for new user before random suffix is used
+	maxUsernameAttempts = 20
+)
+
+// OIDCService provides login with OpenID Connect providers using authorization code flow with PKCE
+// Account at provider is mapped to user through its identity: unknown account gets new user on first login,
+// authenticated user can link account at provider to existing account
+type OIDCService struct {
+	auth       *AuthService
+	identities repository.IdentityRepo
+	providers  map[string]*oidc.Provider
+}
+
+// NewOIDCService creates new instance of OIDCService for given providers
+// Sessions of users logged in with providers are started by auth
+func NewOIDCService(auth *AuthService, identities repository.IdentityRepo, providers []*oidc.Provider) *OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &OIDCService{auth: auth, identities: identities, providers: byName}
+}
+
+// StartOIDCLogin starts login with provider and returns URL user agent is redirected to
+// If userID is not zero, account at provider is linked to this user instead of logging in
+// State, nonce and PKCE code verifier are stored until callback, state is returned to be bound to user agent
+func (o *OIDCService) StartOIDCLogin(provider string, userID int) (models.OIDCAuthorization, error) {
+	p, ok := o.providers[provider]
+	if !ok {
+		return models.OIDCAuthorization{}, ErrUnknownOIDCProvider
+	}
+
+	state, err := generateRandomToken(32)
+	if err != nil {
+		return models.OIDCAuthorization{}, err
+	}
+	nonce, err := generateRandomToken(16)
+	if err != nil {
+		return models.OIDCAuthorization{}, err
+	}
+	verifier, challenge, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return models.OIDCAuthorization{}, err
+	}
+
+	authURL, err := p.AuthCodeURL(state, nonce, challenge)
+	if err != nil {
+		log.Printf("Ошибка при обращении к провайдеру OIDC %s: %v", provider, err)
+		return models.OIDCAuthorization{}, ErrOIDCUnavailable
+	}
+
+	loginState := models.OIDCLoginState{
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if userID != 0 {
+		loginState.UserID = &userID
+	}
+	if err = o.identities.CreateLoginState(loginState, hashToken(state)); err != nil {
+		log.Printf("Ошибка при сохранении состояния входа через OIDC: %v", err)
+		return models.OIDCAuthorization{}, err
+	}
+
+	return models.OIDCAuthorization{URL: authURL, State: state}, nil
+}
+
+// CompleteOIDCLogin handles callback from provider with authorization code
+// It exchanges code for ID token, verifies it and either logs user in or links account at provider to user
+// who started linking. browserState is state bound to user agent, login must be completed in user agent
+// that started it; linking is bound to user by stored state instead
+func (o *OIDCService) CompleteOIDCLogin(provider, state, browserState, code string) (models.OIDCLoginResult, error) {
+	p, ok := o.providers[provider]
+	if !ok {
+		return models.OIDCLoginResult{}, ErrUnknownOIDCProvider
+	}
+
+	loginState, err := o.identities.TakeLoginState(hashToken(state))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrLoginStateNotFound) {
+			log.Printf("Неизвестное состояние входа через OIDC %s", provider)
+			return models.OIDCLoginResult{}, ErrInvalidOIDCState
+		}
+		log.Printf("Ошибка при получении состояния входа через OIDC: %v", err)
+		return models.OIDCLoginResult{}, err
+	}
+	if loginState.Provider != provider || time.Now().After(loginState.ExpiresAt) {
+		log.Printf("Состояние входа через OIDC %s истекло или выдано другому провайдеру", provider)
+		return models.OIDCLoginResult{}, ErrInvalidOIDCState
+	}
+	if loginState.UserID == nil && subtle.ConstantTimeCompare([]byte(browserState), []byte(state)) != 1 {
+		log.Printf("Вход через OIDC %s завершается не в том браузере, в котором начат", provider)
+		return models.OIDCLoginResult{}, ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := p.Exchange(code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("Ошибка при обмене кода авторизации OIDC %s: %v", provider, err)
+		return models.OIDCLoginResult{}, ErrOIDCLoginFailed
+	}
+	claims, err := p.VerifyIDToken(rawIDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("Ошибка при проверке ID-токена OIDC %s: %v", provider, err)
+		return models.OIDCLoginResult{}, ErrOIDCLoginFailed
+	}
+
+	if loginState.UserID != nil {
+		return o.link(*loginState.UserID, provider, claims)
+	}
+	return o.login(provider, claims)
+}
+
+// GetIdentities retrieves accounts at providers linked to user
+func (o *OIDCService) GetIdentities(userID int) ([]models.UserIdentity, error) {
+	identities, err := o.identities.GetAll(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении привязанных учетных записей пользователя ID %d: %v", userID, err)
+		return nil, err
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity unlinks account at provider from user
+func (o *OIDCService) UnlinkIdentity(userID int, provider string) error {
+	if err := o.identities.Delete(userID, provider); err != nil {
+		if errors.Is(err, postgresql.ErrIdentityNotFound) {
+			return ErrIdentityNotFound
+		}
+		log.Printf("Ошибка при отвязке учетной записи %s пользователя ID %d: %v", provider, userID, err)
+		return err
+	}
+
+	log.Printf("Пользователь ID %d отвязал учетную запись %s", userID, provider)
+	return nil
+}
+
+// login logs in user whose account at provider is linked to them, account seen for the first time gets new user
+func (o *OIDCService) login(provider string, claims oidc.Claims) (models.OIDCLoginResult, error) {
+	identity, err := o.identities.GetByProviderSubject(provider, claims.Subject)
+	switch {
+	case errors.Is(err, postgresql.ErrIdentityNotFound):
+		if identity, err = o.signUp(provider, claims); err != nil {
+			return models.OIDCLoginResult{}, err
+		}
+	case err != nil:
+		log.Printf("Ошибка при получении учетной записи %s: %v", provider, err)
+		return models.OIDCLoginResult{}, err
+	default:
+		if err = o.identities.MarkUsed(identity.ID); err != nil {
+			log.Printf("Ошибка при обновлении времени входа через %s: %v", provider, err)
+		}
+	}
+
+	user, err := o.auth.repo.GetByID(identity.UserID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для входа через %s: %v", identity.UserID, provider, err)
+		return models.OIDCLoginResult{}, err
+	}
+	if user.DisabledAt != nil {
+		log.Printf("Попытка входа через %s в отключенную учетную запись: %s", provider, user.Name)
+		return models.OIDCLoginResult{}, ErrUserDisabled
+	}
+
+	tokens, err := o.auth.issueTokens(user)
+	if err != nil {
+		return models.OIDCLoginResult{}, err
+	}
+
+	log.Printf("Пользователь %s вошел через %s", user.Name, provider)
+	return models.OIDCLoginResult{Tokens: tokens, Identity: identity}, nil
+}
+
+// link links account at provider to user who started linking
+func (o *OIDCService) link(userID int, provider string, claims oidc.Claims) (models.OIDCLoginResult, error) {
+	identity, err := o.identities.Create(models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		if errors.Is(err, postgresql.ErrIdentityExists) {
+			log.Printf("Учетная запись %s уже привязана, пользователь ID %d", provider, userID)
+			return models.OIDCLoginResult{}, ErrIdentityLinked
+		}
+		log.Printf("Ошибка при привязке учетной записи %s пользователя ID %d: %v", provider, userID, err)
+		return models.OIDCLoginResult{}, err
+	}
+
+	log.Printf("Пользователь ID %d привязал учетную запись %s", userID, provider)
+	return models.OIDCLoginResult{Identity: identity, Linked: true}, nil
+}
+
+// signUp creates new user for account at provider seen for the first time
+// Username is taken from ID token, user gets random password that can be replaced through password reset
+func (o *OIDCService) signUp(provider string, claims oidc.Claims) (models.UserIdentity, error) {
+	name, err := o.freeUsername(provider, claims)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+
+	password, err := o.auth.policy.generatePassword()
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+	passwordHash, err := generatePasswordHash(password)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+
+	identity, err := o.identities.CreateUser(models.User{Name: name, Password: passwordHash},
+		models.UserIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email})
+	if err != nil {
+		log.Printf("Ошибка при создании пользователя для учетной записи %s: %v", provider, err)
+		return models.UserIdentity{}, err
+	}
+
+	log.Printf("Создан пользователь %s для учетной записи %s", name, provider)
+	return identity, nil
+}
+
+// freeUsername returns username for new user that is not taken yet
+// It is based on preferred_username or email from ID token, numbered suffix is added if name is taken
+func (o *OIDCService) freeUsername(provider string, claims oidc.Claims) (string, error) {
+	base := strings.TrimSpace(claims.PreferredUsername)
+	if base == "" {
+		base = strings.TrimSpace(claims.Email)
+	}
+	if base == "" {
+		base = provider + "-" + claims.Subject
+	}
+
+	for i := 1; i <= maxUsernameAttempts; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		_, err := o.auth.repo.GetByName(name)
+		if errors.Is(err, postgresql.ErrNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			log.Printf("Ошибка при получении пользователя: %v", err)
+			return "", err
+		}
+	}
+
+	suffix, err := generateRandomToken(6)
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + suffix, nil
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/oidc"
+	"rest-notes/internal/app/oidctest"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// memIdentityRepo keeps login states and identities in memory, users are created in users
+type memIdentityRepo struct {
+	repository.IdentityRepo
+	users      *memUserRepo
+	states     map[string]models.OIDCLoginState
+	identities []models.UserIdentity
+}
+
+func (r *memIdentityRepo) CreateLoginState(state models.OIDCLoginState, stateHash string) error {
+	r.states[stateHash] = state
+	return nil
+}
+
+func (r *memIdentityRepo) TakeLoginState(stateHash string) (models.OIDCLoginState, error) {
+	state, ok := r.states[stateHash]
+	if !ok {
+		return models.OIDCLoginState{}, postgresql.ErrLoginStateNotFound
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+func (r *memIdentityRepo) GetByProviderSubject(provider, subject string) (models.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return models.UserIdentity{}, postgresql.ErrIdentityNotFound
+}
+
+func (r *memIdentityRepo) Create(identity models.UserIdentity) (models.UserIdentity, error) {
+	if _, err := r.GetByProviderSubject(identity.Provider, identity.Subject); err == nil {
+		return models.UserIdentity{}, postgresql.ErrIdentityExists
+	}
+	identity.ID = len(r.identities) + 1
+	r.identities = append(r.identities, identity)
+	return identity, nil
+}
+
+func (r *memIdentityRepo) CreateUser(user models.User, identity models.UserIdentity) (models.UserIdentity, error) {
+	if err := r.users.Create(user); err != nil {
+		return models.UserIdentity{}, err
+	}
+	identity.UserID = r.users.users[user.Name].ID
+	return r.Create(identity)
+}
+
+func (r *memIdentityRepo) MarkUsed(id int) error {
+	return nil
+}
+
+// sessionCreator accepts every new session
+type sessionCreator struct {
+	repository.SessionRepo
+}
+
+func (sessionCreator) Create(session models.Session, refreshTokenHash string) error {
+	return nil
+}
+
+// newOIDCTestService creates OIDCService with mock provider "mock" and given existing users
+func newOIDCTestService(t *testing.T, users ...models.User) (*OIDCService, *memIdentityRepo, *oidctest.Issuer) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(nil)
+	issuer, err := oidctest.New("http://"+server.Listener.Addr().String(), "rest-notes", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Config.Handler = issuer
+	server.Start()
+	t.Cleanup(server.Close)
+
+	provider := oidc.NewProvider("mock", oidc.Config{
+		Issuer:       server.URL,
+		ClientID:     "rest-notes",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "profile", "email"},
+		RedirectURL:  "http://app.example.com/auth/oidc/mock/callback",
+	}, server.Client())
+
+	policy, err := NewPasswordPolicy(8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newMemUserRepo(t, users...)
+	identities := &memIdentityRepo{users: repo, states: make(map[string]models.OIDCLoginState)}
+	auth := &AuthService{repo: repo, sessions: sessionCreator{}, policy: policy, keys: newTestKeySet(t),
+		jwtIssuer: "rest-notes", jwtAudience: "rest-notes", accessTokenTTL: time.Minute, refreshTokenTTL: time.Hour}
+
+	return NewOIDCService(auth, identities, []*oidc.Provider{provider}), identities, issuer
+}
+
+// loginAtProvider goes through authorization endpoint of provider as user with given login
+// and returns state and code from callback
+func loginAtProvider(t *testing.T, authorization models.OIDCAuthorization, login string) (string, string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authorization.URL + "&login_hint=" + url.QueryEscape(login))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Query().Get("code") == "" {
+		t.Fatalf("provider redirected to %s without code", callback)
+	}
+	return callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+// oidcLogin logs in with provider as user with given login
+func oidcLogin(t *testing.T, service *OIDCService, login string) (models.OIDCLoginResult, error) {
+	t.Helper()
+
+	authorization, err := service.StartOIDCLogin("mock", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := loginAtProvider(t, authorization, login)
+	return service.CompleteOIDCLogin("mock", state, authorization.State, code)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	service, identities, _ := newOIDCTestService(t)
+
+	// First login creates user
+	result, err := oidcLogin(t, service, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tokens.AccessToken == "" || result.Linked {
+		t.Errorf("login result = %+v, want tokens", result)
+	}
+	user, ok := identities.users.users["alice"]
+	if !ok {
+		t.Fatal("user was not created")
+	}
+	if result.Identity.UserID != user.ID || result.Identity.Subject != "alice" {
+		t.Errorf("identity = %+v, want account alice of user ID %d", result.Identity, user.ID)
+	}
+
+	// Next login finds the same user
+	if result, err = oidcLogin(t, service, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Identity.UserID != user.ID || len(identities.users.users) != 1 || len(identities.identities) != 1 {
+		t.Errorf("second login created user or identity, identity = %+v", result.Identity)
+	}
+}
+
+func TestOIDCLoginUsernameCollision(t *testing.T) {
+	// Local user alice is not taken over by account alice at provider
+	service, identities, _ := newOIDCTestService(t,
+		models.User{Name: "alice", Password: "alice-password"},
+		models.User{Name: "alice-2", Password: "alice-password"})
+
+	result, err := oidcLogin(t, service, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, ok := identities.users.users["alice-3"]
+	if !ok {
+		t.Fatalf("user alice-3 was not created, users: %v", identities.users.users)
+	}
+	if result.Identity.UserID != user.ID || user.ID == identities.users.users["alice"].ID {
+		t.Errorf("account is linked to user ID %d, want new user ID %d", result.Identity.UserID, user.ID)
+	}
+}
+
+func TestFreeUsername(t *testing.T) {
+	taken := func(names ...string) []models.User {
+		users := make([]models.User, 0, len(names))
+		for _, name := range names {
+			users = append(users, models.User{Name: name, Password: "password"})
+		}
+		return users
+	}
+	allTaken := []string{"alice"}
+	for i := 2; i <= maxUsernameAttempts; i++ {
+		allTaken = append(allTaken, fmt.Sprintf("alice-%d", i))
+	}
+
+	tests := []struct {
+		name       string
+		claims     oidc.Claims
+		taken      []models.User
+		want       string
+		wantPrefix string
+	}{
+		{"preferred username", oidc.Claims{Subject: "42", PreferredUsername: "alice", Email: "a@example.com"},
+			nil, "alice", ""},
+		{"surrounding spaces", oidc.Claims{Subject: "42", PreferredUsername: " alice "}, nil, "alice", ""},
+		{"email without preferred username", oidc.Claims{Subject: "42", Email: "a@example.com"}, nil,
+			"a@example.com", ""},
+		{"provider and subject without name and email", oidc.Claims{Subject: "42"}, nil, "mock-42", ""},
+		{"taken name", oidc.Claims{Subject: "42", PreferredUsername: "alice"}, taken("alice"), "alice-2", ""},
+		{"taken numbered names", oidc.Claims{Subject: "42", PreferredUsername: "alice"},
+			taken("alice", "alice-2", "alice-3"), "alice-4", ""},
+		{"all numbered names taken", oidc.Claims{Subject: "42", PreferredUsername: "alice"}, taken(allTaken...),
+			"", "alice-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memUserRepo{users: make(map[string]models.User)}
+			for _, user := range tt.taken {
+				repo.Create(user)
+			}
+			service := NewOIDCService(&AuthService{repo: repo}, nil, nil)
+
+			name, err := service.freeUsername("mock", tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && name != tt.want {
+				t.Errorf("freeUsername = %q, want %q", name, tt.want)
+			}
+			if tt.wantPrefix != "" {
+				if _, ok := repo.users[name]; ok || !strings.HasPrefix(name, tt.wantPrefix) {
+					t.Errorf("freeUsername = %q, want free name starting with %q", name, tt.wantPrefix)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteOIDCLoginRejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		browserState func(state string) string
+		change       func(state *models.OIDCLoginState)
+		overrides    map[string]interface{}
+		wantErr      error
+	}{
+		{"browser state of other login", func(string) string { return "other-state" }, nil, nil,
+			ErrInvalidOIDCState},
+		{"no browser state", func(string) string { return "" }, nil, nil, ErrInvalidOIDCState},
+		{"expired state", nil, func(state *models.OIDCLoginState) {
+			state.ExpiresAt = time.Now().Add(-time.Second)
+		}, nil, ErrInvalidOIDCState},
+		{"state of other provider", nil, func(state *models.OIDCLoginState) {
+			state.Provider = "other"
+		}, nil, ErrInvalidOIDCState},
+		{"PKCE verifier mismatch", nil, func(state *models.OIDCLoginState) {
+			state.CodeVerifier = "other-verifier-of-sufficient-length-0123456789"
+		}, nil, ErrOIDCLoginFailed},
+		{"nonce mismatch", nil, func(state *models.OIDCLoginState) {
+			state.Nonce = "other-nonce"
+		}, nil, ErrOIDCLoginFailed},
+		{"wrong issuer", nil, nil, map[string]interface{}{"iss": "http://evil.example.com"}, ErrOIDCLoginFailed},
+		{"wrong audience", nil, nil, map[string]interface{}{"aud": "other-client"}, ErrOIDCLoginFailed},
+		{"several audiences without azp", nil, nil, map[string]interface{}{"aud": []string{"rest-notes", "other"}},
+			ErrOIDCLoginFailed},
+		{"wrong azp", nil, nil, map[string]interface{}{"aud": []string{"rest-notes", "other"}, "azp": "other"},
+			ErrOIDCLoginFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, identities, issuer := newOIDCTestService(t)
+			issuer.OverrideClaims(tt.overrides)
+
+			authorization, err := service.StartOIDCLogin("mock", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.change != nil {
+				for hash, state := range identities.states {
+					tt.change(&state)
+					identities.states[hash] = state
+				}
+			}
+			state, code := loginAtProvider(t, authorization, "alice")
+			browserState := authorization.State
+			if tt.browserState != nil {
+				browserState = tt.browserState(state)
+			}
+
+			_, err = service.CompleteOIDCLogin("mock", state, browserState, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteOIDCLogin error = %v, want %v", err, tt.wantErr)
+			}
+			if len(identities.users.users) != 0 || len(identities.identities) != 0 {
+				t.Errorf("rejected login created user or identity")
+			}
+		})
+	}
+}
+
+func TestCompleteOIDCLoginStateIsSingleUse(t *testing.T) {
+	service, _, _ := newOIDCTestService(t)
+
+	authorization, err := service.StartOIDCLogin("mock", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := loginAtProvider(t, authorization, "alice")
+	if _, err = service.CompleteOIDCLogin("mock", state, authorization.State, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.CompleteOIDCLogin("mock", state, authorization.State, code); !errors.Is(err,
+		ErrInvalidOIDCState) {
+		t.Errorf("replayed callback error = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestLinkOIDCIdentity(t *testing.T) {
+	service, identities, _ := newOIDCTestService(t,
+		models.User{Name: "alice", Password: "alice-password"},
+		models.User{Name: "bob", Password: "bob-password"})
+	alice, bob := identities.users.users["alice"], identities.users.users["bob"]
+
+	link := func(userID int) (models.OIDCLoginResult, error) {
+		authorization, err := service.StartOIDCLogin("mock", userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Linking is bound to user by stored state, so browser state is not needed
+		state, code := loginAtProvider(t, authorization, "alice-sso")
+		return service.CompleteOIDCLogin("mock", state, "", code)
+	}
+
+	result, err := link(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Linked || result.Identity.UserID != alice.ID || result.Tokens.AccessToken != "" {
+		t.Errorf("link result = %+v, want identity linked to user ID %d without tokens", result, alice.ID)
+	}
+
+	// Account linked to one user can't be linked to another one
+	if _, err = link(bob.ID); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("linking linked account error = %v, want ErrIdentityLinked", err)
+	}
+
+	// Linked account logs in as user it is linked to
+	if result, err = oidcLogin(t, service, "alice-sso"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Identity.UserID != alice.ID || len(identities.users.users) != 2 {
+		t.Errorf("login with linked account got identity %+v, want user ID %d", result.Identity, alice.ID)
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+	"rest-notes/internal/app/mail"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrWeakPassword      = errors.New("пароль не соответствует требованиям")
+	ErrInvalidPassword   = errors.New("неверный пароль")
+	ErrInvalidResetToken = errors.New("недействительный токен сброса пароля")
+)
+
+// maxPasswordBytes is maximum length of password that bcrypt takes into account
+const maxPasswordBytes = 72
+
+// PasswordPolicy checks that new passwords are long enough and are not among known breached passwords
+type PasswordPolicy struct {
+	minLength int
+	breached  map[string]struct{}
+}
+
+// NewPasswordPolicy creates password policy requiring at least minLength characters
+// If breachedList is not empty, it is path to file with one breached password per line, such passwords are rejected
+func NewPasswordPolicy(minLength int, breachedList string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{minLength: minLength, breached: map[string]struct{}{}}
+	if breachedList == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(breachedList)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			policy.breached[strings.ToLower(password)] = struct{}{}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Validate checks password of user with given name against policy
+// Returns error wrapping ErrWeakPassword that explains what is wrong with password
+func (pp *PasswordPolicy) Validate(username, password string) error {
+	if utf8.RuneCountInString(password) < pp.minLength {
+		return fmt.Errorf("%w: пароль должен содержать не менее %d символов", ErrWeakPassword, pp.minLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: пароль не должен быть длиннее %d байт", ErrWeakPassword, maxPasswordBytes)
+	}
+	if strings.EqualFold(password, username) {
+		return fmt.Errorf("%w: пароль не должен совпадать с именем пользователя", ErrWeakPassword)
+	}
+	if _, ok := pp.breached[strings.ToLower(password)]; ok {
+		return fmt.Errorf("%w: пароль найден в списке утекших паролей", ErrWeakPassword)
+	}
+	return nil
+}
+
+// generatePassword returns random password satisfying policy
+func (pp *PasswordPolicy) generatePassword() (string, error) {
+	// n random bytes are encoded into at least 4n/3 characters
+	return generateRandomToken(max(temporaryPasswordBytes, (pp.minLength*3+3)/4))
+}
+
+// ChangePassword replaces password of user after checking their current password
+// All other sessions of user are ended, session the password is changed in stays active
+// Wrong current passwords are counted by login guard like wrong passwords on login
+func (as *AuthService) ChangePassword(userID int, sessionID, currentPassword, newPassword, ip string) error {
+	user, err := as.repo.GetByID(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для смены пароля: %v", userID, err)
+		return err
+	}
+
+	if err = as.guard.Check(user.Name, ip); err != nil {
+		return err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		log.Printf("Неверный текущий пароль при смене пароля пользователя ID %d, IP %s", userID, ip)
+		as.guard.Fail(user.Name, ip)
+		return ErrInvalidPassword
+	}
+	as.guard.Success(user.Name)
+
+	if err = as.policy.Validate(user.Name, newPassword); err != nil {
+		return err
+	}
+
+	hash, err := generatePasswordHash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err = as.repo.UpdatePassword(userID, hash); err != nil {
+		log.Printf("Ошибка при смене пароля пользователя ID %d: %v", userID, err)
+		return err
+	}
+
+	if err = as.sessions.RevokeOthersForUser(userID, sessionID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя ID %d: %v", userID, err)
+		return err
+	}
+
+	log.Printf("Пользователь ID %d сменил пароль", userID)
+	return nil
+}
+
+// RequestPasswordReset sends single-use password reset token to user with given name
+// Users have no e-mail address, so message is addressed by name and mailer only writes it to log or file
+// Unknown and disabled users get nothing, but it is not reported to caller, so that it can't be used
+// to find out which users exist
+func (as *AuthService) RequestPasswordReset(name string) error {
+	user, err := as.repo.GetByName(name)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNotFound) {
+			log.Printf("Запрошен сброс пароля неизвестного пользователя %s", name)
+			return nil
+		}
+		log.Printf("Ошибка при получении пользователя для сброса пароля: %v", err)
+		return err
+	}
+	if user.DisabledAt != nil {
+		log.Printf("Запрошен сброс пароля отключенного пользователя %s", name)
+		return nil
+	}
+
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(as.resetTokenTTL)
+
+	if err = as.resets.Create(user.ID, hashToken(token), expiresAt); err != nil {
+		log.Printf("Ошибка при создании токена сброса пароля пользователя ID %d: %v", user.ID, err)
+		return err
+	}
+
+	err = as.mailer.Send(mail.Message{
+		To:      user.Name,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Для сброса пароля используйте токен %s\nТокен действителен до %s",
+			token, expiresAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.Printf("Ошибка при отправке токена сброса пароля пользователю ID %d: %v", user.ID, err)
+		return err
+	}
+
+	log.Printf("Пользователю ID %d отправлен токен сброса пароля", user.ID)
+	return nil
+}
+
+// ResetPassword sets new password of user using password reset token
+// Token can be used only once and only before it expires; all sessions of user are ended after reset
+func (as *AuthService) ResetPassword(resetToken, newPassword string) error {
+	token, err := as.resets.GetByHash(hashToken(resetToken))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrResetTokenNotFound) {
+			log.Printf("Попытка сброса пароля с неизвестным токеном")
+			return ErrInvalidResetToken
+		}
+		log.Printf("Ошибка при получении токена сброса пароля: %v", err)
+		return err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		log.Printf("Токен сброса пароля пользователя ID %d использован или истек", token.UserID)
+		return ErrInvalidResetToken
+	}
+
+	user, err := as.repo.GetByID(token.UserID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для сброса пароля: %v", token.UserID, err)
+		return err
+	}
+	if user.DisabledAt != nil {
+		log.Printf("Попытка сброса пароля отключенного пользователя ID %d", user.ID)
+		return ErrInvalidResetToken
+	}
+
+	if err = as.policy.Validate(user.Name, newPassword); err != nil {
+		return err
+	}
+
+	hash, err := generatePasswordHash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err = as.resets.Use(token, hash); err != nil {
+		if errors.Is(err, postgresql.ErrResetTokenUsed) {
+			return ErrInvalidResetToken
+		}
+		log.Printf("Ошибка при сбросе пароля пользователя ID %d: %v", user.ID, err)
+		return err
+	}
+
+	if err = as.sessions.RevokeAllForUser(user.ID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя ID %d: %v", user.ID, err)
+		return err
+	}
+
+	log.Printf("Пароль пользователя ID %d сброшен", user.ID)
+	return nil
+}
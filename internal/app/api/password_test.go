@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+)
+
+// revokingSessionRepo remembers sessions kept when other sessions of user are revoked
+type revokingSessionRepo struct {
+	repository.SessionRepo
+	kept []string
+}
+
+func (r *revokingSessionRepo) RevokeOthersForUser(userID int, keepID string) error {
+	r.kept = append(r.kept, keepID)
+	return nil
+}
+
+// newPasswordTestService creates AuthService for user "alice" with password "old-password-123"
+func newPasswordTestService(t *testing.T, guard *LoginGuard) (*AuthService, *memUserRepo, *revokingSessionRepo) {
+	t.Helper()
+
+	repo := newMemUserRepo(t, models.User{Name: "alice", Password: "old-password-123"})
+	sessions := &revokingSessionRepo{}
+	policy, err := NewPasswordPolicy(8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AuthService{repo: repo, sessions: sessions, policy: policy, guard: guard}, repo, sessions
+}
+
+func TestChangePassword(t *testing.T) {
+	as, repo, sessions := newPasswordTestService(t, NewLoginGuard(3, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	if err := as.ChangePassword(1, "s1", "wrong-password", "new-password-456", "10.0.0.1"); !errors.Is(err,
+		ErrInvalidPassword) {
+		t.Fatalf("ChangePassword with wrong current password error = %v, want ErrInvalidPassword", err)
+	}
+	if err := as.ChangePassword(1, "s1", "old-password-123", "alice", "10.0.0.1"); !errors.Is(err,
+		ErrWeakPassword) {
+		t.Fatalf("ChangePassword with weak new password error = %v, want ErrWeakPassword", err)
+	}
+	if err := as.ChangePassword(1, "s1", "old-password-123", "new-password-456", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Get(models.User{Name: "alice", Password: "new-password-456"}); err != nil {
+		t.Errorf("new password does not work: %v", err)
+	}
+	if len(sessions.kept) != 1 || sessions.kept[0] != "s1" {
+		t.Errorf("sessions kept on revoke = %v, want only current session", sessions.kept)
+	}
+}
+
+func TestChangePasswordGuessesAreLimited(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	// Two free attempts, then delay, third failure locks user out
+	as, _, _ := newPasswordTestService(t, NewLoginGuard(2, delay, delay, 3, 0, time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if err := as.ChangePassword(1, "s1", "guess", "new-password-456", "10.0.0.1"); !errors.Is(err,
+			ErrInvalidPassword) {
+			t.Fatalf("guess %d error = %v, want ErrInvalidPassword", i+1, err)
+		}
+	}
+
+	// Right away even correct password is rejected
+	var blocked *LoginBlockedError
+	err := as.ChangePassword(1, "s1", "old-password-123", "new-password-456", "10.0.0.1")
+	if !errors.As(err, &blocked) || blocked.RetryAfter <= 0 {
+		t.Fatalf("ChangePassword during delay error = %v, want *LoginBlockedError", err)
+	}
+
+	// Guesses are counted per user, so changing address does not help
+	time.Sleep(delay + 10*time.Millisecond)
+	if err = as.ChangePassword(1, "s1", "guess", "new-password-456", "10.0.0.2"); !errors.Is(err,
+		ErrInvalidPassword) {
+		t.Fatalf("third guess error = %v, want ErrInvalidPassword", err)
+	}
+	err = as.ChangePassword(1, "s1", "old-password-123", "new-password-456", "10.0.0.3")
+	if !errors.As(err, &blocked) || blocked.RetryAfter < time.Minute {
+		t.Fatalf("ChangePassword after lockout error = %v, want lockout", err)
+	}
+	if !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Errorf("lockout error %v does not wrap ErrTooManyLoginAttempts", err)
+	}
+}
+
+func TestChangePasswordSuccessResetsGuard(t *testing.T) {
+	as, _, _ := newPasswordTestService(t, NewLoginGuard(2, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	// Failure before successful change is forgotten, so next failure is free again
+	as.ChangePassword(1, "s1", "guess", "new-password-456", "10.0.0.1")
+	if err := as.ChangePassword(1, "s1", "old-password-123", "new-password-456", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	as.ChangePassword(1, "s1", "guess", "other-password-789", "10.0.0.2")
+	if err := as.ChangePassword(1, "s1", "new-password-456", "other-password-789", "10.0.0.2"); err != nil {
+		t.Errorf("ChangePassword after forgotten failure error = %v", err)
+	}
+}
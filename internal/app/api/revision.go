@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("ревизия заметки не найдена")
+	ErrInvalidDiffMode  = errors.New("неизвестный режим сравнения")
+)
+
+// GetNoteRevisions retrieves history of user's note, newest revision first
+func (n *NoteService) GetNoteRevisions(userID, noteID int) ([]models.NoteRevision, error) {
+	if _, err := n.GetNote(userID, noteID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := n.repo.GetRevisions(noteID)
+	if err != nil {
+		log.Printf("Ошибка при получении ревизий заметки ID %d: %v", noteID, err)
+		return nil, err
+	}
+
+	log.Printf("Ревизии заметки ID %d успешно получены", noteID)
+	return revisions, nil
+}
+
+// GetNoteRevision retrieves single revision of user's note
+func (n *NoteService) GetNoteRevision(userID, noteID, revision int) (models.NoteRevision, error) {
+	if _, err := n.GetNote(userID, noteID); err != nil {
+		return models.NoteRevision{}, err
+	}
+	return n.getRevision(noteID, revision)
+}
+
+// DiffNoteRevisions compares two revisions of user's note at line or word granularity
+func (n *NoteService) DiffNoteRevisions(userID, noteID, from, to int, mode string) (models.NoteDiff, error) {
+	if mode == "" {
+		mode = models.DiffModeLine
+	}
+	if mode != models.DiffModeLine && mode != models.DiffModeWord {
+		return models.NoteDiff{}, fmt.Errorf("%w: %q", ErrInvalidDiffMode, mode)
+	}
+
+	if _, err := n.GetNote(userID, noteID); err != nil {
+		return models.NoteDiff{}, err
+	}
+
+	fromRevision, err := n.getRevision(noteID, from)
+	if err != nil {
+		return models.NoteDiff{}, err
+	}
+	toRevision, err := n.getRevision(noteID, to)
+	if err != nil {
+		return models.NoteDiff{}, err
+	}
+
+	return models.NoteDiff{
+		From:        from,
+		To:          to,
+		Mode:        mode,
+		Title:       diffText(fromRevision.Title, toRevision.Title, mode),
+		Description: diffText(fromRevision.Description, toRevision.Description, mode),
+		DueDateFrom: fromRevision.DueDate,
+		DueDateTo:   toRevision.DueDate,
+	}, nil
+}
+
+// RestoreNoteRevision brings title, description and due date of note back to given revision
+// Restoring does not rewrite history, it saves restored state as new revision
+// Restored text is spell checked in mode from user's settings, as if note was updated
+// Version is expected current version of note, zero means version note had when it was read
+func (n *NoteService) RestoreNoteRevision(userID, noteID, revision, version int) (models.Note, error) {
+	note, err := n.GetNote(userID, noteID)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	rev, err := n.getRevision(noteID, revision)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	// Tags and notebook are not versioned, so they stay as they are
+	note.Title = rev.Title
+	note.Description = rev.Description
+	note.DueDate = rev.DueDate
+	note.Tags = nil
+	if version != 0 {
+		note.Version = version
+	}
+
+	// Spell check results of current text do not apply to restored one
+	if note, err = n.spelling.checkNote(note, models.SpellOptions{}); err != nil {
+		return models.Note{}, err
+	}
+
+	restoredNote, err := n.repo.Update(note)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoteNotFound) {
+			return models.Note{}, ErrNoteNotFound
+		}
+		if errors.Is(err, postgresql.ErrVersionConflict) {
+			return models.Note{}, ErrVersionConflict
+		}
+		log.Printf("Ошибка при восстановлении ревизии %d заметки ID %d: %v", revision, noteID, err)
+		return models.Note{}, err
+	}
+
+	log.Printf("Заметка ID %d восстановлена до ревизии %d", noteID, revision)
+	return restoredNote, nil
+}
+
+// getRevision retrieves revision of note translating repository errors
+func (n *NoteService) getRevision(noteID, revision int) (models.NoteRevision, error) {
+	rev, err := n.repo.GetRevision(noteID, revision)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrRevisionNotFound) {
+			return models.NoteRevision{}, ErrRevisionNotFound
+		}
+		log.Printf("Ошибка при получении ревизии %d заметки ID %d: %v", revision, noteID, err)
+		return models.NoteRevision{}, err
+	}
+	return rev, nil
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+)
+
+// spellCheckerFunc is SpellChecker implemented by function
+type spellCheckerFunc func(text, lang string, options int) ([]models.SpellingIssue, error)
+
+func (f spellCheckerFunc) CheckText(text, lang string, options int) ([]models.SpellingIssue, error) {
+	return f(text, lang, options)
+}
+
+// revisionNoteRepo keeps single note with its revisions and remembers note passed to Update
+type revisionNoteRepo struct {
+	repository.NoteRepo
+	note      models.Note
+	revisions map[int]models.NoteRevision
+	updated   *models.Note
+}
+
+func (r *revisionNoteRepo) GetByID(id int) (models.Note, error) {
+	return r.note, nil
+}
+
+func (r *revisionNoteRepo) GetRevision(noteID, revision int) (models.NoteRevision, error) {
+	return r.revisions[revision], nil
+}
+
+func (r *revisionNoteRepo) Update(note models.Note) (models.Note, error) {
+	r.updated = &note
+	note.Version++
+	return note, nil
+}
+
+// settingsRepo returns fixed speller settings and empty dictionary
+type settingsRepo struct {
+	repository.SpellerRepo
+	settings models.SpellerSettings
+}
+
+func (r settingsRepo) GetSettings(userID int) (models.SpellerSettings, error) {
+	return r.settings, nil
+}
+
+func (r settingsRepo) GetWords(userID int) ([]models.DictionaryWord, error) {
+	return nil, nil
+}
+
+func TestRestoreNoteRevisionSpellCheck(t *testing.T) {
+	// Checker reports every word "ошибка", so that issues show which text was checked
+	checker := spellCheckerFunc(func(text, lang string, options int) ([]models.SpellingIssue, error) {
+		issues := make([]models.SpellingIssue, 0)
+		if i := strings.Index(text, "ошибка"); i >= 0 {
+			issues = append(issues, models.SpellingIssue{Word: "ошибка", Pos: len([]rune(text[:i])), Len: 6,
+				Code: models.SpellingUnknownWord, Suggestions: []string{"ошибка"}})
+		}
+		return issues, nil
+	})
+
+	tests := []struct {
+		name       string
+		mode       string
+		wantErr    error
+		wantStatus string
+		wantIssues int
+	}{
+		{"warn mode checks restored text", models.SpellModeWarn, nil, models.SpellStatusChecked, 1},
+		{"reject mode rejects mistakes", models.SpellModeReject, ErrSpell, "", 0},
+		{"async mode queues check", models.SpellModeAsync, nil, models.SpellStatusPending, 0},
+		{"off mode clears status", models.SpellModeOff, nil, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Current text was checked and is clean, issues are not loaded with note
+			repo := &revisionNoteRepo{
+				note: models.Note{ID: 1, UserID: 7, Title: "Заголовок", Description: "чистый текст",
+					Version: 3, SpellStatus: models.SpellStatusChecked},
+				revisions: map[int]models.NoteRevision{
+					1: {NoteID: 1, Revision: 1, Title: "Заголовок", Description: "старая ошибка"},
+				},
+			}
+			spelling := NewSpellingService(settingsRepo{settings: models.SpellerSettings{SpellMode: tt.mode}}, nil,
+				checker)
+			service := NewNoteService(repo, nil, spelling)
+
+			_, err := service.RestoreNoteRevision(7, 1, 1, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RestoreNoteRevision error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.updated != nil {
+					t.Errorf("note was updated despite error")
+				}
+				return
+			}
+
+			if repo.updated == nil {
+				t.Fatal("note was not updated")
+			}
+			if repo.updated.Description != "старая ошибка" {
+				t.Errorf("description = %q, want restored one", repo.updated.Description)
+			}
+			if repo.updated.SpellStatus != tt.wantStatus {
+				t.Errorf("spell status = %q, want %q", repo.updated.SpellStatus, tt.wantStatus)
+			}
+			if len(repo.updated.SpellingIssues) != tt.wantIssues {
+				t.Errorf("spelling issues = %+v, want %d", repo.updated.SpellingIssues, tt.wantIssues)
+			}
+			for _, issue := range repo.updated.SpellingIssues {
+				if issue.Field != "description" || issue.Pos != 7 {
+					t.Errorf("issue %+v is not about restored description", issue)
+				}
+			}
+		})
+	}
+}
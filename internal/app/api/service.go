@@ -1,38 +1,203 @@
 package api
 
 import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
 	"github.com/dgrijalva/jwt-go"
+	"rest-notes/internal/app/config"
+	"rest-notes/internal/app/mail"
 	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/oidc"
 	"rest-notes/internal/app/repository"
 )
 
 // Authorization defines interface for user authentication and authorization logic
 type Authorization interface {
 	CreateUser(user models.User) error
-	GenerateToken(user models.User) (string, error)
+	GenerateToken(user models.User, ip string) (models.Tokens, error)
+	RefreshToken(refreshToken string) (models.Tokens, error)
+	Logout(sessionID string) error
+	LogoutAll(userID int) error
 	IsTokenValid(tokenString string) (bool, jwt.MapClaims, error)
+	GetCurrentUser(userID int) (models.User, error)
+	GetJWKS() models.JWKS
+	ChangePassword(userID int, sessionID, currentPassword, newPassword, ip string) error
+	RequestPasswordReset(name string) error
+	ResetPassword(resetToken, newPassword string) error
+	EnrollMFA(userID int) (models.MFAEnrollment, error)
+	ConfirmMFA(userID int, code string) ([]string, error)
+	VerifyMFA(mfaToken, code, ip string) (models.Tokens, error)
+	DisableMFA(userID int, password, code, ip string) error
+	CreateAccessToken(token models.PersonalAccessToken) (models.PersonalAccessToken, error)
+	GetAccessTokens(userID int) ([]models.PersonalAccessToken, error)
+	RevokeAccessToken(userID, tokenID int) error
+}
+
+// OIDC defines interface for login with OpenID Connect providers and management of linked provider accounts
+type OIDC interface {
+	StartOIDCLogin(provider string, userID int) (models.OIDCAuthorization, error)
+	CompleteOIDCLogin(provider, state, browserState, code string) (models.OIDCLoginResult, error)
+	GetIdentities(userID int) ([]models.UserIdentity, error)
+	UnlinkIdentity(userID int, provider string) error
 }
 
 // Note defines interface for note-related operations
 type Note interface {
-	CreateNote(note models.Note) (models.Note, error)
-	GetNoteList(userID int) ([]models.Note, error)
+	CreateNote(note models.Note, spell models.SpellOptions) (models.Note, error)
+	GetNoteList(userID int, filter models.NoteFilter) (models.NoteList, error)
+	GetNote(userID, noteID int) (models.Note, error)
+	UpdateNote(note models.Note, spell models.SpellOptions) (models.Note, error)
+	MoveNote(userID, noteID int, notebookID *int, version int) (models.Note, error)
+	DeleteNote(userID, noteID, version int, permanent bool) error
+	RestoreNote(userID, noteID int) (models.Note, error)
+	GetTrash(userID int) ([]models.Note, error)
+	PurgeTrash(retention time.Duration) (int64, error)
+	SearchNotes(userID int, query string, limit int) ([]models.NoteSearchResult, error)
+	GetNoteRevisions(userID, noteID int) ([]models.NoteRevision, error)
+	GetNoteRevision(userID, noteID, revision int) (models.NoteRevision, error)
+	DiffNoteRevisions(userID, noteID, from, to int, mode string) (models.NoteDiff, error)
+	RestoreNoteRevision(userID, noteID, revision, version int) (models.Note, error)
+	GetNoteSpelling(userID, noteID int) (models.NoteSpelling, error)
+}
+
+// Tag defines interface for tag management operations
+type Tag interface {
+	GetTagList(userID int) ([]models.Tag, error)
+	RenameTag(userID, tagID int, name string) (models.Tag, error)
+	MergeTags(userID int, sourceIDs []int, targetID int) (models.Tag, error)
+	DeleteTag(userID, tagID int) error
+}
+
+// Notebook defines interface for notebook management operations
+type Notebook interface {
+	CreateNotebook(notebook models.Notebook) (models.Notebook, error)
+	GetNotebookTree(userID int) ([]models.Notebook, error)
+	GetNotebook(userID, notebookID int) (models.Notebook, error)
+	UpdateNotebook(notebook models.Notebook) (models.Notebook, error)
+	DeleteNotebook(userID, notebookID int, mode string) error
 }
 
-// Service aggregates Authorization and Note interfaces
-// It combines business logic for user authentication and note management
+// Speller defines interface for spell checking of arbitrary text, user's spell check preferences and custom dictionary
+type Speller interface {
+	GetSpellerSettings(userID int) (models.SpellerSettings, error)
+	UpdateSpellerSettings(userID int, settings models.SpellerSettings) (models.SpellerSettings, error)
+	AddDictionaryWord(userID int, word string) (models.DictionaryWord, error)
+	GetDictionary(userID int) ([]models.DictionaryWord, error)
+	DeleteDictionaryWord(userID int, word string) error
+	CheckSpelling(userID int, text, lang string) ([]models.SpellingIssue, error)
+	CorrectSpelling(userID int, text, lang string) (models.CorrectedText, error)
+	StartSpellWorkers(workers int, pollInterval, retryBackoff time.Duration, maxAttempts int)
+}
+
+// Admin defines interface for administration of user accounts
+type Admin interface {
+	GetUsers() ([]models.User, error)
+	DisableUser(adminID, userID int) (models.User, error)
+	EnableUser(adminID, userID int) (models.User, error)
+	DeleteUser(adminID, userID int) error
+	ResetUserPassword(adminID, userID int) (string, error)
+	BootstrapAdmin(name, password string) error
+}
+
+// Service aggregates Authorization, OIDC, Note, Tag, Notebook, Speller and Admin interfaces
+// It combines business logic for user authentication, management of notes, tags and notebooks, spell checking
+// and administration of users
 type Service struct {
 	Authorization
+	OIDC
 	Note
+	Tag
+	Notebook
+	Speller
+	Admin
 }
 
 // New returns new instance of Service, initializing dependencies
-// It takes repository that holds database access logic and initializes speller service
-func New(repo *repository.Repository) *Service {
-	spellerService := NewSpellerService()
+// It takes repository that holds database access logic and configuration, and initializes spell checker and auth services
+func New(repo *repository.Repository, cfg *config.Config) (*Service, error) {
+	spellChecker, err := newSpellChecker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	spellingService := NewSpellingService(repo.SpellerRepo, repo.SpellJobRepo, spellChecker)
+
+	policy, err := NewPasswordPolicy(cfg.PasswordMinLength, cfg.BreachedPasswords)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить список утекших паролей: %w", err)
+	}
+
+	keys, err := LoadKeySet(cfg.JWTSigningKey, cfg.JWTVerifyKeys)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить ключи JWT: %w", err)
+	}
+	log.Printf("JWT подписываются ключом %s", keys.KeyID())
+
+	guard := NewLoginGuard(cfg.LoginFreeAttempts, cfg.LoginDelay, cfg.LoginMaxDelay, cfg.UserLockoutLimit,
+		cfg.IPLockoutLimit, cfg.LockoutDuration)
+	authService := NewAuthService(repo.UserRepo, repo.SessionRepo, repo.AccessTokenRepo, repo.PasswordResetRepo,
+		repo.MFARepo, policy, guard, newMailer(cfg), keys, cfg.JWTIssuer, cfg.JWTAudience, cfg.MFAIssuer,
+		cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.PasswordResetTTL)
 
 	return &Service{
-		Authorization: NewAuthService(repo.UserRepo),
-		Note:          NewNoteService(repo.NoteRepo, spellerService),
+		Authorization: authService,
+		OIDC:          NewOIDCService(authService, repo.IdentityRepo, newOIDCProviders(cfg)),
+		Note:          NewNoteService(repo.NoteRepo, repo.NotebookRepo, spellingService),
+		Tag:           NewTagService(repo.TagRepo),
+		Notebook:      NewNotebookService(repo.NotebookRepo),
+		Speller:       spellingService,
+		Admin:         NewAdminService(repo.UserRepo, repo.SessionRepo, policy),
+	}, nil
+}
+
+// newMailer creates mailer selected in configuration
+func newMailer(cfg *config.Config) mail.Mailer {
+	switch cfg.Mailer {
+	case config.MailerFile:
+		log.Printf("Письма записываются в файл %s", cfg.MailerFile)
+		return mail.NewFileMailer(cfg.MailerFile)
+	default:
+		return mail.NewLogMailer()
+	}
+}
+
+// newOIDCProviders creates clients of OpenID Connect providers registered in configuration
+// Callback URL of each provider is /auth/oidc/{provider}/callback under public URL of application
+func newOIDCProviders(cfg *config.Config) []*oidc.Provider {
+	client := &http.Client{Timeout: oidcRequestTimeout}
+	providers := make([]*oidc.Provider, 0, len(cfg.OIDCProviders))
+	for _, provider := range cfg.OIDCProviders {
+		providers = append(providers, oidc.NewProvider(provider.Name, oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			Scopes:       provider.Scopes,
+			RedirectURL:  cfg.OIDCRedirectBase + "/auth/oidc/" + provider.Name + "/callback",
+		}, client))
+		log.Printf("Подключен провайдер OIDC %s: %s", provider.Name, provider.Issuer)
+	}
+	return providers
+}
+
+// newSpellChecker creates spell checker for backend selected in configuration
+func newSpellChecker(cfg *config.Config) (SpellChecker, error) {
+	switch cfg.SpellerBackend {
+	case config.SpellerBackendHunspell:
+		checker, err := NewHunspellChecker(cfg.HunspellDic, cfg.HunspellAff)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить словарь hunspell: %w", err)
+		}
+		log.Printf("Используется офлайн-словарь hunspell: %s", cfg.HunspellDic)
+		return checker, nil
+	default:
+		var checker SpellChecker = NewSpellerService(cfg.SpellerURL, cfg.SpellerTimeout, cfg.SpellerRetries,
+			cfg.SpellerBackoff, cfg.SpellerConcurrency)
+		checker = NewCircuitBreakerSpellChecker(checker, cfg.BreakerThreshold, cfg.BreakerCooldown, cfg.BreakerFailOpen)
+		if cfg.SpellerCacheSize > 0 {
+			checker = NewCachedSpellChecker(checker, cfg.SpellerCacheSize, cfg.SpellerCacheTTL)
+		}
+		return checker, nil
 	}
 }
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// RefreshToken exchanges refresh token for new pair of tokens in the same session
+// Every refresh token can be used only once; presenting already used token means it has leaked,
+// so the whole session is revoked
+func (as *AuthService) RefreshToken(refreshToken string) (models.Tokens, error) {
+	token, err := as.sessions.GetRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrRefreshTokenNotFound) {
+			log.Printf("Попытка обновления с неизвестным refresh-токеном")
+			return models.Tokens{}, ErrInvalidRefreshToken
+		}
+		log.Printf("Ошибка при получении refresh-токена: %v", err)
+		return models.Tokens{}, err
+	}
+
+	if token.UsedAt != nil {
+		return models.Tokens{}, as.revokeReusedSession(token)
+	}
+
+	now := time.Now()
+	if token.SessionRevokedAt != nil || now.After(token.SessionExpiresAt) || now.After(token.ExpiresAt) {
+		log.Printf("Refresh-токен сессии %s отозван или истек", token.SessionID)
+		return models.Tokens{}, ErrInvalidRefreshToken
+	}
+
+	user, err := as.repo.GetByID(token.UserID)
+	if err != nil {
+		log.Printf("Ошибка при получении пользователя ID %d для обновления токена: %v", token.UserID, err)
+		return models.Tokens{}, err
+	}
+	if user.DisabledAt != nil {
+		log.Printf("Попытка обновления токена отключенного пользователя ID %d", user.ID)
+		return models.Tokens{}, ErrInvalidRefreshToken
+	}
+
+	// Rotate refresh token, session lifetime is extended with every rotation
+	newRefreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	err = as.sessions.RotateRefreshToken(token, hashToken(newRefreshToken), now.Add(as.refreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrRefreshTokenUsed) {
+			return models.Tokens{}, as.revokeReusedSession(token)
+		}
+		log.Printf("Ошибка при ротации refresh-токена: %v", err)
+		return models.Tokens{}, err
+	}
+
+	accessToken, err := as.generateJWT(user, token.SessionID, as.accessTokenTTL)
+	if err != nil {
+		log.Printf("Ошибка при генерации JWT: %v", err)
+		return models.Tokens{}, err
+	}
+
+	log.Printf("Токены обновлены для пользователя: %s", user.Name)
+	return as.tokens(accessToken, newRefreshToken), nil
+}
+
+// Logout revokes session, all access and refresh tokens issued for it stop working
+func (as *AuthService) Logout(sessionID string) error {
+	if err := as.sessions.Revoke(sessionID); err != nil {
+		log.Printf("Ошибка при отзыве сессии %s: %v", sessionID, err)
+		return err
+	}
+
+	log.Printf("Сессия %s завершена", sessionID)
+	return nil
+}
+
+// LogoutAll revokes all sessions of user
+func (as *AuthService) LogoutAll(userID int) error {
+	if err := as.sessions.RevokeAllForUser(userID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя ID %d: %v", userID, err)
+		return err
+	}
+
+	log.Printf("Все сессии пользователя ID %d завершены", userID)
+	return nil
+}
+
+// startSession creates new session for user and issues its first pair of tokens
+func (as *AuthService) startSession(user models.User) (models.Tokens, error) {
+	sessionID, err := generateRandomToken(16)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	session := models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(as.refreshTokenTTL),
+	}
+	if err = as.sessions.Create(session, hashToken(refreshToken)); err != nil {
+		log.Printf("Ошибка при создании сессии: %v", err)
+		return models.Tokens{}, err
+	}
+
+	// Generate JWT token
+	accessToken, err := as.generateJWT(user, sessionID, as.accessTokenTTL)
+	if err != nil {
+		log.Printf("Ошибка при генерации JWT: %v", err)
+		return models.Tokens{}, err
+	}
+
+	return as.tokens(accessToken, refreshToken), nil
+}
+
+// revokeReusedSession revokes session whose refresh token has been presented second time
+func (as *AuthService) revokeReusedSession(token models.RefreshToken) error {
+	log.Printf("Повторное использование refresh-токена, сессия %s пользователя ID %d отозвана",
+		token.SessionID, token.UserID)
+	if err := as.sessions.Revoke(token.SessionID); err != nil {
+		log.Printf("Ошибка при отзыве сессии %s: %v", token.SessionID, err)
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+// tokens builds response with issued tokens
+func (as *AuthService) tokens(accessToken, refreshToken string) models.Tokens {
+	return models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(as.accessTokenTTL.Seconds()),
+	}
+}
+
+// generateRandomToken returns URL-safe random string built from n random bytes
+func generateRandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns hex-encoded SHA-256 of token, only hashes of opaque tokens are stored in database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// memSessionRepo keeps sessions and their refresh tokens in memory
+type memSessionRepo struct {
+	repository.SessionRepo
+	sessions map[string]*models.Session
+	tokens   map[string]*models.RefreshToken
+}
+
+func newMemSessionRepo() *memSessionRepo {
+	return &memSessionRepo{sessions: make(map[string]*models.Session), tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (r *memSessionRepo) Create(session models.Session, refreshTokenHash string) error {
+	r.sessions[session.ID] = &session
+	r.tokens[refreshTokenHash] = &models.RefreshToken{ID: len(r.tokens) + 1, SessionID: session.ID,
+		UserID: session.UserID, ExpiresAt: session.ExpiresAt}
+	return nil
+}
+
+func (r *memSessionRepo) GetRefreshToken(tokenHash string) (models.RefreshToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return models.RefreshToken{}, postgresql.ErrRefreshTokenNotFound
+	}
+	result := *token
+	session := r.sessions[token.SessionID]
+	result.SessionExpiresAt, result.SessionRevokedAt = session.ExpiresAt, session.RevokedAt
+	return result, nil
+}
+
+func (r *memSessionRepo) RotateRefreshToken(token models.RefreshToken, newTokenHash string, expiresAt time.Time) error {
+	for _, stored := range r.tokens {
+		if stored.ID == token.ID {
+			if stored.UsedAt != nil {
+				return postgresql.ErrRefreshTokenUsed
+			}
+			now := time.Now()
+			stored.UsedAt = &now
+		}
+	}
+	r.tokens[newTokenHash] = &models.RefreshToken{ID: len(r.tokens) + 1, SessionID: token.SessionID,
+		UserID: token.UserID, ExpiresAt: expiresAt}
+	r.sessions[token.SessionID].ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *memSessionRepo) IsActive(id string) (bool, error) {
+	session, ok := r.sessions[id]
+	return ok && session.RevokedAt == nil && time.Now().Before(session.ExpiresAt), nil
+}
+
+func (r *memSessionRepo) Revoke(id string) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *memSessionRepo) RevokeAllForUser(userID int) error {
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			r.Revoke(id)
+		}
+	}
+	return nil
+}
+
+// newSessionTestService creates AuthService for users "alice" and "bob" with password "password123"
+func newSessionTestService(t *testing.T) (*AuthService, *memSessionRepo) {
+	t.Helper()
+
+	repo := newMemUserRepo(t,
+		models.User{Name: "alice", Password: "password123"},
+		models.User{Name: "bob", Password: "password123"})
+	sessions := newMemSessionRepo()
+	return &AuthService{repo: repo, sessions: sessions, guard: NewLoginGuard(10, time.Hour, time.Hour, 0, 0, time.Hour),
+		keys: newTestKeySet(t), jwtIssuer: "rest-notes", jwtAudience: "rest-notes", accessTokenTTL: time.Minute,
+		refreshTokenTTL: time.Hour}, sessions
+}
+
+// login logs user in and returns issued tokens
+func login(t *testing.T, as *AuthService, name string) models.Tokens {
+	t.Helper()
+
+	tokens, err := as.GenerateToken(models.User{Name: name, Password: "password123"}, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+// sessionOf returns ID of session access token is issued for, access token must be valid
+func sessionOf(t *testing.T, as *AuthService, accessToken string) string {
+	t.Helper()
+
+	valid, claims, err := as.IsTokenValid(accessToken)
+	if err != nil || !valid {
+		t.Fatalf("access token is not valid: %v", err)
+	}
+	return claims["sid"].(string)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	as, _ := newSessionTestService(t)
+	first := login(t, as, "alice")
+	sessionID := sessionOf(t, as, first.AccessToken)
+
+	// Refresh returns new pair of tokens in the same session
+	second, err := as.RefreshToken(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatalf("refresh returned the same tokens")
+	}
+	if sid := sessionOf(t, as, second.AccessToken); sid != sessionID {
+		t.Errorf("refreshed access token is issued for session %s, want %s", sid, sessionID)
+	}
+
+	third, err := as.RefreshToken(second.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotated token presented again means it has leaked, so the whole session is revoked
+	if _, err = as.RefreshToken(first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh with rotated token error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err = as.RefreshToken(third.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh with latest token of revoked session error = %v, want ErrInvalidRefreshToken", err)
+	}
+	for _, accessToken := range []string{first.AccessToken, third.AccessToken} {
+		if valid, _, err := as.IsTokenValid(accessToken); valid || !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("access token of revoked session is valid: %v, %v", valid, err)
+		}
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(as *AuthService, sessions *memSessionRepo, refreshToken string)
+	}{
+		{"unknown token", func(as *AuthService, sessions *memSessionRepo, refreshToken string) {
+			delete(sessions.tokens, hashToken(refreshToken))
+		}},
+		{"expired token", func(as *AuthService, sessions *memSessionRepo, refreshToken string) {
+			sessions.tokens[hashToken(refreshToken)].ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{"expired session", func(as *AuthService, sessions *memSessionRepo, refreshToken string) {
+			for _, session := range sessions.sessions {
+				session.ExpiresAt = time.Now().Add(-time.Second)
+			}
+		}},
+		{"disabled user", func(as *AuthService, sessions *memSessionRepo, refreshToken string) {
+			repo := as.repo.(*memUserRepo)
+			user, now := repo.users["alice"], time.Now()
+			user.DisabledAt = &now
+			repo.users["alice"] = user
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as, sessions := newSessionTestService(t)
+			tokens := login(t, as, "alice")
+			tt.change(as, sessions, tokens.RefreshToken)
+
+			if _, err := as.RefreshToken(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("RefreshToken error = %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	as, _ := newSessionTestService(t)
+	phone, laptop := login(t, as, "alice"), login(t, as, "alice")
+
+	// Logout ends only its own session
+	if err := as.Logout(sessionOf(t, as, phone.AccessToken)); err != nil {
+		t.Fatal(err)
+	}
+	if valid, _, _ := as.IsTokenValid(phone.AccessToken); valid {
+		t.Error("access token of ended session is valid")
+	}
+	if _, err := as.RefreshToken(phone.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh in ended session error = %v, want ErrInvalidRefreshToken", err)
+	}
+	sessionOf(t, as, laptop.AccessToken)
+}
+
+func TestLogoutAll(t *testing.T) {
+	as, _ := newSessionTestService(t)
+	phone, laptop, other := login(t, as, "alice"), login(t, as, "alice"), login(t, as, "bob")
+
+	if err := as.LogoutAll(1); err != nil {
+		t.Fatal(err)
+	}
+	for _, tokens := range []models.Tokens{phone, laptop} {
+		if valid, _, _ := as.IsTokenValid(tokens.AccessToken); valid {
+			t.Error("access token of ended session is valid")
+		}
+		if _, err := as.RefreshToken(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("refresh in ended session error = %v, want ErrInvalidRefreshToken", err)
+		}
+	}
+
+	// Sessions of other users stay active
+	sessionOf(t, as, other.AccessToken)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// spellJobLease is how long claimed job stays locked by worker, job of crashed worker is picked up again after it
+const spellJobLease = 5 * time.Minute
+
+// errNotChecked is reported when spell checker lets text through unchecked, so job has to be retried
+var errNotChecked = errors.New("spell checker is unavailable, text was not checked")
+
+// StartSpellWorkers starts pool of workers checking notes saved in async spell mode
+// Idle workers poll queue every pollInterval; failed job is retried after exponentially growing delay
+// starting with retryBackoff and ends in dead letter state after maxAttempts attempts
+func (ss *SpellingService) StartSpellWorkers(workers int, pollInterval, retryBackoff time.Duration, maxAttempts int) {
+	for i := 0; i < workers; i++ {
+		go ss.runSpellWorker(pollInterval, retryBackoff, maxAttempts)
+	}
+	log.Printf("Запущено %d обработчиков фоновой проверки орфографии", workers)
+}
+
+// runSpellWorker processes spell jobs one by one, waiting for pollInterval when there are none
+func (ss *SpellingService) runSpellWorker(pollInterval, retryBackoff time.Duration, maxAttempts int) {
+	for {
+		processed, err := ss.processSpellJob(retryBackoff, maxAttempts)
+		if err != nil {
+			log.Printf("Ошибка при обработке задачи проверки орфографии: %v", err)
+		}
+		if !processed || err != nil {
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
+// processSpellJob claims next job from queue, checks note and stores results on it
+// Returns false if there was no job ready to run
+func (ss *SpellingService) processSpellJob(retryBackoff time.Duration, maxAttempts int) (bool, error) {
+	job, err := ss.jobs.Claim(spellJobLease)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrNoSpellJobs) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	issues, err := ss.checkSpellJob(job)
+	if err == nil {
+		if err = ss.jobs.Complete(job, issues); err != nil {
+			return true, err
+		}
+		log.Printf("Фоновая проверка орфографии заметки ID %d завершена, найдено ошибок: %d", job.NoteID, len(issues))
+		return true, nil
+	}
+
+	if job.Attempts >= maxAttempts {
+		log.Printf("Фоновая проверка орфографии заметки ID %d не удалась после %d попыток: %v",
+			job.NoteID, job.Attempts, err)
+		return true, ss.jobs.Fail(job, err.Error())
+	}
+
+	delay := retryDelay(retryBackoff, job.Attempts)
+	log.Printf("Фоновая проверка орфографии заметки ID %d не удалась, повтор через %v: %v", job.NoteID, delay, err)
+	return true, ss.jobs.Retry(job, time.Now().Add(delay), err.Error())
+}
+
+// checkSpellJob checks text of note from job with settings of note owner
+func (ss *SpellingService) checkSpellJob(job models.SpellJob) ([]models.SpellingIssue, error) {
+	settings, err := ss.GetSpellerSettings(job.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := models.Note{UserID: job.UserID, Title: job.Title, Description: job.Description, Lang: job.Lang}
+	issues, err := ss.checkNoteText(note, settings)
+	if err != nil {
+		return nil, err
+	}
+	if issues == nil {
+		return nil, errNotChecked
+	}
+
+	return issues, nil
+}
@@ -4,39 +4,137 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"rest-notes/internal/app/metrics"
+	"rest-notes/internal/app/models"
 )
 
-var spellerAPIURL = "https://speller.yandex.net/services/spellservice.json/checkText"
+var (
+	spellerRequests = metrics.NewCounter("speller_requests_total", "Requests sent to Speller API")
+	spellerRetries  = metrics.NewCounter("speller_retries_total", "Retried requests to Speller API")
+	spellerErrors   = metrics.NewCounter("speller_errors_total", "Failed requests to Speller API")
+)
+
+// SpellChecker checks text for spelling mistakes
+// lang is comma-separated list of language codes, options is Yandex Speller options bitmask
+// Nil issues without error mean that text was not checked, e.g. because speller is unavailable
+type SpellChecker interface {
+	CheckText(text, lang string, options int) ([]models.SpellingIssue, error)
+}
 
 // SpellerService represents service responsible for interacting with Speller API
+// Requests failed with network error or 5xx status are retried with exponential backoff
 type SpellerService struct {
-	client *http.Client
+	apiURL       string
+	client       *http.Client
+	retries      int
+	retryBackoff time.Duration
+	concurrency  int
 }
 
-// NewSpellerService creates new instance of SpellerService with predefined HTTP client timeout
-func NewSpellerService() *SpellerService {
+// NewSpellerService creates new instance of SpellerService for Speller API at apiURL with given HTTP client timeout
+// of single attempt, number of retries, delay before first retry and limit of concurrent requests for chunks of long text
+func NewSpellerService(apiURL string, timeout time.Duration, retries int, retryBackoff time.Duration,
+	concurrency int) *SpellerService {
 	return &SpellerService{
-		client: &http.Client{Timeout: 10 * time.Second},
+		apiURL:       strings.TrimSuffix(apiURL, "/"),
+		client:       &http.Client{Timeout: timeout},
+		retries:      retries,
+		retryBackoff: retryBackoff,
+		concurrency:  concurrency,
 	}
 }
 
+// spellerStatusError is returned when Speller API responds with unexpected status
+type spellerStatusError struct {
+	status int
+}
+
+func (e *spellerStatusError) Error() string {
+	return fmt.Sprintf("failed to get valid response from speller service: status %d", e.status)
+}
+
+// spellerError is single mistake in response of Speller API
+type spellerError struct {
+	Code int      `json:"code"`
+	Pos  int      `json:"pos"`
+	Row  int      `json:"row"`
+	Col  int      `json:"col"`
+	Len  int      `json:"len"`
+	Word string   `json:"word"`
+	S    []string `json:"s"`
+}
+
 // CheckText sends text to Speller API to check for spelling errors
 // It takes text to check, the language code, and options as parameters
-// Returns spelling errors as list of maps or error if the request fails
-func (s *SpellerService) CheckText(text, lang string, options int) ([]map[string]interface{}, error) {
+// Text longer than Speller API accepts is split into chunks checked concurrently
+// Returns spelling errors or error if all attempts fail
+func (s *SpellerService) CheckText(text, lang string, options int) ([]models.SpellingIssue, error) {
+	if utf8.RuneCountInString(text) <= maxSpellerTextLength {
+		results, err := s.request([]string{text}, lang, options)
+		if err != nil {
+			return nil, err
+		}
+		return toSpellingIssues(results[0], textChunk{}), nil
+	}
+
+	return s.checkChunked(text, lang, options)
+}
+
+// request sends texts to Speller API, retrying failed attempts
+// Single text is checked with checkText method, several texts with checkTexts in one request
+func (s *SpellerService) request(texts []string, lang string, options int) ([][]spellerError, error) {
+	var err error
+	for attempt := 0; attempt <= s.retries; attempt++ {
+		if attempt > 0 {
+			spellerRetries.Inc()
+			time.Sleep(retryDelay(s.retryBackoff, attempt))
+		}
+
+		var results [][]spellerError
+		spellerRequests.Inc()
+		results, err = s.send(texts, lang, options)
+		if err == nil {
+			return results, nil
+		}
+
+		spellerErrors.Inc()
+		log.Printf("Ошибка запроса к Speller API, попытка %d из %d: %v", attempt+1, s.retries+1, err)
+		if !isRetryable(err) {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// send makes single request to Speller API and returns mistakes found in each of texts
+func (s *SpellerService) send(texts []string, lang string, options int) ([][]spellerError, error) {
 	params := url.Values{}
-	params.Add("text", text)
+	for _, text := range texts {
+		params.Add("text", text)
+	}
 	params.Add("lang", lang)
-	params.Add("options", string(options))
+	params.Add("options", strconv.Itoa(options))
 	params.Add("format", "plain")
 
+	method := "/checkText"
+	if len(texts) > 1 {
+		method = "/checkTexts"
+	}
+
 	// Create new POST request with the encoded parameters
-	req, err := http.NewRequest("POST", spellerAPIURL, bytes.NewBufferString(params.Encode()))
+	req, err := http.NewRequest("POST", s.apiURL+method, bytes.NewBufferString(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -54,15 +152,62 @@ func (s *SpellerService) CheckText(text, lang string, options int) ([]map[string
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("failed to get valid response from speller service")
+		return nil, &spellerStatusError{status: resp.StatusCode}
 	}
 
-	// Unmarshal the JSON response into a slice of maps
-	var result []map[string]interface{}
-	if err = json.Unmarshal(body, &result); err != nil {
+	// Unmarshal the JSON response into list of mistakes, checkTexts returns list per text
+	results := make([][]spellerError, 1)
+	if len(texts) == 1 {
+		err = json.Unmarshal(body, &results[0])
+	} else {
+		err = json.Unmarshal(body, &results)
+	}
+	if err != nil || len(results) != len(texts) {
 		log.Printf("Ошибка при парсинге JSON: %v", err)
 		return nil, errors.New("failed to parse JSON response from speller service")
 	}
 
-	return result, nil
+	return results, nil
+}
+
+// toSpellingIssues converts mistakes found in chunk to issues with positions in whole text
+func toSpellingIssues(result []spellerError, chunk textChunk) []models.SpellingIssue {
+	issues := make([]models.SpellingIssue, 0, len(result))
+	for _, e := range result {
+		suggestions := e.S
+		if suggestions == nil {
+			suggestions = []string{}
+		}
+		issues = append(issues, chunk.remap(models.SpellingIssue{
+			Word:        e.Word,
+			Pos:         e.Pos,
+			Len:         e.Len,
+			Row:         e.Row,
+			Col:         e.Col,
+			Suggestions: suggestions,
+			Code:        e.Code,
+		}))
+	}
+
+	return issues
+}
+
+// isRetryable reports whether failed request to Speller API is worth retrying:
+// network errors including timeouts and 5xx responses are, other responses are not
+func isRetryable(err error) bool {
+	var statusErr *spellerStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryDelay returns exponential delay before given retry attempt with random jitter of up to half of it
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff << (attempt - 1)
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
 }
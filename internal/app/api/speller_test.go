@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/spellertest"
+)
+
+// testWords are words known to fake speller in tests
+var testWords = []string{"привет", "как", "дела"}
+
+// flakySpeller serves Speller API with fake speller, failing next requests with given status
+// While hold is set, requests wait until release is closed
+type flakySpeller struct {
+	speller  http.Handler
+	requests atomic.Int32
+	failures atomic.Int32
+	status   atomic.Int32
+	hold     atomic.Bool
+	release  chan struct{}
+}
+
+// newFlakySpeller starts server of flaky fake speller that is closed when test finishes
+func newFlakySpeller(t *testing.T) (*flakySpeller, string) {
+	f := &flakySpeller{speller: spellertest.New(testWords), release: make(chan struct{})}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server.URL
+}
+
+// fail makes next n requests fail with status
+func (f *flakySpeller) fail(n, status int) {
+	f.status.Store(int32(status))
+	f.failures.Store(int32(n))
+}
+
+func (f *flakySpeller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	if f.hold.Load() {
+		<-f.release
+	}
+	if f.failures.Add(-1) >= 0 {
+		http.Error(w, "speller failure", int(f.status.Load()))
+		return
+	}
+	f.speller.ServeHTTP(w, r)
+}
+
+func TestSpellerServiceRetries(t *testing.T) {
+	const backoff = 10 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		wantRequests int32
+		wantStatus   int
+		minElapsed   time.Duration
+	}{
+		{"success", 0, 0, 1, 0, 0},
+		{"5xx then success", 2, http.StatusServiceUnavailable, 3, 0, backoff + 2*backoff},
+		{"5xx on every attempt", 5, http.StatusInternalServerError, 3, http.StatusInternalServerError, 3 * backoff},
+		{"4xx is not retried", 5, http.StatusBadRequest, 1, http.StatusBadRequest, 0},
+		{"429 is not retried", 5, http.StatusTooManyRequests, 1, http.StatusTooManyRequests, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, url := newFlakySpeller(t)
+			fake.fail(tt.failures, tt.status)
+			speller := NewSpellerService(url, 5*time.Second, 2, backoff, 1)
+
+			start := time.Now()
+			issues, err := speller.CheckText("превет как дела", "ru", 0)
+			elapsed := time.Since(start)
+
+			if requests := fake.requests.Load(); requests != tt.wantRequests {
+				t.Errorf("speller got %d requests, want %d", requests, tt.wantRequests)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("check took %v, want at least %v of backoff", elapsed, tt.minElapsed)
+			}
+
+			if tt.wantStatus != 0 {
+				var statusErr *spellerStatusError
+				if !errors.As(err, &statusErr) || statusErr.status != tt.wantStatus {
+					t.Fatalf("CheckText error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != 1 || issues[0].Word != "превет" || issues[0].Code != models.SpellingUnknownWord {
+				t.Errorf("CheckText returned %+v, want single issue about превет", issues)
+			}
+		})
+	}
+}
+
+func TestSpellerServiceRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(spellertest.New(testWords))
+	url := server.URL
+	server.Close()
+
+	speller := NewSpellerService(url, time.Second, 1, time.Millisecond, 1)
+	_, err := speller.CheckText("привет", "ru", 0)
+	if err == nil || !isRetryable(err) {
+		t.Errorf("CheckText error = %v, want retryable network error", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	const backoff = 100 * time.Millisecond
+
+	for attempt := 1; attempt <= 4; attempt++ {
+		base := backoff << (attempt - 1)
+		for i := 0; i < 20; i++ {
+			if delay := retryDelay(backoff, attempt); delay < base || delay > base+base/2 {
+				t.Fatalf("retryDelay(%v, %d) = %v, want between %v and %v", backoff, attempt, delay, base, base+base/2)
+			}
+		}
+	}
+
+	if delay := retryDelay(0, 3); delay != 0 {
+		t.Errorf("retryDelay without backoff = %v, want 0", delay)
+	}
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrInvalidSpellMode  = errors.New("неправильный режим проверки орфографии, допустимы reject, warn, off и async")
+	ErrInvalidWord       = errors.New("слово словаря должно быть непустым, без пробелов и не длиннее 100 символов")
+	ErrWordAlreadyExists = errors.New("слово уже есть в словаре")
+	ErrWordNotFound      = errors.New("слово не найдено в словаре")
+	ErrInvalidLang       = errors.New("неправильный язык заметки, допустимы ru, en, uk и их сочетания через запятую")
+)
+
+// defaultSpellMode is used for users who have not chosen spell mode
+const defaultSpellMode = models.SpellModeReject
+
+// maxWordLength is maximum length of dictionary word in characters
+const maxWordLength = 100
+
+// SpellingService applies user's spell check preferences on top of spell checker
+// and checks notes saved in async mode using background job queue
+type SpellingService struct {
+	repo    repository.SpellerRepo
+	jobs    repository.SpellJobRepo
+	checker SpellChecker
+}
+
+// NewSpellingService creates new instance of SpellingService
+func NewSpellingService(repo repository.SpellerRepo, jobs repository.SpellJobRepo, checker SpellChecker) *SpellingService {
+	return &SpellingService{repo: repo, jobs: jobs, checker: checker}
+}
+
+// GetSpellerSettings retrieves user's speller settings, users without saved settings get defaults
+func (ss *SpellingService) GetSpellerSettings(userID int) (models.SpellerSettings, error) {
+	settings, err := ss.repo.GetSettings(userID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrSpellerSettingsNotFound) {
+			return models.SpellerSettings{SpellMode: defaultSpellMode}, nil
+		}
+		log.Printf("Ошибка при получении настроек проверки орфографии: %v", err)
+		return models.SpellerSettings{}, err
+	}
+
+	return settings, nil
+}
+
+// UpdateSpellerSettings validates and saves user's speller settings
+func (ss *SpellingService) UpdateSpellerSettings(userID int, settings models.SpellerSettings) (models.SpellerSettings, error) {
+	if !isSpellMode(settings.SpellMode) {
+		return models.SpellerSettings{}, ErrInvalidSpellMode
+	}
+
+	if err := ss.repo.SaveSettings(userID, settings); err != nil {
+		log.Printf("Ошибка при сохранении настроек проверки орфографии: %v", err)
+		return models.SpellerSettings{}, err
+	}
+
+	log.Printf("Настройки проверки орфографии обновлены для пользователя ID: %d", userID)
+	return settings, nil
+}
+
+// AddDictionaryWord adds word to user's dictionary, so it is no longer reported as mistake
+func (ss *SpellingService) AddDictionaryWord(userID int, word string) (models.DictionaryWord, error) {
+	word = strings.TrimSpace(word)
+	if word == "" || utf8.RuneCountInString(word) > maxWordLength || strings.IndexFunc(word, unicode.IsSpace) >= 0 {
+		return models.DictionaryWord{}, ErrInvalidWord
+	}
+
+	added, err := ss.repo.AddWord(models.DictionaryWord{UserID: userID, Word: word}, normalizeWord(word))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrWordAlreadyExists) {
+			return models.DictionaryWord{}, ErrWordAlreadyExists
+		}
+		log.Printf("Ошибка при добавлении слова в словарь: %v", err)
+		return models.DictionaryWord{}, err
+	}
+
+	log.Printf("Слово %q добавлено в словарь пользователя ID: %d", word, userID)
+	return added, nil
+}
+
+// GetDictionary retrieves words of user's dictionary
+func (ss *SpellingService) GetDictionary(userID int) ([]models.DictionaryWord, error) {
+	words, err := ss.repo.GetWords(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении словаря: %v", err)
+		return nil, err
+	}
+
+	return words, nil
+}
+
+// DeleteDictionaryWord removes word from user's dictionary, matching is case-insensitive
+func (ss *SpellingService) DeleteDictionaryWord(userID int, word string) error {
+	err := ss.repo.DeleteWord(userID, normalizeWord(strings.TrimSpace(word)))
+	if err != nil {
+		if errors.Is(err, postgresql.ErrWordNotFound) {
+			return ErrWordNotFound
+		}
+		log.Printf("Ошибка при удалении слова из словаря: %v", err)
+		return err
+	}
+
+	log.Printf("Слово %q удалено из словаря пользователя ID: %d", word, userID)
+	return nil
+}
+
+// checkNote checks spelling of note title and description in mode from request or, if it is not set,
+// from user's settings; note language is detected from text if note has none
+// Returns note with spell status and found mistakes set
+// In reject mode ErrSpell wrapped with details is returned if mistakes are found,
+// in warn mode mistakes are returned and failure of spell checker does not prevent saving note,
+// in async mode note is only marked to be checked in background
+func (ss *SpellingService) checkNote(note models.Note, spell models.SpellOptions) (models.Note, error) {
+	settings, err := ss.GetSpellerSettings(note.UserID)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	mode := spell.Mode
+	if mode == "" {
+		mode = settings.SpellMode
+	}
+	if !isSpellMode(mode) {
+		return models.Note{}, ErrInvalidSpellMode
+	}
+
+	note.SpellStatus, note.SpellingIssues = "", nil
+	switch mode {
+	case models.SpellModeOff:
+		return note, nil
+	case models.SpellModeAsync:
+		note.SpellStatus = models.SpellStatusPending
+		return note, nil
+	}
+
+	issues, err := ss.checkNoteText(note, settings)
+	if err != nil {
+		if mode == models.SpellModeWarn {
+			return note, nil
+		}
+		return models.Note{}, err
+	}
+	if issues == nil {
+		// Spell checker is unavailable and lets text through unchecked
+		return note, nil
+	}
+
+	if len(issues) > 0 {
+		log.Printf("Обнаружены орфографические ошибки в тексте: %v", issues)
+
+		// Return formatted error with details
+		if mode == models.SpellModeReject {
+			return models.Note{}, fmt.Errorf("%w: %s", ErrSpell, formatSpellingErrors(issues))
+		}
+	}
+
+	note.SpellStatus, note.SpellingIssues = models.SpellStatusChecked, issues
+	return note, nil
+}
+
+// checkNoteText checks title and description of note with user's settings, marking issues with field they are in
+// Returns nil issues if spell checker did not check text
+func (ss *SpellingService) checkNoteText(note models.Note, settings models.SpellerSettings) ([]models.SpellingIssue, error) {
+	lang := note.Lang
+	if lang == "" {
+		lang = detectLang(note.Title + "\n" + note.Description)
+	}
+
+	fields := []struct {
+		name string
+		text string
+	}{
+		{name: "title", text: note.Title},
+		{name: "description", text: note.Description},
+	}
+
+	issues := make([]models.SpellingIssue, 0)
+	for _, field := range fields {
+		if strings.TrimSpace(field.text) == "" {
+			continue
+		}
+
+		fieldIssues, err := ss.checkText(note.UserID, settings, field.text, lang)
+		if err != nil {
+			return nil, err
+		}
+		if fieldIssues == nil {
+			return nil, nil
+		}
+
+		for _, issue := range fieldIssues {
+			issue.Field = field.name
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// checkText checks text with user's speller options and removes issues about words from user's dictionary
+// Language is detected from text if lang is empty
+func (ss *SpellingService) checkText(userID int, settings models.SpellerSettings, text, lang string) ([]models.SpellingIssue, error) {
+	if lang == "" {
+		lang = detectLang(text)
+	}
+
+	issues, err := ss.checker.CheckText(text, lang, spellerOptions(settings))
+	if err != nil {
+		log.Printf("Ошибка при проверке орфографии: %v", err)
+		return nil, err
+	}
+
+	// Words from user's dictionary are not mistakes
+	return ss.filterDictionaryWords(userID, issues)
+}
+
+// spellerOptions builds Speller options bitmask from named settings
+func spellerOptions(settings models.SpellerSettings) int {
+	options := 0
+	if settings.IgnoreDigits {
+		options |= models.SpellerIgnoreDigits
+	}
+	if settings.IgnoreURLs {
+		options |= models.SpellerIgnoreURLs
+	}
+	if settings.FindRepeatWords {
+		options |= models.SpellerFindRepeatWords
+	}
+	if settings.IgnoreCapitalization {
+		options |= models.SpellerIgnoreCapitalization
+	}
+	return options
+}
+
+// normalizeLang validates comma-separated list of note languages, removing spaces and duplicates
+// Empty list is allowed and means that language is detected automatically
+func normalizeLang(lang string) (string, error) {
+	var langs []string
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(lang, ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		if code != models.LangRussian && code != models.LangEnglish && code != models.LangUkrainian {
+			return "", ErrInvalidLang
+		}
+		seen[code] = true
+		langs = append(langs, code)
+	}
+	return strings.Join(langs, ","), nil
+}
+
+// detectLang guesses languages of text by scripts of its words
+// Cyrillic words mean Russian, or Ukrainian if text has letters specific to it; Latin words mean English
+func detectLang(text string) string {
+	var cyrillicWords, latinWords, russianLetters, ukrainianLetters int
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		var cyrillic, latin int
+		for _, r := range strings.ToLower(word) {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				cyrillic++
+				if strings.ContainsRune("ыэъё", r) {
+					russianLetters++
+				}
+				if strings.ContainsRune("іїєґ", r) {
+					ukrainianLetters++
+				}
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+		}
+		if cyrillic > latin {
+			cyrillicWords++
+		} else if latin > 0 {
+			latinWords++
+		}
+	}
+
+	cyrillicLang := models.LangRussian
+	if ukrainianLetters > russianLetters {
+		cyrillicLang = models.LangUkrainian
+	}
+
+	switch {
+	case latinWords == 0:
+		return cyrillicLang
+	case cyrillicWords == 0:
+		return models.LangEnglish
+	default:
+		return cyrillicLang + "," + models.LangEnglish
+	}
+}
+
+// filterDictionaryWords removes issues about words that are in user's dictionary
+func (ss *SpellingService) filterDictionaryWords(userID int, issues []models.SpellingIssue) ([]models.SpellingIssue, error) {
+	if len(issues) == 0 {
+		return issues, nil
+	}
+
+	words, err := ss.repo.GetWords(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении словаря: %v", err)
+		return nil, err
+	}
+	if len(words) == 0 {
+		return issues, nil
+	}
+
+	known := make(map[string]bool, len(words))
+	for _, word := range words {
+		known[normalizeWord(word.Word)] = true
+	}
+
+	filtered := make([]models.SpellingIssue, 0, len(issues))
+	for _, issue := range issues {
+		if !known[normalizeWord(issue.Word)] {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered, nil
+}
+
+// normalizeWord brings word to form used for dictionary matching: lower case with ё replaced by е
+func normalizeWord(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+// isSpellMode reports whether mode is one of known spell modes
+func isSpellMode(mode string) bool {
+	return mode == models.SpellModeReject || mode == models.SpellModeWarn || mode == models.SpellModeOff ||
+		mode == models.SpellModeAsync
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+	"rest-notes/internal/app/spellertest"
+)
+
+// memSpellerRepo keeps speller settings and dictionary of single user in memory
+// Nil settings mean user has not saved any
+type memSpellerRepo struct {
+	repository.SpellerRepo
+	settings *models.SpellerSettings
+	words    map[string]models.DictionaryWord
+}
+
+func (r *memSpellerRepo) GetSettings(userID int) (models.SpellerSettings, error) {
+	if r.settings == nil {
+		return models.SpellerSettings{}, postgresql.ErrSpellerSettingsNotFound
+	}
+	return *r.settings, nil
+}
+
+func (r *memSpellerRepo) SaveSettings(userID int, settings models.SpellerSettings) error {
+	r.settings = &settings
+	return nil
+}
+
+func (r *memSpellerRepo) AddWord(word models.DictionaryWord, normalizedWord string) (models.DictionaryWord, error) {
+	if _, ok := r.words[normalizedWord]; ok {
+		return models.DictionaryWord{}, postgresql.ErrWordAlreadyExists
+	}
+	word.ID = len(r.words) + 1
+	r.words[normalizedWord] = word
+	return word, nil
+}
+
+func (r *memSpellerRepo) GetWords(userID int) ([]models.DictionaryWord, error) {
+	words := make([]models.DictionaryWord, 0, len(r.words))
+	for _, word := range r.words {
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+func (r *memSpellerRepo) DeleteWord(userID int, normalizedWord string) error {
+	if _, ok := r.words[normalizedWord]; !ok {
+		return postgresql.ErrWordNotFound
+	}
+	delete(r.words, normalizedWord)
+	return nil
+}
+
+// newSpellingTestService creates SpellingService checking texts with fake speller that knows testWords
+// Mode is saved in user's settings unless it is empty
+func newSpellingTestService(t *testing.T, mode string) (*SpellingService, *memSpellerRepo) {
+	t.Helper()
+
+	server := spellertest.NewServer(testWords)
+	t.Cleanup(server.Close)
+
+	repo := &memSpellerRepo{words: make(map[string]models.DictionaryWord)}
+	if mode != "" {
+		repo.settings = &models.SpellerSettings{SpellMode: mode}
+	}
+	return NewSpellingService(repo, nil, NewSpellerService(server.URL, time.Second, 0, 0, 1)), repo
+}
+
+func TestCheckNoteSpellModes(t *testing.T) {
+	tests := []struct {
+		name         string
+		settingsMode string
+		mode         string
+		title        string
+		wantErr      error
+		wantStatus   string
+		wantIssues   []string
+	}{
+		{"default mode rejects mistakes", "", "", "превет", ErrSpell, "", nil},
+		{"reject mode rejects mistakes", models.SpellModeReject, "", "превет как дила", ErrSpell, "", nil},
+		{"reject mode passes correct text", models.SpellModeReject, "", "привет как дела", nil,
+			models.SpellStatusChecked, []string{}},
+		{"warn mode reports mistakes", models.SpellModeWarn, "", "превет как дила", nil, models.SpellStatusChecked,
+			[]string{"превет", "дила"}},
+		{"off mode skips check", models.SpellModeOff, "", "превет", nil, "", nil},
+		{"async mode queues check", models.SpellModeAsync, "", "превет", nil, models.SpellStatusPending, nil},
+		{"request mode overrides settings", models.SpellModeReject, models.SpellModeWarn, "превет", nil,
+			models.SpellStatusChecked, []string{"превет"}},
+		{"request can turn check off", models.SpellModeReject, models.SpellModeOff, "превет", nil, "", nil},
+		{"unknown mode", "", "block", "привет", ErrInvalidSpellMode, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss, _ := newSpellingTestService(t, tt.settingsMode)
+			note := models.Note{UserID: 7, Title: tt.title, SpellStatus: models.SpellStatusFailed}
+
+			checked, err := ss.checkNote(note, models.SpellOptions{Mode: tt.mode})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkNote error = %v, want %v", err, tt.wantErr)
+			}
+			if checked.SpellStatus != tt.wantStatus {
+				t.Errorf("spell status = %q, want %q", checked.SpellStatus, tt.wantStatus)
+			}
+
+			var words []string
+			if checked.SpellingIssues != nil {
+				words = []string{}
+			}
+			for _, issue := range checked.SpellingIssues {
+				words = append(words, issue.Word)
+				if issue.Field != "title" {
+					t.Errorf("issue %q is in field %q, want title", issue.Word, issue.Field)
+				}
+			}
+			if !reflect.DeepEqual(words, tt.wantIssues) {
+				t.Errorf("issues = %v, want %v", words, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestCheckNoteSpellerFailure(t *testing.T) {
+	failing := spellCheckerFunc(func(text, lang string, options int) ([]models.SpellingIssue, error) {
+		return nil, errors.New("speller is down")
+	})
+	ss := NewSpellingService(&memSpellerRepo{}, nil, failing)
+	note := models.Note{UserID: 7, Title: "превет"}
+
+	// Note with unchecked text can still be saved in warn mode, but not in reject mode
+	checked, err := ss.checkNote(note, models.SpellOptions{Mode: models.SpellModeWarn})
+	if err != nil || checked.SpellStatus != "" || checked.SpellingIssues != nil {
+		t.Errorf("checkNote in warn mode = %+v, %v, want unchecked note", checked, err)
+	}
+	if _, err = ss.checkNote(note, models.SpellOptions{Mode: models.SpellModeReject}); err == nil {
+		t.Error("checkNote in reject mode saved unchecked note")
+	}
+}
+
+func TestCheckNoteLang(t *testing.T) {
+	var langs []string
+	checker := spellCheckerFunc(func(text, lang string, options int) ([]models.SpellingIssue, error) {
+		langs = append(langs, lang)
+		return []models.SpellingIssue{}, nil
+	})
+	ss := NewSpellingService(&memSpellerRepo{}, nil, checker)
+	warn := models.SpellOptions{Mode: models.SpellModeWarn}
+
+	// Language of note is used when set and detected from both fields otherwise
+	if _, err := ss.checkNote(models.Note{Title: "Привет", Description: "hello", Lang: "uk"}, warn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.checkNote(models.Note{Title: "Привет", Description: "hello"}, warn); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"uk", "uk", "ru,en", "ru,en"}; !reflect.DeepEqual(langs, want) {
+		t.Errorf("checked in languages %v, want %v", langs, want)
+	}
+}
+
+func TestCorrectNote(t *testing.T) {
+	ss, _ := newSpellingTestService(t, models.SpellModeReject)
+	if _, err := ss.AddDictionaryWord(7, "Гугл"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Words from dictionary are not corrected
+	note, corrections := ss.correctNote(models.Note{UserID: 7, Title: "превет", Description: "как дила гугл"})
+	if note.Title != "привет" || note.Description != "как дела гугл" {
+		t.Errorf("corrected note = %q, %q, want привет, как дела гугл", note.Title, note.Description)
+	}
+	want := []models.SpellingCorrection{
+		{Field: "title", Word: "превет", Replacement: "привет", Pos: 0},
+		{Field: "description", Word: "дила", Replacement: "дела", Pos: 4},
+	}
+	if !reflect.DeepEqual(corrections, want) {
+		t.Errorf("corrections = %+v, want %+v", corrections, want)
+	}
+
+	// Corrected note passes check in reject mode
+	if _, err := ss.checkNote(note, models.SpellOptions{}); err != nil {
+		t.Errorf("checkNote of corrected note error = %v", err)
+	}
+}
+
+func TestDictionaryWords(t *testing.T) {
+	ss, repo := newSpellingTestService(t, models.SpellModeWarn)
+
+	added, err := ss.AddDictionaryWord(7, "  Ёжик ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Word != "Ёжик" {
+		t.Errorf("added word = %q, want Ёжик", added.Word)
+	}
+	if _, ok := repo.words["ежик"]; !ok {
+		t.Errorf("dictionary = %v, want word stored under ежик", repo.words)
+	}
+
+	// Word is the same in any case and with е in place of ё
+	for _, word := range []string{"ёжик", "ЕЖИК"} {
+		if _, err = ss.AddDictionaryWord(7, word); !errors.Is(err, ErrWordAlreadyExists) {
+			t.Errorf("AddDictionaryWord(%q) error = %v, want ErrWordAlreadyExists", word, err)
+		}
+	}
+	invalid := []string{"", "   ", "два слова", "слово\tс\tтабуляцией", strings.Repeat("я", maxWordLength+1)}
+	for _, word := range invalid {
+		if _, err = ss.AddDictionaryWord(7, word); !errors.Is(err, ErrInvalidWord) {
+			t.Errorf("AddDictionaryWord(%q) error = %v, want ErrInvalidWord", word, err)
+		}
+	}
+
+	issues, err := ss.CheckSpelling(7, "привет ежик и ЁЖИК", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Word != "и" {
+		t.Errorf("issues = %+v, want only и", issues)
+	}
+
+	if err = ss.DeleteDictionaryWord(7, " ЕЖИК "); err != nil {
+		t.Fatal(err)
+	}
+	if err = ss.DeleteDictionaryWord(7, "ежик"); !errors.Is(err, ErrWordNotFound) {
+		t.Errorf("DeleteDictionaryWord of deleted word error = %v, want ErrWordNotFound", err)
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"ежик", "ежик"},
+		{"Ёжик", "ежик"},
+		{"ЁЛКА", "елка"},
+		{"Hello", "hello"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeWord(tt.word); got != tt.want {
+			t.Errorf("normalizeWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestDetectLang(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"russian", "Привет, как дела?", "ru"},
+		{"english", "Hello, how are you?", "en"},
+		{"ukrainian", "Привіт, як справи? Їжак", "uk"},
+		{"russian and english", "Встреча по проекту Apollo", "ru,en"},
+		{"ukrainian and english", "Зустріч щодо проєкту Apollo", "uk,en"},
+		{"russian letters outweigh ukrainian", "Объявление: ёлка і ещё", "ru"},
+		{"word mostly in cyrillic", "Пpивет", "ru"},
+		{"no letters", "12:30 — 13:00", "ru"},
+		{"empty text", "", "ru"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLang(tt.text); got != tt.want {
+				t.Errorf("detectLang(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLang(t *testing.T) {
+	tests := []struct {
+		lang    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"ru", "ru", false},
+		{" EN ", "en", false},
+		{"ru, en", "ru,en", false},
+		{"en,ru", "en,ru", false},
+		{"uk,uk,UK", "uk", false},
+		{"ru,,en,", "ru,en", false},
+		{" , ", "", false},
+		{"de", "", true},
+		{"ru,de", "", true},
+		{"russian", "", true},
+		{"ru en", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeLang(tt.lang)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidLang) {
+				t.Errorf("normalizeLang(%q) error = %v, want ErrInvalidLang", tt.lang, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeLang(%q) = %q, %v, want %q", tt.lang, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+var (
+	ErrTagNotFound      = errors.New("тег не найден")
+	ErrTagAlreadyExists = errors.New("тег с таким именем уже существует")
+	ErrInvalidTag       = errors.New("неправильное имя тега")
+)
+
+// maxTagLength is maximum length of tag name in characters
+const maxTagLength = 64
+
+// TagService provides management of user's note tags
+type TagService struct {
+	repo repository.TagRepo
+}
+
+// NewTagService creates new instance of TagService
+func NewTagService(repo repository.TagRepo) *TagService {
+	return &TagService{repo: repo}
+}
+
+// GetTagList retrieves all tags of user with number of notes for each tag
+func (ts *TagService) GetTagList(userID int) ([]models.Tag, error) {
+	tags, err := ts.repo.GetAll(userID)
+	if err != nil {
+		log.Printf("Ошибка при получении списка тегов: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Список тегов успешно получен для пользователя ID: %d", userID)
+	return tags, nil
+}
+
+// RenameTag changes name of user's tag
+// If user already has tag with new name, ErrTagAlreadyExists is returned and tags should be merged instead
+func (ts *TagService) RenameTag(userID, tagID int, name string) (models.Tag, error) {
+	tag, err := ts.getTag(userID, tagID)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	name, err = normalizeTag(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	if err = ts.repo.Rename(tagID, name); err != nil {
+		if errors.Is(err, postgresql.ErrTagAlreadyExists) {
+			return models.Tag{}, ErrTagAlreadyExists
+		}
+		log.Printf("Ошибка при переименовании тега ID %d: %v", tagID, err)
+		return models.Tag{}, err
+	}
+
+	log.Printf("Тег ID %d переименован: %s -> %s", tagID, tag.Name, name)
+	tag.Name = name
+	return tag, nil
+}
+
+// MergeTags moves all notes of source tags to target tag and deletes source tags
+// All tags must belong to user
+func (ts *TagService) MergeTags(userID int, sourceIDs []int, targetID int) (models.Tag, error) {
+	if _, err := ts.getTag(userID, targetID); err != nil {
+		return models.Tag{}, err
+	}
+
+	var sources []int
+	for _, id := range sourceIDs {
+		if id == targetID {
+			continue
+		}
+		if _, err := ts.getTag(userID, id); err != nil {
+			return models.Tag{}, err
+		}
+		sources = append(sources, id)
+	}
+
+	if len(sources) > 0 {
+		if err := ts.repo.Merge(sources, targetID); err != nil {
+			log.Printf("Ошибка при объединении тегов %v в тег ID %d: %v", sources, targetID, err)
+			return models.Tag{}, err
+		}
+	}
+
+	log.Printf("Теги %v объединены в тег ID %d", sources, targetID)
+	return ts.getTag(userID, targetID)
+}
+
+// DeleteTag deletes user's tag and detaches it from all notes
+func (ts *TagService) DeleteTag(userID, tagID int) error {
+	if _, err := ts.getTag(userID, tagID); err != nil {
+		return err
+	}
+
+	if err := ts.repo.Delete(tagID); err != nil {
+		if errors.Is(err, postgresql.ErrTagNotFound) {
+			return ErrTagNotFound
+		}
+		log.Printf("Ошибка при удалении тега ID %d: %v", tagID, err)
+		return err
+	}
+
+	log.Printf("Тег ID %d успешно удален", tagID)
+	return nil
+}
+
+// getTag retrieves tag and makes sure it belongs to user
+// Tags of other users are reported as not found
+func (ts *TagService) getTag(userID, tagID int) (models.Tag, error) {
+	tag, err := ts.repo.GetByID(tagID)
+	if err != nil {
+		if errors.Is(err, postgresql.ErrTagNotFound) {
+			return models.Tag{}, ErrTagNotFound
+		}
+		log.Printf("Ошибка при получении тега ID %d: %v", tagID, err)
+		return models.Tag{}, err
+	}
+
+	if tag.UserID != userID {
+		return models.Tag{}, ErrTagNotFound
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes each tag name, removes duplicates and sorts result
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeTag trims and lowercases tag name and checks its length
+func normalizeTag(tag string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(tag))
+	if name == "" || utf8.RuneCountInString(name) > maxTagLength {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return name, nil
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+)
+
+// rfc6238Secret is SHA1 key of test vectors of RFC 6238, Appendix B
+const rfc6238Secret = "12345678901234567890"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Codes are last six digits of eight-digit codes from the RFC
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if code := totpCode([]byte(rfc6238Secret), totpStep(time.Unix(tt.unix, 0))); code != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, code, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfc6238Secret))
+	now := time.Unix(1111111111, 0)
+	current := totpStep(now)
+	codeAt := func(offset int64) string {
+		return totpCode([]byte(rfc6238Secret), current+offset)
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", secret, codeAt(0), current, true},
+		{"previous step", secret, codeAt(-1), current - 1, true},
+		{"next step", secret, codeAt(1), current + 1, true},
+		{"two steps before", secret, codeAt(-2), 0, false},
+		{"two steps after", secret, codeAt(2), 0, false},
+		{"code with spaces", secret, codeAt(0)[:3] + " " + codeAt(0)[3:], current, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", codeAt(0), current, true},
+		{"short code", secret, codeAt(0)[:5], 0, false},
+		{"wrong code", secret, "000000", 0, false},
+		{"invalid secret", "not base32!", codeAt(0), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("verifyTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// memMFARepo keeps TOTP state of users in memory and marks users in users as having two-factor authentication
+type memMFARepo struct {
+	repository.MFARepo
+	users    *memUserRepo
+	totp     map[int]models.TOTP
+	lastStep map[int]int64
+	recovery map[int]map[string]bool
+}
+
+func newMemMFARepo(users *memUserRepo) *memMFARepo {
+	return &memMFARepo{users: users, totp: make(map[int]models.TOTP), lastStep: make(map[int]int64),
+		recovery: make(map[int]map[string]bool)}
+}
+
+func (r *memMFARepo) GetTOTP(userID int) (models.TOTP, error) {
+	return r.totp[userID], nil
+}
+
+func (r *memMFARepo) SetPendingTOTP(userID int, secret string) error {
+	r.totp[userID] = models.TOTP{Secret: secret}
+	return nil
+}
+
+func (r *memMFARepo) EnableTOTP(userID int, step int64, recoveryCodeHashes []string) error {
+	now := time.Now()
+	r.totp[userID] = models.TOTP{Secret: r.totp[userID].Secret, EnabledAt: &now}
+	r.lastStep[userID] = step
+	r.recovery[userID] = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		r.recovery[userID][hash] = false
+	}
+	r.setMFAEnabled(userID, true)
+	return nil
+}
+
+func (r *memMFARepo) DisableTOTP(userID int) error {
+	delete(r.totp, userID)
+	delete(r.lastStep, userID)
+	delete(r.recovery, userID)
+	r.setMFAEnabled(userID, false)
+	return nil
+}
+
+func (r *memMFARepo) UseTOTPStep(userID int, step int64) (bool, error) {
+	if last, ok := r.lastStep[userID]; ok && last >= step {
+		return false, nil
+	}
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func (r *memMFARepo) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	used, ok := r.recovery[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recovery[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *memMFARepo) setMFAEnabled(userID int, enabled bool) {
+	for name, user := range r.users.users {
+		if user.ID == userID {
+			user.MFAEnabled = enabled
+			r.users.users[name] = user
+		}
+	}
+}
+
+// newMFATestService creates AuthService for user "alice" with password "alice-password" and enrols her in 2FA
+// Returns service, TOTP secret, time step of code used to confirm enrolment and recovery codes
+func newMFATestService(t *testing.T, guard *LoginGuard) (*AuthService, []byte, int64, []string) {
+	t.Helper()
+
+	repo := newMemUserRepo(t, models.User{Name: "alice", Password: "alice-password"})
+	as := &AuthService{repo: repo, sessions: sessionCreator{}, mfa: newMemMFARepo(repo), guard: guard,
+		keys: newTestKeySet(t), jwtIssuer: "rest-notes", jwtAudience: "rest-notes", mfaIssuer: "rest-notes",
+		accessTokenTTL: time.Minute, refreshTokenTTL: time.Hour}
+
+	enrollment, err := as.EnrollMFA(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := totpStep(time.Now())
+	codes, err := as.ConfirmMFA(1, totpCode(secret, step))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("ConfirmMFA returned %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	return as, secret, step, codes
+}
+
+// mfaLogin logs alice in with password and returns mfa_pending token
+func mfaLogin(t *testing.T, as *AuthService) string {
+	t.Helper()
+
+	tokens, err := as.GenerateToken(models.User{Name: "alice", Password: "alice-password"}, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.MFAToken == "" || tokens.AccessToken != "" || tokens.TokenType != mfaPendingTokenType {
+		t.Fatalf("login of user with 2FA returned %+v, want only mfa_pending token", tokens)
+	}
+	return tokens.MFAToken
+}
+
+func TestVerifyMFARejectsReplayedCode(t *testing.T) {
+	as, secret, step, _ := newMFATestService(t, NewLoginGuard(10, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	// Code used to confirm enrolment can't be used again
+	if _, err := as.VerifyMFA(mfaLogin(t, as), totpCode(secret, step), "10.0.0.1"); !errors.Is(err,
+		ErrInvalidMFACode) {
+		t.Fatalf("VerifyMFA with code of enrolment error = %v, want ErrInvalidMFACode", err)
+	}
+
+	// Code of next step is accepted once
+	tokens, err := as.VerifyMFA(mfaLogin(t, as), totpCode(secret, step+1), "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("VerifyMFA returned %+v, want pair of tokens", tokens)
+	}
+	if _, err = as.VerifyMFA(mfaLogin(t, as), totpCode(secret, step+1), "10.0.0.1"); !errors.Is(err,
+		ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA with replayed code error = %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestVerifyMFARecoveryCodes(t *testing.T) {
+	as, _, _, codes := newMFATestService(t, NewLoginGuard(10, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	// Recovery code is accepted in lower case and without dashes, but only once
+	code := codes[0]
+	if _, err := as.VerifyMFA(mfaLogin(t, as), code, "10.0.0.1"); err != nil {
+		t.Fatalf("VerifyMFA with recovery code error = %v", err)
+	}
+	if _, err := as.VerifyMFA(mfaLogin(t, as), code, "10.0.0.1"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA with used recovery code error = %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := as.VerifyMFA(mfaLogin(t, as), strings.ToLower(codes[1]), "10.0.0.1"); err != nil {
+		t.Errorf("VerifyMFA with recovery code in lower case error = %v", err)
+	}
+	if _, err := as.VerifyMFA(mfaLogin(t, as), strings.ReplaceAll(codes[2], "-", ""), "10.0.0.1"); err != nil {
+		t.Errorf("VerifyMFA with recovery code without dashes error = %v", err)
+	}
+}
+
+func TestMFAPendingTokenIsNotAccessToken(t *testing.T) {
+	as, _, _, codes := newMFATestService(t, NewLoginGuard(10, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	mfaToken := mfaLogin(t, as)
+	if valid, _, err := as.IsTokenValid(mfaToken); valid || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("IsTokenValid of mfa_pending token = %v, %v, want ErrInvalidToken", valid, err)
+	}
+
+	// Access token can't be used in place of mfa_pending token either
+	tokens, err := as.VerifyMFA(mfaToken, codes[0], "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = as.VerifyMFA(tokens.AccessToken, codes[1], "10.0.0.1"); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("VerifyMFA with access token error = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestDisableMFAGuessesAreLimited(t *testing.T) {
+	as, secret, step, _ := newMFATestService(t, NewLoginGuard(2, time.Hour, time.Hour, 0, 0, time.Hour))
+
+	if err := as.DisableMFA(1, "guess", totpCode(secret, step+1), "10.0.0.1"); !errors.Is(err,
+		ErrInvalidPassword) {
+		t.Fatalf("DisableMFA with wrong password error = %v, want ErrInvalidPassword", err)
+	}
+	if err := as.DisableMFA(1, "alice-password", "000000", "10.0.0.2"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("DisableMFA with wrong code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	// After two failures even correct password and code are rejected
+	var blocked *LoginBlockedError
+	if err := as.DisableMFA(1, "alice-password", totpCode(secret, step+1), "10.0.0.3"); !errors.As(err, &blocked) {
+		t.Fatalf("DisableMFA after failures error = %v, want *LoginBlockedError", err)
+	}
+	if user, _ := as.repo.GetByID(1); !user.MFAEnabled {
+		t.Error("2FA was disabled despite block")
+	}
+}
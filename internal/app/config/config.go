@@ -2,20 +2,178 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
-var defaultHttpPort = ":8080"
+var (
+	defaultHttpPort           = ":8080"
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+	defaultAccessTokenTTL     = 15 * time.Minute
+	defaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	defaultSpellerBackend     = SpellerBackendYandex
+	defaultSpellerURL         = "https://speller.yandex.net/services/spellservice.json"
+	defaultSpellerTimeout     = 10 * time.Second
+	defaultSpellerRetries     = 2
+	defaultSpellerBackoff     = 200 * time.Millisecond
+	defaultSpellerConcurrency = 4
+	defaultBreakerThreshold   = 5
+	defaultBreakerCooldown    = 30 * time.Second
+	defaultSpellerCacheSize   = 1000
+	defaultSpellerCacheTTL    = time.Hour
+	defaultSpellWorkers       = 2
+	defaultSpellPollInterval  = time.Second
+	defaultSpellJobBackoff    = 30 * time.Second
+	defaultSpellJobAttempts   = 5
+	defaultPasswordMinLength  = 8
+	defaultPasswordResetTTL   = time.Hour
+	defaultMailer             = MailerLog
+	defaultMailerFile         = "mail.log"
+	defaultLoginFreeAttempts  = 3
+	defaultLoginDelay         = time.Second
+	defaultLoginMaxDelay      = time.Minute
+	defaultUserLockoutLimit   = 10
+	defaultIPLockoutLimit     = 50
+	defaultLockoutDuration    = 15 * time.Minute
+	defaultRateLimitBackend   = RateLimitMemory
+	defaultAuthRateLimit      = 30
+	defaultAuthRateBurst      = 10
+	defaultAPIRateLimit       = 600
+	defaultAPIRateBurst       = 100
+	defaultMFAIssuer          = "rest-notes"
+	defaultJWTIssuer          = "rest-notes"
+	defaultJWTAudience        = "rest-notes"
+	defaultOIDCRedirectBase   = "http://localhost:8080"
+	defaultOIDCScopes         = []string{"openid", "profile", "email"}
+)
+
+// Spell checker backends
+const (
+	SpellerBackendYandex   = "yandex"
+	SpellerBackendHunspell = "hunspell"
+)
+
+// Mailers delivering messages to users
+const (
+	MailerLog  = "log"
+	MailerFile = "file"
+)
 
-// Config struct holds configuration values for database url and http port
+// Storages of rate limiter buckets
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+// oidcProviderName matches names of OpenID Connect providers, they are used in routes and names of variables
+var oidcProviderName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// OIDCProvider is registration of application at OpenID Connect provider
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// maxPasswordMinLength is longest password length that can be required, bcrypt ignores bytes after 72nd
+const maxPasswordMinLength = 72
+
+// Config struct holds configuration values for database url, http port, tokens, spell checker and background jobs
 type Config struct {
-	DbUrl    string
-	HttpPort string
+	DbUrl              string
+	HttpPort           string
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	SpellerBackend     string
+	HunspellDic        string
+	HunspellAff        string
+	SpellerURL         string
+	SpellerTimeout     time.Duration
+	SpellerRetries     int
+	SpellerBackoff     time.Duration
+	SpellerConcurrency int
+	BreakerThreshold   int
+	BreakerCooldown    time.Duration
+	BreakerFailOpen    bool
+	SpellerCacheSize   int
+	SpellerCacheTTL    time.Duration
+	SpellWorkers       int
+	SpellPollInterval  time.Duration
+	SpellJobBackoff    time.Duration
+	SpellJobAttempts   int
+	AdminUsername      string
+	AdminPassword      string
+	PasswordMinLength  int
+	BreachedPasswords  string
+	PasswordResetTTL   time.Duration
+	Mailer             string
+	MailerFile         string
+	LoginFreeAttempts  int
+	LoginDelay         time.Duration
+	LoginMaxDelay      time.Duration
+	UserLockoutLimit   int
+	IPLockoutLimit     int
+	LockoutDuration    time.Duration
+	RateLimitBackend   string
+	AuthRateLimit      int
+	AuthRateBurst      int
+	APIRateLimit       int
+	APIRateBurst       int
+	TrustProxy         bool
+	MFAIssuer          string
+	JWTSigningKey      string
+	JWTVerifyKeys      []string
+	JWTIssuer          string
+	JWTAudience        string
+	OIDCProviders      []OIDCProvider
+	OIDCRedirectBase   string
+	MetricsToken       string
 }
 
 // New creates new Config instance by reading environment variables
 // It checks if required DATABASE_URL is set; if not, it returns error
 // If HTTP_PORT is not set, it defaults to ":8080".
+// TRASH_RETENTION and TRASH_PURGE_INTERVAL are durations like "720h", they default to 30 days and 1 hour
+// ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL default to 15 minutes and 30 days
+// SPELLER_BACKEND is "yandex" (default) or "hunspell"; hunspell requires HUNSPELL_DIC and HUNSPELL_AFF paths
+// SPELLER_URL is base URL of Speller API, e.g. of local fake speller, it defaults to Yandex Speller
+// SPELLER_TIMEOUT, SPELLER_RETRIES and SPELLER_RETRY_BACKOFF tune requests to Speller API,
+// SPELLER_CONCURRENCY limits concurrent requests for chunks of long text
+// SPELLER_BREAKER_THRESHOLD consecutive failures open circuit for SPELLER_BREAKER_COOLDOWN,
+// with SPELLER_BREAKER_FAIL_OPEN=true notes are saved unchecked while it is open
+// SPELLER_CACHE_SIZE (0 disables cache) and SPELLER_CACHE_TTL limit cache of check results
+// SPELL_WORKERS (0 disables background checks on this instance) poll queue of async checks every SPELL_POLL_INTERVAL,
+// failed check is retried after SPELL_JOB_RETRY_BACKOFF doubling each time, up to SPELL_JOB_MAX_ATTEMPTS attempts
+// ADMIN_USERNAME and ADMIN_PASSWORD are credentials of administrator created on startup, existing user
+// with this name gets admin role only if ADMIN_PASSWORD is their password
+// PASSWORD_MIN_LENGTH (default 8) and PASSWORD_BREACHED_LIST, path to file with one breached password per line,
+// set password policy; PASSWORD_RESET_TTL (default 1 hour) is lifetime of password reset token
+// MAILER is "log" (default) or "file", file mailer appends messages to MAILER_FILE (default "mail.log")
+// After LOGIN_FREE_ATTEMPTS failed logins per username or IP address next attempt has to wait LOGIN_DELAY,
+// doubling up to LOGIN_MAX_DELAY; LOGIN_USER_LOCKOUT_LIMIT and LOGIN_IP_LOCKOUT_LIMIT failures (0 disables)
+// lock login out for LOGIN_LOCKOUT_DURATION
+// RATE_LIMIT_BACKEND is "memory" (default) or "postgres" to share limits between replicas;
+// RATE_LIMIT_AUTH_PER_MINUTE and RATE_LIMIT_API_PER_MINUTE (0 disables) with bursts RATE_LIMIT_AUTH_BURST
+// and RATE_LIMIT_API_BURST limit requests per client address to /auth and to other routes
+// TRUST_PROXY=true takes client address from X-Forwarded-For header set by reverse proxy
+// MFA_ISSUER (default "rest-notes") is name of service shown in authenticator apps
+// JWT_SIGNING_KEY is required path to PEM file with RSA or Ed25519 private key that JWTs are signed with,
+// JWT_VERIFICATION_KEYS is comma-separated list of PEM files with previous keys still accepted after rotation
+// JWT_ISSUER and JWT_AUDIENCE (both default to "rest-notes") are iss and aud claims of JWTs
+// OIDC_PROVIDERS is comma-separated list of names of OpenID Connect providers, for provider "corp"
+// OIDC_CORP_ISSUER and OIDC_CORP_CLIENT_ID are required, OIDC_CORP_CLIENT_SECRET is empty for public client
+// and OIDC_CORP_SCOPES defaults to "openid profile email"; OIDC_REDIRECT_BASE_URL (default "http://localhost:8080")
+// is public URL of application that callback path is appended to
+// METRICS_TOKEN is bearer token Prometheus scrapes /metrics with, without it metrics are available to administrators only
 func New() (*Config, error) {
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
@@ -27,8 +185,407 @@ func New() (*Config, error) {
 		httpPort = defaultHttpPort
 	}
 
+	trashRetention, err := getDuration("TRASH_RETENTION", defaultTrashRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	trashPurgeInterval, err := getDuration("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenTTL, err := getDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenTTL, err := getDuration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerBackend := os.Getenv("SPELLER_BACKEND")
+	if spellerBackend == "" {
+		spellerBackend = defaultSpellerBackend
+	}
+
+	hunspellDic := os.Getenv("HUNSPELL_DIC")
+	hunspellAff := os.Getenv("HUNSPELL_AFF")
+
+	switch spellerBackend {
+	case SpellerBackendYandex:
+	case SpellerBackendHunspell:
+		if hunspellDic == "" || hunspellAff == "" {
+			return nil, fmt.Errorf("для SPELLER_BACKEND=hunspell нужны HUNSPELL_DIC и HUNSPELL_AFF")
+		}
+	default:
+		return nil, fmt.Errorf("неправильное значение SPELLER_BACKEND: %q", spellerBackend)
+	}
+
+	spellerURL := os.Getenv("SPELLER_URL")
+	if spellerURL == "" {
+		spellerURL = defaultSpellerURL
+	}
+
+	spellerTimeout, err := getDuration("SPELLER_TIMEOUT", defaultSpellerTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerRetries, err := getInt("SPELLER_RETRIES", defaultSpellerRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerBackoff, err := getDuration("SPELLER_RETRY_BACKOFF", defaultSpellerBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerConcurrency, err := getInt("SPELLER_CONCURRENCY", defaultSpellerConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	if spellerConcurrency == 0 {
+		return nil, fmt.Errorf("SPELLER_CONCURRENCY должен быть больше нуля")
+	}
+
+	breakerThreshold, err := getInt("SPELLER_BREAKER_THRESHOLD", defaultBreakerThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if breakerThreshold == 0 {
+		return nil, fmt.Errorf("SPELLER_BREAKER_THRESHOLD должен быть больше нуля")
+	}
+
+	breakerCooldown, err := getDuration("SPELLER_BREAKER_COOLDOWN", defaultBreakerCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	breakerFailOpen, err := getBool("SPELLER_BREAKER_FAIL_OPEN", false)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerCacheSize, err := getInt("SPELLER_CACHE_SIZE", defaultSpellerCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	spellerCacheTTL, err := getDuration("SPELLER_CACHE_TTL", defaultSpellerCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	spellWorkers, err := getInt("SPELL_WORKERS", defaultSpellWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	spellPollInterval, err := getDuration("SPELL_POLL_INTERVAL", defaultSpellPollInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	spellJobBackoff, err := getDuration("SPELL_JOB_RETRY_BACKOFF", defaultSpellJobBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	spellJobAttempts, err := getInt("SPELL_JOB_MAX_ATTEMPTS", defaultSpellJobAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if spellJobAttempts == 0 {
+		return nil, fmt.Errorf("SPELL_JOB_MAX_ATTEMPTS должен быть больше нуля")
+	}
+
+	adminUsername := os.Getenv("ADMIN_USERNAME")
+	adminPassword := os.Getenv("ADMIN_PASSWORD")
+	if adminUsername != "" && adminPassword == "" {
+		return nil, fmt.Errorf("для ADMIN_USERNAME нужен ADMIN_PASSWORD")
+	}
+
+	passwordMinLength, err := getInt("PASSWORD_MIN_LENGTH", defaultPasswordMinLength)
+	if err != nil {
+		return nil, err
+	}
+	if passwordMinLength > maxPasswordMinLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH не может быть больше %d", maxPasswordMinLength)
+	}
+
+	passwordResetTTL, err := getDuration("PASSWORD_RESET_TTL", defaultPasswordResetTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer := os.Getenv("MAILER")
+	if mailer == "" {
+		mailer = defaultMailer
+	}
+	if mailer != MailerLog && mailer != MailerFile {
+		return nil, fmt.Errorf("неправильное значение MAILER: %q", mailer)
+	}
+
+	mailerFile := os.Getenv("MAILER_FILE")
+	if mailerFile == "" {
+		mailerFile = defaultMailerFile
+	}
+
+	loginFreeAttempts, err := getInt("LOGIN_FREE_ATTEMPTS", defaultLoginFreeAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	loginDelay, err := getDuration("LOGIN_DELAY", defaultLoginDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	loginMaxDelay, err := getDuration("LOGIN_MAX_DELAY", defaultLoginMaxDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	userLockoutLimit, err := getInt("LOGIN_USER_LOCKOUT_LIMIT", defaultUserLockoutLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	ipLockoutLimit, err := getInt("LOGIN_IP_LOCKOUT_LIMIT", defaultIPLockoutLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutDuration, err := getDuration("LOGIN_LOCKOUT_DURATION", defaultLockoutDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitBackend := os.Getenv("RATE_LIMIT_BACKEND")
+	if rateLimitBackend == "" {
+		rateLimitBackend = defaultRateLimitBackend
+	}
+	if rateLimitBackend != RateLimitMemory && rateLimitBackend != RateLimitPostgres {
+		return nil, fmt.Errorf("неправильное значение RATE_LIMIT_BACKEND: %q", rateLimitBackend)
+	}
+
+	authRateLimit, authRateBurst, err := getRateLimit("RATE_LIMIT_AUTH", defaultAuthRateLimit, defaultAuthRateBurst)
+	if err != nil {
+		return nil, err
+	}
+
+	apiRateLimit, apiRateBurst, err := getRateLimit("RATE_LIMIT_API", defaultAPIRateLimit, defaultAPIRateBurst)
+	if err != nil {
+		return nil, err
+	}
+
+	trustProxy, err := getBool("TRUST_PROXY", false)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = defaultMFAIssuer
+	}
+
+	jwtSigningKey := os.Getenv("JWT_SIGNING_KEY")
+	if jwtSigningKey == "" {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY не задан")
+	}
+
+	var jwtVerifyKeys []string
+	for _, file := range strings.Split(os.Getenv("JWT_VERIFICATION_KEYS"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			jwtVerifyKeys = append(jwtVerifyKeys, file)
+		}
+	}
+
+	jwtIssuer := os.Getenv("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = defaultJWTIssuer
+	}
+
+	jwtAudience := os.Getenv("JWT_AUDIENCE")
+	if jwtAudience == "" {
+		jwtAudience = defaultJWTAudience
+	}
+
+	oidcProviders, err := getOIDCProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	oidcRedirectBase := os.Getenv("OIDC_REDIRECT_BASE_URL")
+	if oidcRedirectBase == "" {
+		oidcRedirectBase = defaultOIDCRedirectBase
+	}
+	if u, err := url.Parse(oidcRedirectBase); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("неправильное значение OIDC_REDIRECT_BASE_URL: %q", oidcRedirectBase)
+	}
+
 	return &Config{
-		DbUrl:    dbURL,
-		HttpPort: httpPort,
+		DbUrl:              dbURL,
+		HttpPort:           httpPort,
+		TrashRetention:     trashRetention,
+		TrashPurgeInterval: trashPurgeInterval,
+		AccessTokenTTL:     accessTokenTTL,
+		RefreshTokenTTL:    refreshTokenTTL,
+		SpellerBackend:     spellerBackend,
+		HunspellDic:        hunspellDic,
+		HunspellAff:        hunspellAff,
+		SpellerURL:         spellerURL,
+		SpellerTimeout:     spellerTimeout,
+		SpellerRetries:     spellerRetries,
+		SpellerBackoff:     spellerBackoff,
+		SpellerConcurrency: spellerConcurrency,
+		BreakerThreshold:   breakerThreshold,
+		BreakerCooldown:    breakerCooldown,
+		BreakerFailOpen:    breakerFailOpen,
+		SpellerCacheSize:   spellerCacheSize,
+		SpellerCacheTTL:    spellerCacheTTL,
+		SpellWorkers:       spellWorkers,
+		SpellPollInterval:  spellPollInterval,
+		SpellJobBackoff:    spellJobBackoff,
+		SpellJobAttempts:   spellJobAttempts,
+		AdminUsername:      adminUsername,
+		AdminPassword:      adminPassword,
+		PasswordMinLength:  passwordMinLength,
+		BreachedPasswords:  os.Getenv("PASSWORD_BREACHED_LIST"),
+		PasswordResetTTL:   passwordResetTTL,
+		Mailer:             mailer,
+		MailerFile:         mailerFile,
+		LoginFreeAttempts:  loginFreeAttempts,
+		LoginDelay:         loginDelay,
+		LoginMaxDelay:      loginMaxDelay,
+		UserLockoutLimit:   userLockoutLimit,
+		IPLockoutLimit:     ipLockoutLimit,
+		LockoutDuration:    lockoutDuration,
+		RateLimitBackend:   rateLimitBackend,
+		AuthRateLimit:      authRateLimit,
+		AuthRateBurst:      authRateBurst,
+		APIRateLimit:       apiRateLimit,
+		APIRateBurst:       apiRateBurst,
+		TrustProxy:         trustProxy,
+		MFAIssuer:          mfaIssuer,
+		JWTSigningKey:      jwtSigningKey,
+		JWTVerifyKeys:      jwtVerifyKeys,
+		JWTIssuer:          jwtIssuer,
+		JWTAudience:        jwtAudience,
+		OIDCProviders:      oidcProviders,
+		OIDCRedirectBase:   strings.TrimSuffix(oidcRedirectBase, "/"),
+		MetricsToken:       os.Getenv("METRICS_TOKEN"),
 	}, nil
 }
+
+// getOIDCProviders reads registrations of OpenID Connect providers listed in OIDC_PROVIDERS
+// Settings of each provider are read from variables prefixed with OIDC_ and upper-cased provider name
+func getOIDCProviders() ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !oidcProviderName.MatchString(name) {
+			return nil, fmt.Errorf("неправильное имя провайдера OIDC: %q, допустимы строчные латинские буквы и цифры", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("провайдер OIDC %q указан дважды", name)
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " ")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("для провайдера OIDC %q нужны %sISSUER и %sCLIENT_ID", name, prefix, prefix)
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = defaultOIDCScopes
+		}
+
+		hasOpenID := false
+		for _, scope := range provider.Scopes {
+			hasOpenID = hasOpenID || scope == "openid"
+		}
+		if !hasOpenID {
+			return nil, fmt.Errorf("%sSCOPES должен содержать openid", prefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// getRateLimit reads requests per minute and burst of rate limit from variables prefix_PER_MINUTE and prefix_BURST
+// Burst must be positive when limit is enabled
+func getRateLimit(prefix string, fallbackLimit, fallbackBurst int) (int, int, error) {
+	limit, err := getInt(prefix+"_PER_MINUTE", fallbackLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	burst, err := getInt(prefix+"_BURST", fallbackBurst)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit > 0 && burst == 0 {
+		return 0, 0, fmt.Errorf("%s_BURST должен быть больше нуля", prefix)
+	}
+
+	return limit, burst, nil
+}
+
+// getDuration reads positive duration from environment variable, returning fallback if it is not set
+func getDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("неправильное значение %s: %q", name, value)
+	}
+	return duration, nil
+}
+
+// getInt reads non-negative integer from environment variable, returning fallback if it is not set
+func getInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("неправильное значение %s: %q", name, value)
+	}
+	return n, nil
+}
+
+// getBool reads boolean from environment variable, returning fallback if it is not set
+func getBool(name string, fallback bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("неправильное значение %s: %q", name, value)
+	}
+	return b, nil
+}
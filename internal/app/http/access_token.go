@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/models"
+)
+
+// CreateAccessTokenHandler handles HTTP POST request to issue personal access token
+// Token value is present only in this response
+func (h *Handler) CreateAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	token := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	createdToken, err := h.service.CreateAccessToken(token)
+	if err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+
+	// Respond with created token
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdToken)
+}
+
+// GetAccessTokensHandler handles HTTP GET request to list user's active personal access tokens
+func (h *Handler) GetAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.service.GetAccessTokens(userID)
+	if err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+
+	// Respond with list of tokens
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RevokeAccessTokenHandler handles HTTP DELETE request to revoke personal access token
+func (h *Handler) RevokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор токена", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.RevokeAccessToken(userID, tokenID); err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAccessTokenError maps service errors of personal access token operations to HTTP responses
+func writeAccessTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrAccessTokenNotFound):
+		http.Error(w, "Токен доступа не найден", http.StatusNotFound)
+	case errors.Is(err, api.ErrInvalidAccessToken):
+		http.Error(w, "Укажите название токена, хотя бы одну из областей доступа "+
+			"notes:read, notes:write, account:read и срок действия в будущем", http.StatusBadRequest)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
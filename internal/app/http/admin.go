@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+)
+
+// GetUsersHandler handles HTTP GET request of administrator to retrieve all users
+func (h *Handler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetUsers()
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	// Respond with list of users
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// DisableUserHandler handles HTTP POST request of administrator to disable user's account
+func (h *Handler) DisableUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор пользователя", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.DisableUser(adminID, userID)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	// Respond with disabled user
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// EnableUserHandler handles HTTP POST request of administrator to enable disabled user's account
+func (h *Handler) EnableUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор пользователя", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.EnableUser(adminID, userID)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	// Respond with enabled user
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// ResetUserPasswordHandler handles HTTP POST request of administrator to reset user's password
+// Responds with generated temporary password
+func (h *Handler) ResetUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор пользователя", http.StatusBadRequest)
+		return
+	}
+
+	password, err := h.service.ResetUserPassword(adminID, userID)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	// Respond with new password
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"password": password})
+}
+
+// DeleteUserHandler handles HTTP DELETE request of administrator to delete user with all their data
+func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор пользователя", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.DeleteUser(adminID, userID); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAdminError maps errors returned by admin service to HTTP status codes
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrUserNotFound):
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+	case errors.Is(err, api.ErrCannotManageSelf):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
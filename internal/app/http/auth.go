@@ -26,7 +26,7 @@ func (h *Handler) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	user := models.User{
 		Name:     input.Name,
-		Password: input.Name,
+		Password: input.Password,
 	}
 
 	// Attempt to create user using service
@@ -36,6 +36,10 @@ func (h *Handler) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Пользователь с этим именем уже существует", http.StatusConflict)
 			return
 		}
+		if errors.Is(err, api.ErrWeakPassword) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
 		return
@@ -47,8 +51,8 @@ func (h *Handler) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // LoginUserHandler handles user login requests
-// It parses request body to get username and password, generates token
-// and responds with token if successful
+// It parses request body to get username and password, starts new session
+// and responds with access and refresh tokens if successful
 func (h *Handler) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Name     string `json:"username"`
@@ -63,17 +67,213 @@ func (h *Handler) LoginUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	user := models.User{
 		Name:     input.Name,
-		Password: input.Name,
+		Password: input.Password,
 	}
 
-	// Attempt to generate token using service
-	token, err := h.service.Authorization.GenerateToken(user)
+	// Attempt to generate tokens using service
+	tokens, err := h.service.Authorization.GenerateToken(user, h.clientIP(r))
 	if err != nil {
+		var blocked *api.LoginBlockedError
+		if errors.As(err, &blocked) {
+			writeTooManyRequests(w, blocked.RetryAfter, "Слишком много неудачных попыток входа, повторите позже")
+			return
+		}
+		if errors.Is(err, api.ErrInvalidCredentials) {
+			http.Error(w, "Неверное имя пользователя или пароль", http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, api.ErrUserDisabled) {
+			http.Error(w, "Учетная запись отключена", http.StatusForbidden)
+			return
+		}
+
 		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
 		return
 	}
-	// Respond with created user
+	// Respond with issued tokens
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(token)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RefreshTokenHandler handles token refresh requests
+// It exchanges refresh token from request body for new pair of tokens,
+// refresh token can be used only once
+func (h *Handler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.service.Authorization.RefreshToken(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, api.ErrInvalidRefreshToken) {
+			http.Error(w, "Недействительный refresh-токен", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// LogoutHandler handles logout requests
+// It revokes session of access token used for request
+func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := r.Context().Value("SessionID").(string)
+	if !ok || sessionID == "" {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Authorization.Logout(sessionID); err != nil {
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAllHandler handles requests to log out from all devices
+// It revokes every session of authenticated user
+func (h *Handler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Authorization.LogoutAll(userID); err != nil {
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCurrentUserHandler handles HTTP GET request to retrieve account of authenticated user
+func (h *Handler) GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.service.GetCurrentUser(userID)
+	if err != nil {
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// JWKSHandler serves public keys that JWTs are verified with as JSON Web Key Set,
+// so that other services can verify tokens issued by this one
+func (h *Handler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.service.Authorization.GetJWKS())
+}
+
+// ChangePasswordHandler handles requests to change password of authenticated user
+// Current password must be given, all other sessions of user are ended
+func (h *Handler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+	sessionID, _ := r.Context().Value("SessionID").(string)
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	err := h.service.Authorization.ChangePassword(userID, sessionID, input.CurrentPassword, input.NewPassword,
+		h.clientIP(r))
+	if err != nil {
+		var blocked *api.LoginBlockedError
+		if errors.As(err, &blocked) {
+			writeTooManyRequests(w, blocked.RetryAfter, "Слишком много неудачных попыток ввода пароля, повторите позже")
+			return
+		}
+		writePasswordError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPasswordHandler handles requests to send password reset token to user
+// Response is the same whether user exists or not
+func (h *Handler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"username"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Name == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Authorization.RequestPasswordReset(input.Name); err != nil {
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPasswordHandler handles requests to set new password using password reset token
+func (h *Handler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Token == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Authorization.ResetPassword(input.Token, input.NewPassword); err != nil {
+		writePasswordError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePasswordError maps errors of password change and reset to HTTP status codes
+func writePasswordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrWeakPassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrInvalidPassword):
+		http.Error(w, "Неверный текущий пароль", http.StatusForbidden)
+	case errors.Is(err, api.ErrInvalidResetToken):
+		http.Error(w, "Недействительный или истекший токен сброса пароля", http.StatusBadRequest)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rest-notes/internal/app/models"
+)
+
+var errNoIfMatch = errors.New("не указан заголовок If-Match")
+
+// noteETag builds strong entity tag of note from its version
+func noteETag(note models.Note) string {
+	return `"` + strconv.Itoa(note.Version) + `"`
+}
+
+// parseIfMatch extracts expected note version from If-Match header
+// Wildcard "*" matches any version and is returned as zero, which makes repository skip version check
+// Note has single current version, so list of several entity tags is rejected as malformed
+func parseIfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errNoIfMatch
+	}
+	if header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, errors.New("Заголовок If-Match должен содержать одну версию заметки")
+	}
+
+	// Weak tag is compared by value
+	tag := strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || version <= 0 {
+		return 0, errors.New("Неправильный заголовок If-Match")
+	}
+	return version, nil
+}
+
+// writePreconditionError responds with 428 if If-Match header is missing and with 400 if it is malformed
+func writePreconditionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNoIfMatch) {
+		http.Error(w, "Требуется заголовок If-Match с версией заметки", http.StatusPreconditionRequired)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// writeNote responds with note in JSON and its version in ETag header
+func writeNote(w http.ResponseWriter, status int, note models.Note) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(note)
+}
+
+// writeConflict responds with 412 Precondition Failed and current representation of note
+func (h *Handler) writeConflict(w http.ResponseWriter, userID, noteID int) {
+	note, err := h.service.GetNote(userID, noteID)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+	writeNote(w, http.StatusPreconditionFailed, note)
+}
+
+// writeJSONWithETag responds with value in JSON and weak ETag computed from response body
+// If client already has the same representation according to If-None-Match, 304 Not Modified is returned
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+	"rest-notes/internal/app/repository/postgresql"
+)
+
+// versionedNoteRepo keeps single note and checks its version like PostgreSQL repository does
+// Zero version skips the check
+type versionedNoteRepo struct {
+	repository.NoteRepo
+	note models.Note
+}
+
+func (r *versionedNoteRepo) GetByID(id int) (models.Note, error) {
+	if id != r.note.ID {
+		return models.Note{}, postgresql.ErrNoteNotFound
+	}
+	return r.note, nil
+}
+
+func (r *versionedNoteRepo) Delete(id, version int) error {
+	if version != 0 && version != r.note.Version {
+		return postgresql.ErrVersionConflict
+	}
+	now := time.Now()
+	r.note.DeletedAt = &now
+	r.note.Version++
+	return nil
+}
+
+func (r *versionedNoteRepo) Move(id int, notebookID *int, version int) error {
+	if r.note.DeletedAt != nil {
+		return postgresql.ErrNoteNotFound
+	}
+	if version != 0 && version != r.note.Version {
+		return postgresql.ErrVersionConflict
+	}
+	r.note.NotebookID = notebookID
+	r.note.Version++
+	return nil
+}
+
+func (r *versionedNoteRepo) Restore(id int) error {
+	r.note.DeletedAt = nil
+	r.note.Version++
+	return nil
+}
+
+// newNoteRequest creates request to note with ID 1 made by user with ID 7
+func newNoteRequest(method, path, ifMatch, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	r = mux.SetURLVars(r, map[string]string{"id": "1"})
+	return r.WithContext(context.WithValue(r.Context(), "UserID", 7))
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{"missing header", "", http.StatusPreconditionRequired},
+		{"blank header", "  ", http.StatusPreconditionRequired},
+		{"current version", `"3"`, http.StatusNoContent},
+		{"wildcard", "*", http.StatusNoContent},
+		{"weak tag", `W/"3"`, http.StatusNoContent},
+		{"list of tags", `"3", "4"`, http.StatusBadRequest},
+		{"list with wildcard", `*, "3"`, http.StatusBadRequest},
+		{"non-numeric version", `"abc"`, http.StatusBadRequest},
+		{"zero version", `"0"`, http.StatusBadRequest},
+		{"negative version", `"-3"`, http.StatusBadRequest},
+		{"stale version", `"2"`, http.StatusPreconditionFailed},
+		{"weak stale version", `W/"2"`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &versionedNoteRepo{note: models.Note{ID: 1, UserID: 7, Title: "Заметка", Version: 3}}
+			h := New(api.Service{Note: api.NewNoteService(repo, nil, nil)}, nil, nil, false, "")
+
+			w := httptest.NewRecorder()
+			h.DeleteNoteHandler(w, newNoteRequest("DELETE", "/notes/1", tt.ifMatch, ""))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if (repo.note.DeletedAt != nil) != (tt.wantStatus == http.StatusNoContent) {
+				t.Errorf("note deleted = %v with status %d", repo.note.DeletedAt != nil, w.Code)
+			}
+
+			// Client that lost the race gets current note with its version
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				var note models.Note
+				if err := json.NewDecoder(w.Body).Decode(&note); err != nil || note.Version != 3 {
+					t.Errorf("body = %+v, %v, want current note", note, err)
+				}
+				if etag := w.Header().Get("ETag"); etag != `"3"` {
+					t.Errorf("ETag = %s, want \"3\"", etag)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveNoteIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		wantStatus  int
+		wantVersion int
+	}{
+		{"missing header", "", http.StatusPreconditionRequired, 3},
+		{"malformed header", `"3", "4"`, http.StatusBadRequest, 3},
+		{"stale version", `"2"`, http.StatusPreconditionFailed, 3},
+		{"current version", `"3"`, http.StatusOK, 4},
+		{"wildcard", "*", http.StatusOK, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &versionedNoteRepo{note: models.Note{ID: 1, UserID: 7, Title: "Заметка", Version: 3}}
+			h := New(api.Service{Note: api.NewNoteService(repo, nil, nil)}, nil, nil, false, "")
+
+			w := httptest.NewRecorder()
+			h.MoveNoteHandler(w, newNoteRequest("POST", "/notes/1/move", tt.ifMatch, `{"notebook_id": null}`))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if repo.note.Version != tt.wantVersion {
+				t.Errorf("note version = %d, want %d", repo.note.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestRestoreNoteWithoutIfMatch(t *testing.T) {
+	deletedAt := time.Now()
+	repo := &versionedNoteRepo{note: models.Note{ID: 1, UserID: 7, Title: "Заметка", Version: 4,
+		DeletedAt: &deletedAt}}
+	h := New(api.Service{Note: api.NewNoteService(repo, nil, nil)}, nil, nil, false, "")
+
+	w := httptest.NewRecorder()
+	h.RestoreNoteHandler(w, newNoteRequest("POST", "/notes/1/restore", "", ""))
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"5"` {
+		t.Errorf("status = %d, ETag = %s, want 200 with \"5\"", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestWriteJSONWithETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeJSONWithETag(w, httptest.NewRequest("GET", "/notes/list", nil), []string{"a"})
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q, want 200 with ETag", w.Code, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"same tag", etag, http.StatusNotModified},
+		{"strong form of tag", etag[2:], http.StatusNotModified},
+		{"tag in list", `"other", ` + etag, http.StatusNotModified},
+		{"wildcard", "*", http.StatusNotModified},
+		{"other tag", `W/"other"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/notes/list", nil)
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+			writeJSONWithETag(w, r, []string{"a"})
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -6,30 +6,229 @@ import (
 
 	"github.com/gorilla/mux"
 	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/metrics"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/ratelimit"
 )
 
-// Handler struct holds service used for handling requests
+// Handler struct holds service used for handling requests and rate limiters of route groups
 type Handler struct {
-	service api.Service
+	service      api.Service
+	authLimiter  ratelimit.Limiter
+	apiLimiter   ratelimit.Limiter
+	trustProxy   bool
+	metricsToken string
 }
 
 // New creates new Handler instance with provided service
-func New(service api.Service) *Handler {
-	return &Handler{service: service}
+// authLimiter limits requests to authentication routes and apiLimiter to other routes, nil limiter means no limit
+// With trustProxy client address is taken from X-Forwarded-For header set by reverse proxy
+// metricsToken is bearer token metrics are scraped with, if it is empty metrics are available to administrators only
+func New(service api.Service, authLimiter, apiLimiter ratelimit.Limiter, trustProxy bool, metricsToken string) *Handler {
+	return &Handler{service: service, authLimiter: authLimiter, apiLimiter: apiLimiter, trustProxy: trustProxy,
+		metricsToken: metricsToken}
 }
 
 // RegisterRoutes registers HTTP routes
 func (h *Handler) RegisterRoutes(r *mux.Router) {
+	if h.metricsToken != "" {
+		r.Handle("/metrics", h.RequireMetricsTokenMiddleware(metrics.Handler())).Methods("GET")
+	} else {
+		r.Handle("/metrics", h.adminOnly(metrics.Handler())).Methods("GET")
+	}
+	r.HandleFunc("/.well-known/jwks.json", h.JWKSHandler).Methods("GET")
+
 	authRouter := r.PathPrefix("/auth").Subrouter()
+	authRouter.Use(h.rateLimited("auth", h.authLimiter))
 	authRouter.HandleFunc("/register", h.RegisterUserHandler).Methods("POST")
 	authRouter.HandleFunc("/login", h.LoginUserHandler).Methods("POST")
+	authRouter.HandleFunc("/refresh", h.RefreshTokenHandler).Methods("POST")
+	authRouter.HandleFunc("/password/forgot", h.ForgotPasswordHandler).Methods("POST")
+	authRouter.HandleFunc("/password/reset", h.ResetPasswordHandler).Methods("POST")
+	authRouter.HandleFunc("/mfa/verify", h.VerifyMFAHandler).Methods("POST")
+	authRouter.HandleFunc("/oidc/{provider:[a-z][a-z0-9]*}/login", h.OIDCLoginHandler).Methods("GET")
+	authRouter.HandleFunc("/oidc/{provider:[a-z][a-z0-9]*}/callback", h.OIDCCallbackHandler).Methods("GET")
+
+	logoutRouter := http.HandlerFunc(h.LogoutHandler)
+	authRouter.Handle("/logout", h.sessionOnly(logoutRouter)).Methods("POST")
+
+	logoutAllRouter := http.HandlerFunc(h.LogoutAllHandler)
+	authRouter.Handle("/logout-all", h.sessionOnly(logoutAllRouter)).Methods("POST")
+
+	changePasswordRouter := http.HandlerFunc(h.ChangePasswordHandler)
+	authRouter.Handle("/password", h.sessionOnly(changePasswordRouter)).Methods("POST")
+
+	enrollMFARouter := http.HandlerFunc(h.EnrollMFAHandler)
+	authRouter.Handle("/mfa/enroll", h.sessionOnly(enrollMFARouter)).Methods("POST")
+
+	confirmMFARouter := http.HandlerFunc(h.ConfirmMFAHandler)
+	authRouter.Handle("/mfa/confirm", h.sessionOnly(confirmMFARouter)).Methods("POST")
+
+	disableMFARouter := http.HandlerFunc(h.DisableMFAHandler)
+	authRouter.Handle("/mfa/disable", h.sessionOnly(disableMFARouter)).Methods("POST")
+
+	currentUserRouter := http.HandlerFunc(h.GetCurrentUserHandler)
+	authRouter.Handle("/me", h.scoped(models.ScopeAccountRead, currentUserRouter)).Methods("GET")
+
+	createAccessTokenRouter := http.HandlerFunc(h.CreateAccessTokenHandler)
+	authRouter.Handle("/tokens", h.sessionOnly(createAccessTokenRouter)).Methods("POST")
+
+	getAccessTokensRouter := http.HandlerFunc(h.GetAccessTokensHandler)
+	authRouter.Handle("/tokens", h.sessionOnly(getAccessTokensRouter)).Methods("GET")
+
+	revokeAccessTokenRouter := http.HandlerFunc(h.RevokeAccessTokenHandler)
+	authRouter.Handle("/tokens/{id:[0-9]+}", h.sessionOnly(revokeAccessTokenRouter)).Methods("DELETE")
+
+	linkOIDCRouter := http.HandlerFunc(h.LinkOIDCHandler)
+	authRouter.Handle("/oidc/{provider:[a-z][a-z0-9]*}/link", h.sessionOnly(linkOIDCRouter)).Methods("POST")
+
+	unlinkOIDCRouter := http.HandlerFunc(h.UnlinkOIDCHandler)
+	authRouter.Handle("/oidc/{provider:[a-z][a-z0-9]*}/link", h.sessionOnly(unlinkOIDCRouter)).Methods("DELETE")
+
+	getIdentitiesRouter := http.HandlerFunc(h.GetIdentitiesHandler)
+	authRouter.Handle("/oidc/identities", h.sessionOnly(getIdentitiesRouter)).Methods("GET")
 
 	noteRouter := r.PathPrefix("/notes").Subrouter()
+	noteRouter.Use(h.rateLimited("api", h.apiLimiter))
 	createNoteRouter := http.HandlerFunc(h.CreateNoteHandler)
-	noteRouter.Handle("/new", h.RequireValidTokenMiddleware(createNoteRouter)).Methods("POST")
+	noteRouter.Handle("/new", h.scoped(models.ScopeNotesWrite, createNoteRouter)).Methods("POST")
 
 	getNotesRouter := http.HandlerFunc(h.GetNoteListHandler)
-	noteRouter.Handle("/list", h.RequireValidTokenMiddleware(getNotesRouter)).Methods("GET")
+	noteRouter.Handle("/list", h.scoped(models.ScopeNotesRead, getNotesRouter)).Methods("GET")
+
+	searchNotesRouter := http.HandlerFunc(h.SearchNotesHandler)
+	noteRouter.Handle("/search", h.scoped(models.ScopeNotesRead, searchNotesRouter)).Methods("GET")
+
+	getTrashRouter := http.HandlerFunc(h.GetTrashHandler)
+	noteRouter.Handle("/trash", h.scoped(models.ScopeNotesRead, getTrashRouter)).Methods("GET")
+
+	getNoteRouter := http.HandlerFunc(h.GetNoteHandler)
+	noteRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesRead, getNoteRouter)).Methods("GET")
+
+	updateNoteRouter := http.HandlerFunc(h.UpdateNoteHandler)
+	noteRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, updateNoteRouter)).Methods("PUT", "PATCH")
+
+	moveNoteRouter := http.HandlerFunc(h.MoveNoteHandler)
+	noteRouter.Handle("/{id:[0-9]+}/move", h.scoped(models.ScopeNotesWrite, moveNoteRouter)).Methods("POST")
+
+	restoreNoteRouter := http.HandlerFunc(h.RestoreNoteHandler)
+	noteRouter.Handle("/{id:[0-9]+}/restore", h.scoped(models.ScopeNotesWrite, restoreNoteRouter)).Methods("POST")
+
+	getSpellingRouter := http.HandlerFunc(h.GetNoteSpellingHandler)
+	noteRouter.Handle("/{id:[0-9]+}/spelling", h.scoped(models.ScopeNotesRead, getSpellingRouter)).Methods("GET")
+
+	getRevisionsRouter := http.HandlerFunc(h.GetNoteRevisionsHandler)
+	noteRouter.Handle("/{id:[0-9]+}/revisions", h.scoped(models.ScopeNotesRead, getRevisionsRouter)).Methods("GET")
+
+	diffRevisionsRouter := http.HandlerFunc(h.DiffNoteRevisionsHandler)
+	noteRouter.Handle("/{id:[0-9]+}/revisions/diff", h.scoped(models.ScopeNotesRead, diffRevisionsRouter)).Methods("GET")
+
+	getRevisionRouter := http.HandlerFunc(h.GetNoteRevisionHandler)
+	noteRouter.Handle("/{id:[0-9]+}/revisions/{rev:[0-9]+}", h.scoped(models.ScopeNotesRead, getRevisionRouter)).
+		Methods("GET")
+
+	restoreRevisionRouter := http.HandlerFunc(h.RestoreNoteRevisionHandler)
+	noteRouter.Handle("/{id:[0-9]+}/revisions/{rev:[0-9]+}/restore",
+		h.scoped(models.ScopeNotesWrite, restoreRevisionRouter)).Methods("POST")
+
+	deleteNoteRouter := http.HandlerFunc(h.DeleteNoteHandler)
+	noteRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, deleteNoteRouter)).Methods("DELETE")
+
+	tagRouter := r.PathPrefix("/tags").Subrouter()
+	tagRouter.Use(h.rateLimited("api", h.apiLimiter))
+	getTagsRouter := http.HandlerFunc(h.GetTagListHandler)
+	tagRouter.Handle("", h.scoped(models.ScopeNotesRead, getTagsRouter)).Methods("GET")
+
+	mergeTagsRouter := http.HandlerFunc(h.MergeTagsHandler)
+	tagRouter.Handle("/merge", h.scoped(models.ScopeNotesWrite, mergeTagsRouter)).Methods("POST")
+
+	renameTagRouter := http.HandlerFunc(h.RenameTagHandler)
+	tagRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, renameTagRouter)).Methods("PUT")
+
+	deleteTagRouter := http.HandlerFunc(h.DeleteTagHandler)
+	tagRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, deleteTagRouter)).Methods("DELETE")
+
+	notebookRouter := r.PathPrefix("/notebooks").Subrouter()
+	notebookRouter.Use(h.rateLimited("api", h.apiLimiter))
+	createNotebookRouter := http.HandlerFunc(h.CreateNotebookHandler)
+	notebookRouter.Handle("", h.scoped(models.ScopeNotesWrite, createNotebookRouter)).Methods("POST")
+
+	getNotebooksRouter := http.HandlerFunc(h.GetNotebookTreeHandler)
+	notebookRouter.Handle("", h.scoped(models.ScopeNotesRead, getNotebooksRouter)).Methods("GET")
+
+	getNotebookRouter := http.HandlerFunc(h.GetNotebookHandler)
+	notebookRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesRead, getNotebookRouter)).Methods("GET")
+
+	updateNotebookRouter := http.HandlerFunc(h.UpdateNotebookHandler)
+	notebookRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, updateNotebookRouter)).Methods("PUT")
+
+	deleteNotebookRouter := http.HandlerFunc(h.DeleteNotebookHandler)
+	notebookRouter.Handle("/{id:[0-9]+}", h.scoped(models.ScopeNotesWrite, deleteNotebookRouter)).Methods("DELETE")
+
+	getNotebookNotesRouter := http.HandlerFunc(h.GetNotebookNotesHandler)
+	notebookRouter.Handle("/{id:[0-9]+}/notes", h.scoped(models.ScopeNotesRead, getNotebookNotesRouter)).Methods("GET")
+
+	spellerRouter := r.PathPrefix("/speller").Subrouter()
+	spellerRouter.Use(h.rateLimited("api", h.apiLimiter))
+	checkSpellingRouter := http.HandlerFunc(h.CheckSpellingHandler)
+	spellerRouter.Handle("/check", h.scoped(models.ScopeNotesRead, checkSpellingRouter)).Methods("POST")
+
+	correctSpellingRouter := http.HandlerFunc(h.CorrectSpellingHandler)
+	spellerRouter.Handle("/correct", h.scoped(models.ScopeNotesRead, correctSpellingRouter)).Methods("POST")
+
+	getSpellerSettingsRouter := http.HandlerFunc(h.GetSpellerSettingsHandler)
+	spellerRouter.Handle("/settings", h.scoped(models.ScopeAccountRead, getSpellerSettingsRouter)).Methods("GET")
+
+	updateSpellerSettingsRouter := http.HandlerFunc(h.UpdateSpellerSettingsHandler)
+	spellerRouter.Handle("/settings", h.sessionOnly(updateSpellerSettingsRouter)).Methods("PUT")
+
+	getDictionaryRouter := http.HandlerFunc(h.GetDictionaryHandler)
+	spellerRouter.Handle("/dictionary", h.scoped(models.ScopeAccountRead, getDictionaryRouter)).Methods("GET")
+
+	addDictionaryWordRouter := http.HandlerFunc(h.AddDictionaryWordHandler)
+	spellerRouter.Handle("/dictionary", h.sessionOnly(addDictionaryWordRouter)).Methods("POST")
+
+	deleteDictionaryWordRouter := http.HandlerFunc(h.DeleteDictionaryWordHandler)
+	spellerRouter.Handle("/dictionary/{word}", h.sessionOnly(deleteDictionaryWordRouter)).Methods("DELETE")
+
+	adminRouter := r.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(h.rateLimited("api", h.apiLimiter))
+	getUsersRouter := http.HandlerFunc(h.GetUsersHandler)
+	adminRouter.Handle("/users", h.adminOnly(getUsersRouter)).Methods("GET")
+
+	disableUserRouter := http.HandlerFunc(h.DisableUserHandler)
+	adminRouter.Handle("/users/{id:[0-9]+}/disable", h.adminOnly(disableUserRouter)).Methods("POST")
+
+	enableUserRouter := http.HandlerFunc(h.EnableUserHandler)
+	adminRouter.Handle("/users/{id:[0-9]+}/enable", h.adminOnly(enableUserRouter)).Methods("POST")
+
+	resetPasswordRouter := http.HandlerFunc(h.ResetUserPasswordHandler)
+	adminRouter.Handle("/users/{id:[0-9]+}/reset-password", h.adminOnly(resetPasswordRouter)).Methods("POST")
+
+	deleteUserRouter := http.HandlerFunc(h.DeleteUserHandler)
+	adminRouter.Handle("/users/{id:[0-9]+}", h.adminOnly(deleteUserRouter)).Methods("DELETE")
+}
+
+// scoped protects handler with token validation and requires token to grant given scope
+func (h *Handler) scoped(scope string, handler http.Handler) http.Handler {
+	return h.RequireValidTokenMiddleware(h.RequireScopeMiddleware(scope, handler))
+}
+
+// sessionOnly protects handler with token validation and rejects personal access tokens
+func (h *Handler) sessionOnly(handler http.Handler) http.Handler {
+	return h.RequireValidTokenMiddleware(h.RequireSessionMiddleware(handler))
+}
+
+// adminOnly protects handler with token validation and allows only login sessions of administrators
+func (h *Handler) adminOnly(handler http.Handler) http.Handler {
+	return h.sessionOnly(h.RequireRoleMiddleware(models.RoleAdmin, handler))
+}
+
+// rateLimited returns middleware limiting requests to route group with limiter
+func (h *Handler) rateLimited(group string, limiter ratelimit.Limiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return h.RateLimitMiddleware(group, limiter, next)
+	}
 }
 
 // StartServer initializes and starts HTTP server on given port
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"rest-notes/internal/app/api"
+)
+
+// EnrollMFAHandler handles requests to start enrolment in TOTP two-factor authentication
+// It responds with secret, otpauth URI and base64-encoded PNG image of its QR code
+func (h *Handler) EnrollMFAHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.service.Authorization.EnrollMFA(userID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmMFAHandler handles requests to enable two-factor authentication with first code from authenticator app
+// It responds with one-time recovery codes
+func (h *Handler) ConfirmMFAHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.Authorization.ConfirmMFA(userID, input.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+// VerifyMFAHandler handles second step of login of user with two-factor authentication
+// It exchanges mfa_token returned by login and TOTP or recovery code for access and refresh tokens
+func (h *Handler) VerifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.MFAToken == "" || input.Code == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.service.Authorization.VerifyMFA(input.MFAToken, input.Code, h.clientIP(r))
+	if err != nil {
+		var blocked *api.LoginBlockedError
+		switch {
+		case errors.As(err, &blocked):
+			writeTooManyRequests(w, blocked.RetryAfter, "Слишком много неудачных попыток входа, повторите позже")
+		case errors.Is(err, api.ErrInvalidMFAToken):
+			http.Error(w, "Недействительный или истекший токен 2FA, войдите заново", http.StatusUnauthorized)
+		case errors.Is(err, api.ErrInvalidMFACode):
+			http.Error(w, "Неверный код", http.StatusUnauthorized)
+		case errors.Is(err, api.ErrUserDisabled):
+			http.Error(w, "Учетная запись отключена", http.StatusForbidden)
+		default:
+			http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// DisableMFAHandler handles requests to turn two-factor authentication off, password and code are required
+func (h *Handler) DisableMFAHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Authorization.DisableMFA(userID, input.Password, input.Code, h.clientIP(r)); err != nil {
+		var blocked *api.LoginBlockedError
+		if errors.As(err, &blocked) {
+			writeTooManyRequests(w, blocked.RetryAfter, "Слишком много неудачных попыток, повторите позже")
+			return
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeMFAError maps errors of two-factor authentication management to HTTP status codes
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrMFAAlreadyEnabled):
+		http.Error(w, "Двухфакторная аутентификация уже включена", http.StatusConflict)
+	case errors.Is(err, api.ErrMFANotEnabled), errors.Is(err, api.ErrMFANotEnrolled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrInvalidMFACode):
+		http.Error(w, "Неверный код", http.StatusForbidden)
+	case errors.Is(err, api.ErrInvalidPassword):
+		http.Error(w, "Неверный пароль", http.StatusForbidden)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
@@ -2,10 +2,23 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/metrics"
+	"rest-notes/internal/app/ratelimit"
 )
 
+var rateLimitedRequests = metrics.NewCounter("http_rate_limited_total", "Requests rejected by rate limiter")
+
 // RequestIDMiddleware adds requested endpoint to request context for further use
 // This middleware extracts endpoint from request URL and stores it in the context
 func (h *Handler) RequestIDMiddleware(next http.Handler) http.Handler {
@@ -17,9 +30,9 @@ func (h *Handler) RequestIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RequireValidTokenMiddleware validates JWT from Authorization header
-// This middleware checks if valid token is provided, extracts user ID from claims,
-// and adds user ID to request context for further use
+// RequireValidTokenMiddleware validates JWT or personal access token from Authorization header
+// This middleware checks if valid token is provided and its session is not revoked,
+// extracts user ID, session ID, role and token scopes from claims, and adds them to request context for further use
 func (h *Handler) RequireValidTokenMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -37,6 +50,10 @@ func (h *Handler) RequireValidTokenMiddleware(next http.Handler) http.Handler {
 		// Check if token is valid
 		authenticated, claims, err := h.service.IsTokenValid(tokenString)
 		if err != nil {
+			if errors.Is(err, api.ErrInvalidToken) {
+				http.Error(w, "Пользователь не авторизован", http.StatusUnauthorized)
+				return
+			}
 			http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
 			return
 		}
@@ -53,8 +70,131 @@ func (h *Handler) RequireValidTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		sessionID, _ := claims["sid"].(string)
+		role, _ := claims["role"].(string)
+
 		ctx = context.WithValue(ctx, "UserID", int(userID))
+		ctx = context.WithValue(ctx, "SessionID", sessionID)
+		ctx = context.WithValue(ctx, "Role", role)
+
+		// Personal access tokens are limited to their scopes, session tokens grant everything
+		if scope, ok := claims["scope"].(string); ok {
+			ctx = context.WithValue(ctx, "Scopes", strings.Fields(scope))
+		}
 		// If token is valid pass request further
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireScopeMiddleware checks that token used for request grants given scope
+// This middleware must be applied after RequireValidTokenMiddleware
+func (h *Handler) RequireScopeMiddleware(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes, ok := r.Context().Value("Scopes").([]string)
+		if ok && !containsScope(scopes, scope) {
+			http.Error(w, "Недостаточно прав токена, требуется "+scope, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRoleMiddleware allows request only when authenticated user has given role
+// Personal access tokens carry no role, so they never pass this check
+// This middleware must be applied after RequireValidTokenMiddleware
+func (h *Handler) RequireRoleMiddleware(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userRole, _ := r.Context().Value("Role").(string)
+		if userRole != role {
+			http.Error(w, "Недостаточно прав", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSessionMiddleware allows request only when it is authenticated with login session token
+// This middleware must be applied after RequireValidTokenMiddleware
+func (h *Handler) RequireSessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID, ok := r.Context().Value("SessionID").(string)
+		if !ok || sessionID == "" {
+			http.Error(w, "Действие недоступно для токена доступа", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireMetricsTokenMiddleware allows request only when it carries configured metrics token as bearer token
+func (h *Handler) RequireMetricsTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.metricsToken)) != 1 {
+			http.Error(w, "Пользователь не авторизован", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimitMiddleware limits rate of requests from each client address to route group with limiter
+// Rejected requests get 429 response with Retry-After header; if limiter fails, requests are let through
+func (h *Handler) RateLimitMiddleware(group string, limiter ratelimit.Limiter, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := h.clientIP(r)
+		allowed, retryAfter, err := limiter.Allow(group + ":" + ip)
+		if err != nil {
+			log.Printf("Ошибка ограничителя частоты запросов: %v", err)
+		} else if !allowed {
+			rateLimitedRequests.Inc()
+			log.Printf("Превышена частота запросов: группа %s, IP %s, %s %s", group, ip, r.Method, r.URL.Path)
+			writeTooManyRequests(w, retryAfter, "Слишком много запросов, повторите позже")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns address of client that sent request
+// Behind trusted reverse proxy it is the first address in X-Forwarded-For header
+func (h *Handler) clientIP(r *http.Request) string {
+	if h.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			client, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(client)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeTooManyRequests writes 429 response telling client to retry after given time, rounded up to seconds
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+// containsScope reports whether scope is in list of granted scopes
+func containsScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -2,16 +2,21 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/gorilla/mux"
 	"rest-notes/internal/app/api"
 	"rest-notes/internal/app/models"
 )
 
 // CreateNoteHandler handles HTTP POST request to create new note
 // It parses request body, validates data, and calls service to create note
+// Optional spell_mode overrides user's spell check mode for this request,
+// autocorrect=true query parameter replaces mistakes with first suggestions before note is saved
 func (h *Handler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("UserID").(int)
 	if !ok {
@@ -20,9 +25,13 @@ func (h *Handler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		DueDate     string `json:"due_date"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Lang        string   `json:"lang"`
+		DueDate     string   `json:"due_date"`
+		Tags        []string `json:"tags"`
+		NotebookID  *int     `json:"notebook_id"`
+		SpellMode   string   `json:"spell_mode"`
 	}
 
 	// Decode request body into input struct
@@ -31,11 +40,15 @@ func (h *Handler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse due date from string to time.Time format
-	dueDate, err := time.Parse(time.RFC3339, input.DueDate)
-	if err != nil {
-		http.Error(w, "Неправильный формат даты", http.StatusBadRequest)
-		return
+	// Parse due date from string to time.Time format, note without due date is allowed
+	var dueDate *time.Time
+	if input.DueDate != "" {
+		parsed, err := time.Parse(time.RFC3339, input.DueDate)
+		if err != nil {
+			http.Error(w, "Неправильный формат даты", http.StatusBadRequest)
+			return
+		}
+		dueDate = &parsed
 	}
 
 	// Create new note object
@@ -45,28 +58,33 @@ func (h *Handler) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
 		//
 		Title:       input.Title,
 		Description: input.Description,
+		Lang:        input.Lang,
 		DueDate:     dueDate,
+		Tags:        input.Tags,
+		NotebookID:  input.NotebookID,
+	}
+
+	// Spell check options of this request
+	spell := models.SpellOptions{
+		Mode:        input.SpellMode,
+		Autocorrect: r.URL.Query().Get("autocorrect") == "true",
 	}
 
 	// Call service to create note
-	createdNote, err := h.service.CreateNote(note)
+	createdNote, err := h.service.CreateNote(note, spell)
 	if err != nil {
-		if strings.Contains(err.Error(), api.ErrSpell.Error()) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		writeNoteError(w, err)
 		return
 	}
 
 	// Respond with created note
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdNote)
+	writeNote(w, http.StatusCreated, createdNote)
 }
 
-// GetNoteListHandler handles HTTP GET request to retrieve all notes
-// It calls service to get list of notes and returns them in response
+// GetNoteListHandler handles HTTP GET request to retrieve page of notes
+// It parses pagination, sorting and filtering query parameters, calls service to get list of notes
+// and returns them in envelope with cursor of the next page;
+// 304 Not Modified is returned if page did not change since version in If-None-Match
 func (h *Handler) GetNoteListHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("UserID").(int)
 	if !ok {
@@ -74,17 +92,444 @@ func (h *Handler) GetNoteListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	notes, err := h.service.GetNoteList(userID)
+	filter, err := parseNoteFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	notes, err := h.service.GetNoteList(userID, filter)
+	if err != nil {
+		writeNoteError(w, err)
 		return
 	}
 
 	// Respond with list of notes
+	writeJSONWithETag(w, r, notes)
+}
+
+// SearchNotesHandler handles HTTP GET request for full-text search over notes
+// Search query is taken from q parameter, optional limit restricts number of results
+func (h *Handler) SearchNotesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var limit int
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "Неправильное значение limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.service.SearchNotes(userID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with found notes
 	w.Header().Set("Content-Type", "application/json")
-	if len(notes) == 0 {
-		json.NewEncoder(w).Encode("Список заметок пуст")
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetNoteHandler handles HTTP GET request to retrieve single note by ID
+func (h *Handler) GetNoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.service.GetNote(userID, noteID)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with note
+	writeNote(w, http.StatusOK, note)
+}
+
+// GetNoteSpellingHandler handles HTTP GET request to retrieve spell check status and mistakes of note
+// It is used to get results of background check of note saved in async spell mode
+func (h *Handler) GetNoteSpellingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	spelling, err := h.service.GetNoteSpelling(userID, noteID)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with spell check results
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spelling)
+}
+
+// UpdateNoteHandler handles HTTP PUT and PATCH requests to update note
+// PUT replaces all fields of note, PATCH changes only fields present in request body
+// Request must carry note version in If-Match header, on mismatch current note is returned with 412
+func (h *Handler) UpdateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	var input struct {
+		Title       *string         `json:"title"`
+		Description *string         `json:"description"`
+		Lang        *string         `json:"lang"`
+		DueDate     json.RawMessage `json:"due_date"`
+		Tags        []string        `json:"tags"`
+		NotebookID  optionalID      `json:"notebook_id"`
+		SpellMode   string          `json:"spell_mode"`
+	}
+
+	// Decode request body into input struct
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	note := models.Note{ID: noteID, UserID: userID}
+
+	// PATCH starts from current state of note, PUT requires full representation
+	if r.Method == http.MethodPatch {
+		note, err = h.service.GetNote(userID, noteID)
+		if err != nil {
+			writeNoteError(w, err)
+			return
+		}
+	} else if input.Title == nil {
+		http.Error(w, "Не указаны обязательные поля", http.StatusBadRequest)
+		return
+	}
+
+	if input.Title != nil {
+		note.Title = *input.Title
+	}
+	if input.Description != nil {
+		note.Description = *input.Description
+	}
+
+	// PUT without language switches note to automatic language detection
+	if input.Lang != nil {
+		note.Lang = *input.Lang
+	} else if r.Method == http.MethodPut {
+		note.Lang = ""
+	}
+
+	// Wildcard If-Match keeps version PATCH has read, so concurrent change is still detected
+	if version != 0 {
+		note.Version = version
+	}
+
+	// PUT without notebook moves note out of notebook
+	if input.NotebookID.Set || r.Method == http.MethodPut {
+		note.NotebookID = input.NotebookID.Value
+	}
+
+	// PUT without tags removes all tags of note
+	if input.Tags != nil {
+		note.Tags = input.Tags
+	} else if r.Method == http.MethodPut {
+		note.Tags = []string{}
+	}
+
+	// Due date set to null removes it, absent due date is removed only by PUT
+	if input.DueDate != nil || r.Method == http.MethodPut {
+		note.DueDate, err = parseDueDate(input.DueDate)
+		if err != nil {
+			http.Error(w, "Неправильный формат даты", http.StatusBadRequest)
+			return
+		}
+	}
+
+	updatedNote, err := h.service.UpdateNote(note, models.SpellOptions{Mode: input.SpellMode})
+	if err != nil {
+		if errors.Is(err, api.ErrVersionConflict) {
+			h.writeConflict(w, userID, noteID)
+			return
+		}
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with updated note
+	writeNote(w, http.StatusOK, updatedNote)
+}
+
+// MoveNoteHandler handles HTTP POST request to move note into another notebook
+// Null notebook_id moves note out of any notebook
+// Request must carry note version in If-Match header, on mismatch current note is returned with 412
+func (h *Handler) MoveNoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	var input struct {
+		NotebookID *int `json:"notebook_id"`
+	}
+
+	// Decode request body into input struct
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
 		return
 	}
+
+	note, err := h.service.MoveNote(userID, noteID, input.NotebookID, version)
+	if err != nil {
+		if errors.Is(err, api.ErrVersionConflict) {
+			h.writeConflict(w, userID, noteID)
+			return
+		}
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with moved note
+	writeNote(w, http.StatusOK, note)
+}
+
+// DeleteNoteHandler handles HTTP DELETE request to delete note by ID
+// Note is moved to trash unless permanent=true is passed in query
+// Request must carry note version in If-Match header, on mismatch current note is returned with 412
+func (h *Handler) DeleteNoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	var permanent bool
+	if value := r.URL.Query().Get("permanent"); value != "" {
+		if permanent, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Неправильное значение permanent", http.StatusBadRequest)
+			return
+		}
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	if err = h.service.DeleteNote(userID, noteID, version, permanent); err != nil {
+		if errors.Is(err, api.ErrVersionConflict) {
+			h.writeConflict(w, userID, noteID)
+			return
+		}
+		writeNoteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreNoteHandler handles HTTP POST request to restore note from trash
+// Unlike other changes of note it does not require If-Match header
+func (h *Handler) RestoreNoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.service.RestoreNote(userID, noteID)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with restored note
+	writeNote(w, http.StatusOK, note)
+}
+
+// GetTrashHandler handles HTTP GET request to retrieve notes in trash
+func (h *Handler) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notes, err := h.service.GetTrash(userID)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with notes in trash
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(notes)
 }
+
+// optionalID is nullable ID in JSON body that distinguishes absent field from explicit null
+type optionalID struct {
+	Set   bool
+	Value *int
+}
+
+// UnmarshalJSON marks field as present and decodes its value
+func (o *optionalID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// parseDueDate parses due date from raw JSON value, null or missing value means note has no due date
+func parseDueDate(raw json.RawMessage) (*time.Time, error) {
+	var value *string
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+	}
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	dueDate, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &dueDate, nil
+}
+
+// parseNoteFilter builds note list filter from query parameters
+// Supported parameters: limit, cursor, sort, order, due_before, due_after, overdue, has_due_date,
+// tag (may be repeated) and tag_mode
+func parseNoteFilter(query url.Values) (models.NoteFilter, error) {
+	filter := models.NoteFilter{
+		Cursor:  query.Get("cursor"),
+		Sort:    query.Get("sort"),
+		Order:   query.Get("order"),
+		Tags:    query["tag"],
+		TagMode: query.Get("tag_mode"),
+	}
+
+	var err error
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			return models.NoteFilter{}, errors.New("Неправильное значение limit")
+		}
+	}
+
+	if value := query.Get("due_before"); value != "" {
+		dueBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return models.NoteFilter{}, errors.New("Неправильный формат даты due_before")
+		}
+		filter.DueBefore = &dueBefore
+	}
+
+	if value := query.Get("due_after"); value != "" {
+		dueAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return models.NoteFilter{}, errors.New("Неправильный формат даты due_after")
+		}
+		filter.DueAfter = &dueAfter
+	}
+
+	if value := query.Get("overdue"); value != "" {
+		if filter.Overdue, err = strconv.ParseBool(value); err != nil {
+			return models.NoteFilter{}, errors.New("Неправильное значение overdue")
+		}
+	}
+
+	if value := query.Get("has_due_date"); value != "" {
+		hasDueDate, err := strconv.ParseBool(value)
+		if err != nil {
+			return models.NoteFilter{}, errors.New("Неправильное значение has_due_date")
+		}
+		filter.HasDueDate = &hasDueDate
+	}
+
+	return filter, nil
+}
+
+// parseNoteID extracts note ID from URL path variables
+func parseNoteID(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["id"])
+}
+
+// writeNoteError maps errors returned by note service to HTTP status codes
+func writeNoteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrSpell), errors.Is(err, api.ErrInvalidList), errors.Is(err, api.ErrEmptyQuery),
+		errors.Is(err, api.ErrInvalidTag), errors.Is(err, api.ErrInvalidSpellMode), errors.Is(err, api.ErrInvalidLang):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrNoteNotFound):
+		http.Error(w, "Заметка не найдена", http.StatusNotFound)
+	case errors.Is(err, api.ErrForbidden):
+		http.Error(w, "Нет доступа к заметке", http.StatusForbidden)
+	case errors.Is(err, api.ErrNotebookNotFound):
+		http.Error(w, "Блокнот не найден", http.StatusNotFound)
+	case errors.Is(err, api.ErrNoteNotInTrash):
+		http.Error(w, "Заметка не находится в корзине", http.StatusConflict)
+	case errors.Is(err, api.ErrSpellerUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
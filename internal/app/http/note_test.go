@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository"
+)
+
+// emptyNoteRepo has no notes
+type emptyNoteRepo struct {
+	repository.NoteRepo
+}
+
+func (emptyNoteRepo) GetAll(userID int, filter models.NoteFilter) ([]models.Note, error) {
+	return nil, nil
+}
+
+func TestGetNoteListHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"no parameters", "", http.StatusOK},
+		{"sort and order", "?sort=due_date&order=desc&limit=100", http.StatusOK},
+		{"limit above maximum is capped", "?limit=1000", http.StatusOK},
+		{"zero limit", "?limit=0", http.StatusBadRequest},
+		{"negative limit", "?limit=-1", http.StatusBadRequest},
+		{"non-numeric limit", "?limit=ten", http.StatusBadRequest},
+		{"unknown sort field", "?sort=priority", http.StatusBadRequest},
+		{"unknown order", "?sort=title&order=up", http.StatusBadRequest},
+		{"invalid cursor", "?cursor=not-a-cursor", http.StatusBadRequest},
+		{"tampered cursor", "?cursor=eyJzIjoidGl0bGUiLCJvIjoiYXNjIiwidiI6IkEiLCJpZCI6MX0x", http.StatusBadRequest},
+	}
+
+	h := New(api.Service{Note: api.NewNoteService(emptyNoteRepo{}, nil, nil)}, nil, nil, false, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/notes/list"+tt.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), "UserID", 7))
+			w := httptest.NewRecorder()
+			h.GetNoteListHandler(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
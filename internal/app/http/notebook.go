@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/models"
+)
+
+// CreateNotebookHandler handles HTTP POST request to create new notebook
+func (h *Handler) CreateNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Name     string `json:"name"`
+		ParentID *int   `json:"parent_id"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	notebook := models.Notebook{
+		UserID:   userID,
+		ParentID: input.ParentID,
+		Name:     input.Name,
+	}
+
+	createdNotebook, err := h.service.CreateNotebook(notebook)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	// Respond with created notebook
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdNotebook)
+}
+
+// GetNotebookTreeHandler handles HTTP GET request to retrieve all user's notebooks as tree
+func (h *Handler) GetNotebookTreeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notebooks, err := h.service.GetNotebookTree(userID)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	// Respond with tree of notebooks
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notebooks)
+}
+
+// GetNotebookHandler handles HTTP GET request to retrieve single notebook
+func (h *Handler) GetNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notebookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор блокнота", http.StatusBadRequest)
+		return
+	}
+
+	notebook, err := h.service.GetNotebook(userID, notebookID)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	// Respond with notebook
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notebook)
+}
+
+// UpdateNotebookHandler handles HTTP PUT request to rename notebook or move it under another parent
+// Null or absent parent_id makes notebook top-level
+func (h *Handler) UpdateNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notebookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор блокнота", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Name     string `json:"name"`
+		ParentID *int   `json:"parent_id"`
+	}
+
+	// Decode request body into input struct
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	notebook := models.Notebook{
+		ID:       notebookID,
+		UserID:   userID,
+		ParentID: input.ParentID,
+		Name:     input.Name,
+	}
+
+	updatedNotebook, err := h.service.UpdateNotebook(notebook)
+	if err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	// Respond with updated notebook
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedNotebook)
+}
+
+// DeleteNotebookHandler handles HTTP DELETE request to delete notebook
+// Mode query parameter is either cascade or move-to-parent (default)
+func (h *Handler) DeleteNotebookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notebookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор блокнота", http.StatusBadRequest)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = models.NotebookDeleteMoveToParent
+	}
+
+	if err = h.service.DeleteNotebook(userID, notebookID, mode); err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetNotebookNotesHandler handles HTTP GET request to retrieve notes of notebook
+// It accepts the same pagination, sorting and filtering parameters as note list
+func (h *Handler) GetNotebookNotesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	notebookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор блокнота", http.StatusBadRequest)
+		return
+	}
+
+	if _, err = h.service.GetNotebook(userID, notebookID); err != nil {
+		writeNotebookError(w, err)
+		return
+	}
+
+	filter, err := parseNoteFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.NotebookID = &notebookID
+
+	notes, err := h.service.GetNoteList(userID, filter)
+	if err != nil {
+		writeNoteError(w, err)
+		return
+	}
+
+	// Respond with list of notes
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notes)
+}
+
+// writeNotebookError maps errors returned by notebook service to HTTP status codes
+func writeNotebookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrInvalidNotebook), errors.Is(err, api.ErrInvalidDeleteMode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrNotebookCycle):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, api.ErrNotebookNotFound):
+		http.Error(w, "Блокнот не найден", http.StatusNotFound)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+)
+
+// oidcStateCookie is name of cookie that binds OpenID Connect login to browser it was started in
+const oidcStateCookie = "oidc_state"
+
+// OIDCLoginHandler handles requests to log in with OpenID Connect provider
+// It redirects browser to provider's authorization endpoint, state of login is kept in cookie
+func (h *Handler) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	authorization, err := h.service.OIDC.StartOIDCLogin(provider, 0)
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    authorization.State,
+		Path:     "/auth/oidc/" + provider,
+		HttpOnly: true,
+		Secure:   h.isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authorization.URL, http.StatusFound)
+}
+
+// OIDCCallbackHandler handles redirect back from OpenID Connect provider with authorization code
+// Login responds with access and refresh tokens (or mfa_token), linking responds with linked account
+func (h *Handler) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+
+	if providerError := query.Get("error"); providerError != "" {
+		http.Error(w, "Провайдер отклонил вход: "+providerError, http.StatusUnauthorized)
+		return
+	}
+	if query.Get("code") == "" || query.Get("state") == "" {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	browserState := ""
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+
+	// State is used only once, so cookie is not needed anymore
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/auth/oidc/" + provider,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	result, err := h.service.OIDC.CompleteOIDCLogin(provider, query.Get("state"), browserState, query.Get("code"))
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Linked {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(result.Identity)
+		return
+	}
+	json.NewEncoder(w).Encode(result.Tokens)
+}
+
+// LinkOIDCHandler handles requests of authenticated user to link account at OpenID Connect provider
+// It responds with URL of provider's authorization endpoint to be opened in browser
+func (h *Handler) LinkOIDCHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	authorization, err := h.service.OIDC.StartOIDCLogin(mux.Vars(r)["provider"], userID)
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(authorization)
+}
+
+// UnlinkOIDCHandler handles requests to unlink account at OpenID Connect provider from authenticated user
+func (h *Handler) UnlinkOIDCHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.OIDC.UnlinkIdentity(userID, mux.Vars(r)["provider"]); err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetIdentitiesHandler handles HTTP GET request to list accounts at OpenID Connect providers linked to user
+func (h *Handler) GetIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.service.OIDC.GetIdentities(userID)
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// isHTTPS reports whether request came over HTTPS, directly or through trusted reverse proxy
+func (h *Handler) isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || (h.trustProxy && r.Header.Get("X-Forwarded-Proto") == "https")
+}
+
+// writeOIDCError maps errors of login with OpenID Connect providers to HTTP status codes
+func writeOIDCError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrUnknownOIDCProvider):
+		http.Error(w, "Неизвестный провайдер", http.StatusNotFound)
+	case errors.Is(err, api.ErrInvalidOIDCState):
+		http.Error(w, "Недействительная или истекшая попытка входа, начните вход заново", http.StatusBadRequest)
+	case errors.Is(err, api.ErrOIDCLoginFailed):
+		http.Error(w, "Не удалось войти через провайдера", http.StatusUnauthorized)
+	case errors.Is(err, api.ErrOIDCUnavailable):
+		http.Error(w, "Провайдер недоступен", http.StatusBadGateway)
+	case errors.Is(err, api.ErrIdentityLinked):
+		http.Error(w, "Учетная запись провайдера уже привязана", http.StatusConflict)
+	case errors.Is(err, api.ErrIdentityNotFound):
+		http.Error(w, "Учетная запись провайдера не привязана", http.StatusNotFound)
+	case errors.Is(err, api.ErrUserDisabled):
+		http.Error(w, "Учетная запись отключена", http.StatusForbidden)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
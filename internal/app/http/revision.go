@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+)
+
+// GetNoteRevisionsHandler handles HTTP GET request to retrieve history of note
+func (h *Handler) GetNoteRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.GetNoteRevisions(userID, noteID)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	// Respond with list of revisions
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetNoteRevisionHandler handles HTTP GET request to retrieve single revision of note
+func (h *Handler) GetNoteRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := strconv.Atoi(mux.Vars(r)["rev"])
+	if err != nil {
+		http.Error(w, "Неправильный номер ревизии", http.StatusBadRequest)
+		return
+	}
+
+	rev, err := h.service.GetNoteRevision(userID, noteID, revision)
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	// Respond with revision
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rev)
+}
+
+// DiffNoteRevisionsHandler handles HTTP GET request to compare two revisions of note
+// Revisions are passed in from and to query parameters, mode is line (default) or word
+func (h *Handler) DiffNoteRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil {
+		http.Error(w, "Неправильный номер ревизии from", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil {
+		http.Error(w, "Неправильный номер ревизии to", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.service.DiffNoteRevisions(userID, noteID, from, to, query.Get("mode"))
+	if err != nil {
+		writeRevisionError(w, err)
+		return
+	}
+
+	// Respond with diff
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// RestoreNoteRevisionHandler handles HTTP POST request to roll note back to given revision
+// Optional If-Match header protects against overwriting concurrent change
+func (h *Handler) RestoreNoteRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	noteID, err := parseNoteID(r)
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор заметки", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := strconv.Atoi(mux.Vars(r)["rev"])
+	if err != nil {
+		http.Error(w, "Неправильный номер ревизии", http.StatusBadRequest)
+		return
+	}
+
+	var version int
+	if r.Header.Get("If-Match") != "" {
+		if version, err = parseIfMatch(r); err != nil {
+			writePreconditionError(w, err)
+			return
+		}
+	}
+
+	note, err := h.service.RestoreNoteRevision(userID, noteID, revision, version)
+	if err != nil {
+		if errors.Is(err, api.ErrVersionConflict) {
+			h.writeConflict(w, userID, noteID)
+			return
+		}
+		writeRevisionError(w, err)
+		return
+	}
+
+	// Respond with restored note
+	writeNote(w, http.StatusOK, note)
+}
+
+// writeRevisionError maps errors returned by revision operations to HTTP status codes
+func writeRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrInvalidDiffMode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrRevisionNotFound):
+		http.Error(w, "Ревизия заметки не найдена", http.StatusNotFound)
+	default:
+		writeNoteError(w, err)
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+	"rest-notes/internal/app/models"
+)
+
+// GetSpellerSettingsHandler handles HTTP GET request to retrieve user's spell check settings
+func (h *Handler) GetSpellerSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	settings, err := h.service.GetSpellerSettings(userID)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSpellerSettingsHandler handles HTTP PUT request to change user's spell check settings
+func (h *Handler) UpdateSpellerSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input models.SpellerSettings
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.service.UpdateSpellerSettings(userID, input)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// AddDictionaryWordHandler handles HTTP POST request to add word to user's dictionary
+func (h *Handler) AddDictionaryWordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Word string `json:"word"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	word, err := h.service.AddDictionaryWord(userID, input.Word)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(word)
+}
+
+// GetDictionaryHandler handles HTTP GET request to list words of user's dictionary
+func (h *Handler) GetDictionaryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	words, err := h.service.GetDictionary(userID)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(words)
+}
+
+// DeleteDictionaryWordHandler handles HTTP DELETE request to remove word from user's dictionary
+func (h *Handler) DeleteDictionaryWordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteDictionaryWord(userID, mux.Vars(r)["word"]); err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CheckSpellingHandler handles HTTP POST request to check spelling of arbitrary text
+// Request body holds text and optional language, response is list of found mistakes
+func (h *Handler) CheckSpellingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Text string `json:"text"`
+		Lang string `json:"lang"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	issues, err := h.service.CheckSpelling(userID, input.Text, input.Lang)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		SpellingIssues []models.SpellingIssue `json:"spelling_issues"`
+	}{SpellingIssues: issues})
+}
+
+// CorrectSpellingHandler handles HTTP POST request to correct spelling of arbitrary text
+// Response holds text with mistakes replaced by first suggestions and list of applied replacements
+func (h *Handler) CorrectSpellingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		Text string `json:"text"`
+		Lang string `json:"lang"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	corrected, err := h.service.CorrectSpelling(userID, input.Text, input.Lang)
+	if err != nil {
+		writeSpellerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(corrected)
+}
+
+// writeSpellerError maps service errors of speller operations to HTTP responses
+func writeSpellerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrInvalidSpellMode), errors.Is(err, api.ErrInvalidWord), errors.Is(err, api.ErrEmptyText),
+		errors.Is(err, api.ErrInvalidLang):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrWordAlreadyExists):
+		http.Error(w, "Слово уже есть в словаре", http.StatusConflict)
+	case errors.Is(err, api.ErrWordNotFound):
+		http.Error(w, "Слово не найдено в словаре", http.StatusNotFound)
+	case errors.Is(err, api.ErrSpellerUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"rest-notes/internal/app/api"
+)
+
+// GetTagListHandler handles HTTP GET request to retrieve user's tags with note counts
+func (h *Handler) GetTagListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := h.service.GetTagList(userID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	// Respond with list of tags
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// RenameTagHandler handles HTTP PUT request to rename tag
+func (h *Handler) RenameTagHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	tagID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор тега", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Name string `json:"name"`
+	}
+
+	// Decode request body into input struct
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.RenameTag(userID, tagID, input.Name)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	// Respond with renamed tag
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// MergeTagsHandler handles HTTP POST request to merge several tags into one
+// Notes of source tags get target tag, source tags are deleted
+func (h *Handler) MergeTagsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		SourceIDs []int `json:"source_ids"`
+		TargetID  int   `json:"target_id"`
+	}
+
+	// Decode request body into input struct
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Неправильный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.MergeTags(userID, input.SourceIDs, input.TargetID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	// Respond with resulting tag
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// DeleteTagHandler handles HTTP DELETE request to delete tag
+func (h *Handler) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("UserID").(int)
+	if !ok {
+		http.Error(w, "Ошибка аутентификации", http.StatusUnauthorized)
+		return
+	}
+
+	tagID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Неправильный идентификатор тега", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.DeleteTag(userID, tagID); err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTagError maps errors returned by tag service to HTTP status codes
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrInvalidTag):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, api.ErrTagNotFound):
+		http.Error(w, "Тег не найден", http.StatusNotFound)
+	case errors.Is(err, api.ErrTagAlreadyExists):
+		http.Error(w, "Тег с таким именем уже существует, используйте объединение тегов", http.StatusConflict)
+	default:
+		http.Error(w, "Проблема на сервере", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+// Package mail delivers messages to users, e.g. password reset tokens
+// Local implementations write messages to log or to file instead of sending them
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is single message addressed to user
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to users
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// LogMailer writes messages to application log, it is meant for local development
+type LogMailer struct{}
+
+// NewLogMailer creates new LogMailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send writes message to log
+func (lm *LogMailer) Send(msg Message) error {
+	log.Printf("Письмо для %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer appends messages to file, like local mailbox
+type FileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileMailer creates new FileMailer writing to file at path, file is created if it does not exist
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send appends message to file
+func (fm *FileMailer) Send(msg Message) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	file, err := os.OpenFile(fm.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
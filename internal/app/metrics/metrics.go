@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is monotonically increasing metric
+type Counter struct {
+	value int64
+}
+
+// Inc increases counter by one
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.value, 1)
+}
+
+// Value returns current value of counter
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+// metric is registered metric with its description and function reading its value
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func() float64
+}
+
+var (
+	mu      sync.Mutex
+	metrics = make(map[string]metric)
+)
+
+// NewCounter creates counter and registers it under given name
+func NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	register(metric{name: name, help: help, kind: "counter", value: func() float64 {
+		return float64(c.Value())
+	}})
+	return c
+}
+
+// NewGaugeFunc registers gauge whose value is read by calling f
+// Registering gauge with existing name replaces previous one
+func NewGaugeFunc(name, help string, f func() float64) {
+	register(metric{name: name, help: help, kind: "gauge", value: f})
+}
+
+// register adds metric to registry
+func register(m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+	metrics[m.name] = m
+}
+
+// Handler returns HTTP handler exposing all registered metrics in Prometheus text format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		registered := make([]metric, 0, len(metrics))
+		for _, m := range metrics {
+			registered = append(registered, m)
+		}
+		mu.Unlock()
+
+		sort.Slice(registered, func(i, j int) bool {
+			return registered[i].name < registered[j].name
+		})
+
+		var b strings.Builder
+		for _, m := range registered {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", m.name, m.help, m.name, m.kind, m.name, m.value())
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(b.String()))
+	})
+}
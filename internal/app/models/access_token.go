@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PersonalAccessToken is long-lived token for scripts and integrations limited to set of scopes
+// Token itself is returned only once on creation, only its hash is stored
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// Access token scopes
+const (
+	ScopeNotesRead   = "notes:read"
+	ScopeNotesWrite  = "notes:write"
+	ScopeAccountRead = "account:read"
+)
+
+// Scopes lists all scopes that can be granted to personal access token
+var Scopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeAccountRead}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// UserIdentity links user to account at external OpenID Connect provider, account is identified by its subject
+type UserIdentity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"-"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCLoginState is stored state of OpenID Connect login started by redirect to provider
+// UserID is set when authenticated user links provider account to their own account instead of logging in
+type OIDCLoginState struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserID       *int
+	ExpiresAt    time.Time
+}
+
+// OIDCAuthorization is started OpenID Connect login: URL of provider's authorization endpoint and state bound to it
+type OIDCAuthorization struct {
+	URL   string `json:"authorization_url"`
+	State string `json:"-"`
+}
+
+// OIDCLoginResult is result of callback from OpenID Connect provider
+// Login gets Tokens, linking of provider account gets Identity with Linked set
+type OIDCLoginResult struct {
+	Tokens   Tokens
+	Identity UserIdentity
+	Linked   bool
+}
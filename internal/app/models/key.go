@@ -0,0 +1,19 @@
+package models
+
+// JWK is public key in JSON Web Key format (RFC 7517)
+// RSA keys have modulus N and exponent E, Ed25519 keys have curve Crv and public key X
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is JSON Web Key Set of keys that tokens can be verified with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
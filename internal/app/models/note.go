@@ -3,11 +3,76 @@ package models
 import "time"
 
 type Note struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	DueDate     time.Time `json:"due_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	NotebookID  *int       `json:"notebook_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Lang        string     `json:"lang"`
+	DueDate     *time.Time `json:"due_date"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int        `json:"version"`
+
+	// SpellStatus tells whether note text has been spell checked, see GET /notes/{id}/spelling for results
+	// SpellingIssues are mistakes found in note when it is saved in warn spell mode,
+	// Corrections are replacements applied when note is created with autocorrect
+	SpellStatus    string               `json:"spell_status,omitempty"`
+	SpellingIssues []SpellingIssue      `json:"spelling_issues,omitempty"`
+	Corrections    []SpellingCorrection `json:"corrections,omitempty"`
+}
+
+// Fields notes can be sorted by
+const (
+	NoteSortDueDate   = "due_date"
+	NoteSortCreatedAt = "created_at"
+	NoteSortUpdatedAt = "updated_at"
+	NoteSortTitle     = "title"
+)
+
+// Sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// NoteCursor points to last note of previous page in keyset pagination
+// Value holds sort field value of that note in textual form
+type NoteCursor struct {
+	Value string
+	ID    int
+}
+
+// NoteFilter holds pagination, sorting and filtering parameters for note list
+// Cursor is opaque value received from client, After is its decoded form passed to repository
+type NoteFilter struct {
+	Limit      int
+	Cursor     string
+	After      *NoteCursor
+	Sort       string
+	Order      string
+	DueBefore  *time.Time
+	DueAfter   *time.Time
+	Overdue    bool
+	HasDueDate *bool
+	Tags       []string
+	TagMode    string
+	NotebookID *int
+}
+
+// NoteList is single page of notes with cursor pointing to the next page
+type NoteList struct {
+	Items      []Note `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NoteSearchResult is note found by full-text search with its rank and highlighted fragments
+// TitleHighlight and Snippet are escaped HTML where only matched words are wrapped in <b> tags
+type NoteSearchResult struct {
+	Note
+	Rank           float32 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Notebook struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"-"`
+	ParentID  *int       `json:"parent_id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Children  []Notebook `json:"children,omitempty"`
+}
+
+// Notebook delete modes
+// Cascade deletes notebook with all nested notebooks and their notes,
+// MoveToParent moves nested notebooks and notes one level up before deleting notebook
+const (
+	NotebookDeleteCascade      = "cascade"
+	NotebookDeleteMoveToParent = "move-to-parent"
+)
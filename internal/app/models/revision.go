@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+type NoteRevision struct {
+	NoteID      int        `json:"note_id"`
+	Revision    int        `json:"revision"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date"`
+	AuthorID    *int       `json:"author_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Diff granularity modes
+const (
+	DiffModeLine = "line"
+	DiffModeWord = "word"
+)
+
+// Diff operation types
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffOp is single fragment of text diff
+type DiffOp struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// NoteDiff describes changes between two revisions of note
+type NoteDiff struct {
+	From        int        `json:"from"`
+	To          int        `json:"to"`
+	Mode        string     `json:"mode"`
+	Title       []DiffOp   `json:"title"`
+	Description []DiffOp   `json:"description"`
+	DueDateFrom *time.Time `json:"due_date_from"`
+	DueDateTo   *time.Time `json:"due_date_to"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Session is single login of user, all access and refresh tokens issued after login belong to it
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshToken is stored refresh token, only hash of token itself is kept
+type RefreshToken struct {
+	ID        int
+	SessionID string
+	UserID    int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+
+	// State of session the token belongs to
+	SessionExpiresAt time.Time
+	SessionRevokedAt *time.Time
+}
+
+// Tokens is pair of tokens issued to client on login and refresh
+// If user has two-factor authentication enabled, login returns only MFAToken of type "mfa_pending"
+// that has to be exchanged for pair of tokens with code
+type Tokens struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// PasswordResetToken is stored single-use token for resetting forgotten password, only hash of token itself is kept
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// SpellingIssue is single spelling mistake found in checked text
+// Pos, Len and Col are measured in characters, Row and Col are zero-based;
+// Field tells which field of note mistake is in
+type SpellingIssue struct {
+	Field       string   `json:"field,omitempty"`
+	Word        string   `json:"word"`
+	Pos         int      `json:"pos"`
+	Len         int      `json:"len"`
+	Row         int      `json:"row"`
+	Col         int      `json:"col"`
+	Suggestions []string `json:"suggestions"`
+	Code        int      `json:"code"`
+}
+
+// Spelling issue codes, same as in Yandex Speller API
+const (
+	SpellingUnknownWord    = 1
+	SpellingRepeatWord     = 2
+	SpellingCapitalization = 3
+	SpellingTooManyErrors  = 4
+)
+
+// Speller options bitmask values, same as in Yandex Speller API
+const (
+	SpellerIgnoreDigits         = 2
+	SpellerIgnoreURLs           = 4
+	SpellerFindRepeatWords      = 8
+	SpellerIgnoreCapitalization = 512
+)
+
+// Languages supported by speller
+const (
+	LangRussian   = "ru"
+	LangEnglish   = "en"
+	LangUkrainian = "uk"
+)
+
+// Spell check modes: reject refuses to save text with mistakes, warn saves it and reports mistakes, off skips check,
+// async saves text immediately and checks it in background
+const (
+	SpellModeReject = "reject"
+	SpellModeWarn   = "warn"
+	SpellModeOff    = "off"
+	SpellModeAsync  = "async"
+)
+
+// Spell check statuses of note, empty status means that note text has not been checked
+const (
+	SpellStatusPending = "pending"
+	SpellStatusChecked = "checked"
+	SpellStatusFailed  = "failed"
+)
+
+// NoteSpelling is result of spell checking note, Error is last error of background check if it failed
+type NoteSpelling struct {
+	NoteID    int             `json:"note_id"`
+	Status    string          `json:"spell_status"`
+	Issues    []SpellingIssue `json:"spelling_issues"`
+	CheckedAt *time.Time      `json:"checked_at"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// SpellJob is background spell check of note claimed by worker
+// Generation changes every time note is queued again, so results of outdated check are discarded
+type SpellJob struct {
+	ID          int
+	NoteID      int
+	UserID      int
+	Title       string
+	Description string
+	Lang        string
+	Attempts    int
+	Generation  int
+}
+
+// SpellerSettings holds user's spell check preferences
+type SpellerSettings struct {
+	SpellMode            string `json:"spell_mode"`
+	IgnoreDigits         bool   `json:"ignore_digits"`
+	IgnoreURLs           bool   `json:"ignore_urls"`
+	FindRepeatWords      bool   `json:"find_repeat_words"`
+	IgnoreCapitalization bool   `json:"ignore_capitalization"`
+}
+
+// SpellOptions holds spell check options of single request, empty Mode means mode from user's settings
+// Autocorrect replaces mistakes with first suggestions before text is checked
+type SpellOptions struct {
+	Mode        string
+	Autocorrect bool
+}
+
+// SpellingCorrection is replacement of misspelled word applied to text, Pos is position in original text
+type SpellingCorrection struct {
+	Field       string `json:"field,omitempty"`
+	Word        string `json:"word"`
+	Replacement string `json:"replacement"`
+	Pos         int    `json:"pos"`
+}
+
+// CorrectedText is text with spelling mistakes replaced by first suggestions
+type CorrectedText struct {
+	Text        string               `json:"text"`
+	Corrections []SpellingCorrection `json:"corrections"`
+}
+
+// DictionaryWord is word from user's custom dictionary that speller must not report as mistake
+type DictionaryWord struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	Word      string    `json:"word"`
+	CreatedAt time.Time `json:"created_at"`
+}
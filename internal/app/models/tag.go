@@ -0,0 +1,14 @@
+package models
+
+type Tag struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"-"`
+	Name      string `json:"name"`
+	NoteCount int    `json:"note_count"`
+}
+
+// Tag filter modes for note list
+const (
+	TagModeAnd = "and"
+	TagModeOr  = "or"
+)
@@ -3,8 +3,31 @@ package models
 import "time"
 
 type User struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"username"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	Name       string     `json:"username"`
+	Password   string     `json:"-"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	MFAEnabled bool       `json:"mfa_enabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// User roles: admin can manage accounts of other users
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// TOTP is state of user's TOTP two-factor authentication
+// Secret is set on enrolment, EnabledAt once enrolment is confirmed with first code
+type TOTP struct {
+	Secret    string
+	EnabledAt *time.Time
+}
+
+// MFAEnrollment is returned to user starting TOTP enrolment, QRCode is PNG image of URI
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode []byte `json:"qr_code_png"`
 }
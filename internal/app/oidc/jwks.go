@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// jwk is public key in JSON Web Key format (RFC 7517), only RSA and EC signing keys are used
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwkSet is JSON Web Key Set published at provider's jwks_uri
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// publicKeys returns signing keys of set by key ID, keys of unsupported types are skipped
+func (s jwkSet) publicKeys() map[string]interface{} {
+	keys := make(map[string]interface{}, len(s.Keys))
+	for _, key := range s.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if publicKey := key.publicKey(); publicKey != nil {
+			keys[key.Kid] = publicKey
+		}
+	}
+	return keys
+}
+
+// publicKey decodes RSA or EC public key, returns nil if key is malformed or of unsupported type
+func (k jwk) publicKey() interface{} {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	default:
+		return nil
+	}
+}
+
+// decodeBigInt decodes base64url-encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,317 @@
+// Package oidc implements relying party side of OpenID Connect authorization code flow with PKCE
+// It discovers provider endpoints, exchanges authorization code for ID token and verifies ID token
+// with provider's published keys
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	ErrTokenExchange  = errors.New("authorization code exchange failed")
+)
+
+const (
+	// keysRefreshInterval is minimum interval between fetches of provider keys caused by unknown kid
+	keysRefreshInterval = time.Minute
+
+	// maxResponseSize limits size of responses read from provider
+	maxResponseSize = 1 << 20
+)
+
+// Config holds registration of client at OpenID Connect provider
+// ClientSecret may be empty for public client, it then relies on PKCE only
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+}
+
+// Claims are claims of verified ID token that identify user
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// discovery is part of provider metadata (OpenID Connect Discovery 1.0) used by client
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is client of single OpenID Connect provider
+// Metadata is discovered on first use and keys are fetched again when token is signed with unknown key
+type Provider struct {
+	name   string
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *discovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates client of provider with given name and registration, requests are made with client
+func NewProvider(name string, config Config, client *http.Client) *Provider {
+	return &Provider{name: name, config: config, client: client}
+}
+
+// Name returns name of provider used in routes and configuration
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns URL of provider's authorization endpoint that user agent is redirected to
+// state and nonce are bound to login attempt, codeChallenge is S256 PKCE challenge of code verifier
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange exchanges authorization code and PKCE code verifier for ID token at provider's token endpoint
+// Returns raw ID token that must be checked with VerifyIDToken
+func (p *Provider) Exchange(code, codeVerifier string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic requires form-encoding of credentials (RFC 6749, section 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("%w: status %d: %v", ErrTokenExchange, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("%w: status %d: %s %s", ErrTokenExchange, resp.StatusCode, token.Error,
+			token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("%w: no id_token in response", ErrTokenExchange)
+	}
+	return token.IDToken, nil
+}
+
+// VerifyIDToken verifies signature of ID token with provider's keys and checks its claims:
+// issuer, audience (and authorized party if there are several audiences), expiration and nonce
+func (p *Provider) VerifyIDToken(rawIDToken, nonce string) (Claims, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	parser := jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}}
+	token, err := parser.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(kid)
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, ErrInvalidIDToken
+	}
+	if iss, _ := claims["iss"].(string); iss != metadata.Issuer {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, iss)
+	}
+	if !p.validAudience(claims) {
+		return Claims{}, fmt.Errorf("%w: token is not issued to client", ErrInvalidIDToken)
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return Claims{}, fmt.Errorf("%w: no expiration time", ErrInvalidIDToken)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return Claims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	result := Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+	result.PreferredUsername, _ = claims["preferred_username"].(string)
+	result.Name, _ = claims["name"].(string)
+	if result.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	return result, nil
+}
+
+// validAudience checks that client is among audiences of token, aud may be string or array
+// Token with several audiences must name client as authorized party
+func (p *Provider) validAudience(claims jwt.MapClaims) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == p.config.ClientID
+	case []interface{}:
+		found := false
+		for _, value := range aud {
+			if value == p.config.ClientID {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+		if len(aud) > 1 {
+			azp, _ := claims["azp"].(string)
+			return azp == p.config.ClientID
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// discover fetches provider metadata from issuer's well-known URL, successful result is cached
+func (p *Provider) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata discovery
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("discovery of %s: %w", p.config.Issuer, err)
+	}
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovery of %s: issuer mismatch %q", p.config.Issuer, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery of %s: incomplete provider metadata", p.config.Issuer)
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// key returns provider's public key with given ID
+// Keys are fetched again if there is no such key, so that rotation of provider keys is picked up
+func (p *Provider) key(kid string) (interface{}, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	var set jwkSet
+	if err = p.getJSON(metadata.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	p.keys = set.publicKeys()
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// getJSON fetches JSON document from URL
+func (p *Provider) getJSON(location string, v interface{}) error {
+	resp, err := p.client.Get(location)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// NewCodeVerifier returns random PKCE code verifier and its S256 code challenge (RFC 7636)
+func NewCodeVerifier() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+	return verifier, CodeChallenge(verifier), nil
+}
+
+// CodeChallenge returns S256 code challenge of PKCE code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,230 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"rest-notes/internal/app/oidctest"
+)
+
+const testClientID = "rest-notes"
+
+// newTestProvider starts mock provider with client having clientSecret and returns provider and client of it
+func newTestProvider(t *testing.T, clientSecret string) (*oidctest.Issuer, *Provider) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(nil)
+	issuer, err := oidctest.New("http://"+server.Listener.Addr().String(), testClientID, clientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Config.Handler = issuer
+	server.Start()
+	t.Cleanup(server.Close)
+
+	provider := NewProvider("mock", Config{
+		Issuer:       server.URL,
+		ClientID:     testClientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{"openid", "profile", "email"},
+		RedirectURL:  "http://app.example.com/auth/oidc/mock/callback",
+	}, server.Client())
+	return issuer, provider
+}
+
+// authorize goes through authorization endpoint in place of user agent and returns authorization code
+func authorize(t *testing.T, provider *Provider, nonce, codeChallenge string) string {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL("state", nonce, codeChallenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Query().Get("state") != "state" {
+		t.Fatalf("provider redirected to %s without state", location)
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		t.Fatalf("provider redirected to %s without code", location)
+	}
+	return code
+}
+
+func TestProviderLogin(t *testing.T) {
+	tests := []struct {
+		name         string
+		clientSecret string
+	}{
+		{"confidential client", "secret"},
+		{"public client", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, provider := newTestProvider(t, tt.clientSecret)
+			verifier, challenge, err := NewCodeVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rawIDToken, err := provider.Exchange(authorize(t, provider, "nonce", challenge), verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := provider.VerifyIDToken(rawIDToken, "nonce")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := Claims{Subject: oidctest.DefaultUser, Email: oidctest.DefaultUser + "@example.com",
+				EmailVerified: true, PreferredUsername: oidctest.DefaultUser, Name: oidctest.DefaultUser}
+			if claims != want {
+				t.Errorf("claims = %+v, want %+v", claims, want)
+			}
+		})
+	}
+}
+
+func TestProviderExchangeRejected(t *testing.T) {
+	_, provider := newTestProvider(t, "secret")
+	verifier, challenge, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Code intercepted without code verifier is useless
+	otherVerifier, _, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorize(t, provider, "nonce", challenge)
+	if _, err = provider.Exchange(code, otherVerifier); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("Exchange with wrong code verifier error = %v, want ErrTokenExchange", err)
+	}
+
+	// Code is valid only once, even after failed exchange
+	if _, err = provider.Exchange(code, verifier); !errors.Is(err, ErrTokenExchange) {
+		t.Errorf("Exchange with used code error = %v, want ErrTokenExchange", err)
+	}
+
+	// Client with wrong secret is rejected
+	provider.config.ClientSecret = "wrong"
+	if _, err = provider.Exchange(authorize(t, provider, "nonce", challenge), verifier); !errors.Is(err,
+		ErrTokenExchange) {
+		t.Errorf("Exchange with wrong client secret error = %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+		nonce     string
+		wantErr   bool
+	}{
+		{"valid token", nil, "nonce", false},
+		{"nonce mismatch", nil, "other-nonce", true},
+		{"no nonce", map[string]interface{}{"nonce": nil}, "nonce", true},
+		{"wrong issuer", map[string]interface{}{"iss": "http://evil.example.com"}, "nonce", true},
+		{"no issuer", map[string]interface{}{"iss": nil}, "nonce", true},
+		{"wrong audience", map[string]interface{}{"aud": "other-client"}, "nonce", true},
+		{"no audience", map[string]interface{}{"aud": nil}, "nonce", true},
+		{"audience list with client", map[string]interface{}{"aud": []string{testClientID}}, "nonce", false},
+		{"audience list without client", map[string]interface{}{"aud": []string{"other-client"}}, "nonce", true},
+		{"several audiences without azp", map[string]interface{}{"aud": []string{testClientID, "other-client"}},
+			"nonce", true},
+		{"several audiences with wrong azp", map[string]interface{}{"aud": []string{testClientID, "other-client"},
+			"azp": "other-client"}, "nonce", true},
+		{"several audiences with azp", map[string]interface{}{"aud": []string{testClientID, "other-client"},
+			"azp": testClientID}, "nonce", false},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}, "nonce", true},
+		{"no expiration", map[string]interface{}{"exp": nil}, "nonce", true},
+		{"no subject", map[string]interface{}{"sub": nil}, "nonce", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, provider := newTestProvider(t, "secret")
+			issuer.OverrideClaims(tt.overrides)
+			verifier, challenge, err := NewCodeVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rawIDToken, err := provider.Exchange(authorize(t, provider, "nonce", challenge), verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = provider.VerifyIDToken(rawIDToken, tt.nonce)
+			if tt.wantErr && !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("VerifyIDToken error = %v, want ErrInvalidIDToken", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifyIDToken error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenSignature(t *testing.T) {
+	_, provider := newTestProvider(t, "secret")
+	verifier, challenge, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawIDToken, err := provider.Exchange(authorize(t, provider, "nonce", challenge), verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same claims signed with key of attacker under provider's key ID
+	parts := strings.Split(rawIDToken, ".")
+	token, _, err := new(jwt.Parser).ParseUnverified(rawIDToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := jwt.SigningMethodRS256.Sign(parts[0]+"."+parts[1], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := parts[0] + "." + parts[1] + "." + signature
+	if _, err = provider.VerifyIDToken(forged, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken of forged token error = %v, want ErrInvalidIDToken", err)
+	}
+
+	// Unsigned token is rejected
+	token.Method = jwt.SigningMethodNone
+	token.Header["alg"] = "none"
+	unsigned, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = provider.VerifyIDToken(unsigned, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken of unsigned token error = %v, want ErrInvalidIDToken", err)
+	}
+}
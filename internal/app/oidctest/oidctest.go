@@ -0,0 +1,309 @@
+// Package oidctest provides mock OpenID Connect provider for development and tests
+// It implements discovery, authorization, token and JWKS endpoints of authorization code flow with PKCE
+// Authorization endpoint does not ask for credentials: it logs in user named by login_hint parameter
+// (or default user) and immediately redirects back with authorization code
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// DefaultUser is login of user who is logged in when authorization request has no login_hint
+	DefaultUser = "user"
+
+	keyID    = "oidctest"
+	codeTTL  = time.Minute
+	tokenTTL = 5 * time.Minute
+)
+
+// grant is authorization code issued by authorization endpoint
+type grant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	login         string
+	expiresAt     time.Time
+}
+
+// Issuer is mock OpenID Connect provider with single registered client
+// ID tokens are signed with RS256 key generated on creation
+type Issuer struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu        sync.Mutex
+	codes     map[string]grant
+	overrides map[string]interface{}
+}
+
+// New creates mock provider with given issuer URL that serves one client
+// Empty clientSecret registers public client, which is authenticated only by PKCE
+func New(issuer, clientID, clientSecret string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]grant),
+	}, nil
+}
+
+// NewServer starts HTTP server of mock provider for given client
+// Its URL is issuer URL to configure application with, server must be closed by caller
+func NewServer(clientID, clientSecret string) (*httptest.Server, error) {
+	server := httptest.NewUnstartedServer(nil)
+	issuer, err := New("http://"+server.Listener.Addr().String(), clientID, clientSecret)
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+	server.Config.Handler = issuer
+	server.Start()
+	return server, nil
+}
+
+// OverrideClaims replaces claims of ID tokens issued from now on with given values, nil value removes claim
+// It lets tests check that client rejects tokens with wrong issuer, audience, nonce and so on
+func (i *Issuer) OverrideClaims(overrides map[string]interface{}) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.overrides = overrides
+}
+
+// ServeHTTP routes requests to endpoints of provider
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		i.discovery(w, r)
+	case "/authorize":
+		i.authorize(w, r)
+	case "/token":
+		i.token(w, r)
+	case "/jwks":
+		i.jwks(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// discovery serves provider metadata
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.issuer,
+		"authorization_endpoint":                i.issuer + "/authorize",
+		"token_endpoint":                        i.issuer + "/token",
+		"jwks_uri":                              i.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+// authorize logs user in and redirects back to client with authorization code and state
+// Errors in client_id or redirect_uri are shown to user agent, other errors are returned to client
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if query.Get("client_id") != i.clientID || err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "unknown client or invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := url.Values{}
+	params.Set("state", query.Get("state"))
+
+	switch {
+	case query.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+		params.Set("error_description", "S256 code challenge is required")
+	case !strings.Contains(" "+query.Get("scope")+" ", " openid "):
+		params.Set("error", "invalid_scope")
+	default:
+		login := query.Get("login_hint")
+		if login == "" {
+			login = DefaultUser
+		}
+
+		code := randomString()
+		i.mu.Lock()
+		i.codes[code] = grant{
+			clientID:      i.clientID,
+			redirectURI:   redirectURI.String(),
+			codeChallenge: query.Get("code_challenge"),
+			nonce:         query.Get("nonce"),
+			login:         login,
+			expiresAt:     time.Now().Add(codeTTL),
+		}
+		i.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	values := redirectURI.Query()
+	for name := range params {
+		values.Set(name, params.Get(name))
+	}
+	redirectURI.RawQuery = values.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges authorization code for ID token after checking client credentials and PKCE code verifier
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != i.clientID || clientSecret != i.clientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// Code is valid only once
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	g, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(g.expiresAt) || g.clientID != clientID:
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	case r.PostForm.Get("redirect_uri") != g.redirectURI:
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	case codeChallenge(r.PostForm.Get("code_verifier")) != g.codeChallenge:
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	idToken, err := i.idToken(g)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// jwks serves public key ID tokens are signed with
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	publicKey := i.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// idToken issues signed ID token for user of grant, subject is user's login
+func (i *Issuer) idToken(g grant) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                i.issuer,
+		"sub":                g.login,
+		"aud":                g.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(tokenTTL).Unix(),
+		"email":              g.login + "@example.com",
+		"email_verified":     true,
+		"preferred_username": g.login,
+		"name":               g.login,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+
+	i.mu.Lock()
+	for name, value := range i.overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(i.key)
+}
+
+// codeChallenge returns S256 code challenge of PKCE code verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns random URL-safe string used as authorization code and access token
+func randomString() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// writeError writes OAuth 2.0 error response
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// writeJSON writes value as JSON response with given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
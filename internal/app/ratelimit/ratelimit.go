@@ -0,0 +1,131 @@
+// Package ratelimit limits request rate per key with token bucket algorithm
+// Bucket holds up to burst tokens and is refilled with perMinute tokens every minute, every request takes one token
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have been refilled to full are dropped
+const sweepInterval = time.Minute
+
+// Limiter decides whether request identified by key is allowed
+// When request is not allowed, it returns time after which next request can be allowed
+type Limiter interface {
+	Allow(key string) (bool, time.Duration, error)
+}
+
+// Store keeps token buckets shared by several instances of application
+type Store interface {
+	Take(key string, rate float64, burst int) (bool, time.Duration, error)
+	DeleteIdle(before time.Time) error
+}
+
+// bucket is state of single token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryLimiter keeps token buckets in memory of single instance
+type MemoryLimiter struct {
+	rate      float64
+	burst     int
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter creates limiter allowing perMinute requests per minute and bursts of up to burst requests per key
+func NewMemoryLimiter(perMinute, burst int) *MemoryLimiter {
+	return &MemoryLimiter{
+		rate:      float64(perMinute) / 60,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes token from bucket of key
+func (ml *MemoryLimiter) Allow(key string) (bool, time.Duration, error) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(ml.lastSweep) >= sweepInterval {
+		ml.sweep(now)
+	}
+
+	b, ok := ml.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(ml.burst), updated: now}
+		ml.buckets[key] = b
+	}
+
+	allowed, retryAfter := take(b, now, ml.rate, ml.burst)
+	return allowed, retryAfter, nil
+}
+
+// sweep drops buckets that are full by now, they are no different from new ones
+func (ml *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range ml.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*ml.rate >= float64(ml.burst) {
+			delete(ml.buckets, key)
+		}
+	}
+	ml.lastSweep = now
+}
+
+// StoreLimiter keeps token buckets in store shared by all instances of application
+type StoreLimiter struct {
+	store     Store
+	rate      float64
+	burst     int
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewStoreLimiter creates limiter with buckets kept in store,
+// it allows perMinute requests per minute and bursts of up to burst requests per key
+func NewStoreLimiter(store Store, perMinute, burst int) *StoreLimiter {
+	return &StoreLimiter{store: store, rate: float64(perMinute) / 60, burst: burst, lastSweep: time.Now()}
+}
+
+// Allow takes token from bucket of key
+// Once in a while buckets that have been idle long enough to refill completely are deleted from store
+func (sl *StoreLimiter) Allow(key string) (bool, time.Duration, error) {
+	sl.mu.Lock()
+	sweep := time.Since(sl.lastSweep) >= sweepInterval
+	if sweep {
+		sl.lastSweep = time.Now()
+	}
+	sl.mu.Unlock()
+
+	if sweep {
+		fillTime := time.Duration(float64(sl.burst) / sl.rate * float64(time.Second))
+		if err := sl.store.DeleteIdle(time.Now().Add(-fillTime)); err != nil {
+			return false, 0, err
+		}
+	}
+
+	return sl.store.Take(key, sl.rate, sl.burst)
+}
+
+// Take refills bucket with given state for time passed since its last update and takes one token from it if possible
+// Returns new number of tokens, whether token was taken and, if it was not, time until bucket has token again
+// Negative elapsed time, when bucket was updated after time was read, is treated as zero
+func Take(tokens float64, elapsed time.Duration, rate float64, burst int) (float64, bool, time.Duration) {
+	tokens = min(float64(burst), tokens+max(0, elapsed.Seconds())*rate)
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	return tokens, false, time.Duration((1 - tokens) / rate * float64(time.Second))
+}
+
+// take updates in-memory bucket with Take
+func take(b *bucket, now time.Time, rate float64, burst int) (bool, time.Duration) {
+	tokens, allowed, retryAfter := Take(b.tokens, now.Sub(b.updated), rate, burst)
+	b.tokens, b.updated = tokens, now
+	return allowed, retryAfter
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	// One token per second, up to three tokens
+	const rate, burst = 1.0, 3
+
+	tests := []struct {
+		name           string
+		tokens         float64
+		elapsed        time.Duration
+		wantTokens     float64
+		wantAllowed    bool
+		wantRetryAfter time.Duration
+	}{
+		{"full bucket", 3, 0, 2, true, 0},
+		{"last token", 1, 0, 0, true, 0},
+		{"empty bucket", 0, 0, 0, false, time.Second},
+		{"partly refilled bucket", 0.25, 0, 0.25, false, 750 * time.Millisecond},
+		{"refill makes token", 0, 1500 * time.Millisecond, 0.5, true, 0},
+		{"refill up to burst", 1, time.Hour, 2, true, 0},
+		{"refill without token", 0, 500 * time.Millisecond, 0.5, false, 500 * time.Millisecond},
+		{"negative elapsed time drains nothing", 1, -10 * time.Second, 0, true, 0},
+		{"negative elapsed time keeps retry after", 0.5, -10 * time.Second, 0.5, false, 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, allowed, retryAfter := Take(tt.tokens, tt.elapsed, rate, burst)
+			if tokens != tt.wantTokens || allowed != tt.wantAllowed || retryAfter != tt.wantRetryAfter {
+				t.Errorf("Take = %v, %v, %v, want %v, %v, %v", tokens, allowed, retryAfter,
+					tt.wantTokens, tt.wantAllowed, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterBurst(t *testing.T) {
+	limiter := NewMemoryLimiter(60, 3)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := limiter.Allow("ip:10.0.0.1"); !allowed {
+			t.Fatalf("request %d of burst was not allowed", i+1)
+		}
+	}
+	allowed, retryAfter, _ := limiter.Allow("ip:10.0.0.1")
+	if allowed || retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("request over burst = %v, %v, want rejected with retry after up to second", allowed, retryAfter)
+	}
+
+	// Buckets of keys are independent
+	if allowed, _, _ = limiter.Allow("ip:10.0.0.2"); !allowed {
+		t.Error("request with other key was not allowed")
+	}
+}
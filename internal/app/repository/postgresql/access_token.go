@@ -0,0 +1,125 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var ErrAccessTokenNotFound = errors.New("access token not found")
+
+// accessTokenColumns is list of columns selected for personal access token
+const accessTokenColumns = `id, user_id, name, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+// AccessTokenPostgres is repository implementation for managing personal access tokens in PostgreSQL database
+type AccessTokenPostgres struct {
+	db database.Database
+}
+
+// NewAccessTokenPostgres creates new AccessTokenPostgres instance with given database connection
+func NewAccessTokenPostgres(db database.Database) *AccessTokenPostgres {
+	return &AccessTokenPostgres{db: db}
+}
+
+// Create inserts new personal access token with given token hash
+// Returns created token with ID and creation time filled in
+func (ap *AccessTokenPostgres) Create(token models.PersonalAccessToken, tokenHash string) (models.PersonalAccessToken, error) {
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, created_at, expires_at)
+	          VALUES ($1, $2, $3, $4, NOW(), $5) RETURNING id, created_at`
+
+	ctx := context.Background()
+
+	err := ap.db.GetPool().QueryRow(ctx, query, token.UserID, token.Name, tokenHash, token.Scopes, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+
+	return token, nil
+}
+
+// GetAll retrieves all not revoked personal access tokens of user, newest first
+func (ap *AccessTokenPostgres) GetAll(userID int) ([]models.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens
+	          WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC, id DESC`
+
+	ctx := context.Background()
+
+	rows, err := ap.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]models.PersonalAccessToken, 0)
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// GetByHash retrieves personal access token by its hash
+// Returns ErrAccessTokenNotFound if there is no such token or its owner is disabled
+func (ap *AccessTokenPostgres) GetByHash(tokenHash string) (models.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens
+	          WHERE token_hash = $1 AND user_id IN (SELECT id FROM users WHERE disabled_at IS NULL)`
+
+	ctx := context.Background()
+
+	token, err := scanAccessToken(ap.db.GetPool().QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PersonalAccessToken{}, ErrAccessTokenNotFound
+		}
+		return models.PersonalAccessToken{}, err
+	}
+
+	return token, nil
+}
+
+// MarkUsed records that token has just been used
+// Time of last use is updated at most once a minute to avoid write on every request
+func (ap *AccessTokenPostgres) MarkUsed(id int) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = NOW()
+	          WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	ctx := context.Background()
+
+	_, err := ap.db.GetPool().Exec(ctx, query, id)
+	return err
+}
+
+// Revoke marks personal access token of user as revoked
+// Returns ErrAccessTokenNotFound if user has no such active token
+func (ap *AccessTokenPostgres) Revoke(id, userID int) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW()
+	          WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	ctx := context.Background()
+
+	tag, err := ap.db.GetPool().Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// scanAccessToken scans personal access token row selected with accessTokenColumns
+func scanAccessToken(row pgx.Row) (models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Scopes, &token.CreatedAt,
+		&token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt)
+	return token, err
+}
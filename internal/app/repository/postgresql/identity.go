@@ -0,0 +1,192 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var (
+	ErrIdentityNotFound   = errors.New("user identity not found")
+	ErrIdentityExists     = errors.New("user identity already exists")
+	ErrLoginStateNotFound = errors.New("oidc login state not found")
+)
+
+// identityColumns is list of columns selected for user identity
+const identityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+// IdentityPostgres is repository implementation for managing external identities of users
+// and states of OpenID Connect logins in PostgreSQL database
+type IdentityPostgres struct {
+	db database.Database
+}
+
+// NewIdentityPostgres creates new IdentityPostgres instance with given database connection
+func NewIdentityPostgres(db database.Database) *IdentityPostgres {
+	return &IdentityPostgres{db: db}
+}
+
+// CreateLoginState stores state of started OpenID Connect login by hash of state parameter
+// Expired states of abandoned logins are deleted at the same time
+func (ip *IdentityPostgres) CreateLoginState(state models.OIDCLoginState, stateHash string) error {
+	ctx := context.Background()
+
+	_, err := ip.db.GetPool().Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, user_id, created_at, expires_at)
+	          VALUES ($1, $2, $3, $4, $5, NOW(), $6)`
+	_, err = ip.db.GetPool().Exec(ctx, query, stateHash, state.Provider, state.Nonce, state.CodeVerifier,
+		state.UserID, state.ExpiresAt)
+	return err
+}
+
+// TakeLoginState retrieves and deletes state of OpenID Connect login, so that each state is used only once
+// Returns ErrLoginStateNotFound if there is no such state
+func (ip *IdentityPostgres) TakeLoginState(stateHash string) (models.OIDCLoginState, error) {
+	query := `DELETE FROM oidc_login_states WHERE state_hash = $1
+	          RETURNING provider, nonce, code_verifier, user_id, expires_at`
+	var state models.OIDCLoginState
+	ctx := context.Background()
+
+	err := ip.db.GetPool().QueryRow(ctx, query, stateHash).Scan(&state.Provider, &state.Nonce, &state.CodeVerifier,
+		&state.UserID, &state.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.OIDCLoginState{}, ErrLoginStateNotFound
+		}
+		return models.OIDCLoginState{}, err
+	}
+	return state, nil
+}
+
+// GetByProviderSubject retrieves identity by provider and subject of account at provider
+// Returns ErrIdentityNotFound if account is not linked to any user
+func (ip *IdentityPostgres) GetByProviderSubject(provider, subject string) (models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE provider = $1 AND subject = $2`
+	ctx := context.Background()
+
+	identity, err := scanIdentity(ip.db.GetPool().QueryRow(ctx, query, provider, subject))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.UserIdentity{}, ErrIdentityNotFound
+		}
+		return models.UserIdentity{}, err
+	}
+	return identity, nil
+}
+
+// GetAll retrieves all identities of user ordered by provider
+func (ip *IdentityPostgres) GetAll(userID int) ([]models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = $1 ORDER BY provider`
+	ctx := context.Background()
+
+	rows, err := ip.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := make([]models.UserIdentity, 0)
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// Create links account at provider to existing user and returns created identity
+// Returns ErrIdentityExists if account is already linked or user already has account at this provider
+func (ip *IdentityPostgres) Create(identity models.UserIdentity) (models.UserIdentity, error) {
+	ctx := context.Background()
+
+	err := insertIdentity(ctx, ip.db.GetPool(), &identity)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+	return identity, nil
+}
+
+// CreateUser creates new user with linked account at provider in single transaction
+// Returns created identity, its UserID is ID of new user
+func (ip *IdentityPostgres) CreateUser(user models.User, identity models.UserIdentity) (models.UserIdentity, error) {
+	ctx := context.Background()
+
+	tx, err := ip.db.GetPool().Begin(ctx)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `INSERT INTO users (username, password, role, created_at)
+	                        VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'user'), NOW()) RETURNING id`,
+		user.Name, user.Password, user.Role).Scan(&identity.UserID)
+	if err != nil {
+		return models.UserIdentity{}, err
+	}
+
+	if err = insertIdentity(ctx, tx, &identity); err != nil {
+		return models.UserIdentity{}, err
+	}
+
+	return identity, tx.Commit(ctx)
+}
+
+// MarkUsed records that user has just logged in with identity
+func (ip *IdentityPostgres) MarkUsed(id int) error {
+	ctx := context.Background()
+
+	_, err := ip.db.GetPool().Exec(ctx, `UPDATE user_identities SET last_login_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// Delete unlinks account at provider from user
+// Returns ErrIdentityNotFound if user has no account at this provider
+func (ip *IdentityPostgres) Delete(userID int, provider string) error {
+	ctx := context.Background()
+
+	tag, err := ip.db.GetPool().Exec(ctx, `DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`,
+		userID, provider)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+// insertIdentity inserts identity and fills in its ID and creation time
+func insertIdentity(ctx context.Context, q rowQuerier, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email, created_at, last_login_at)
+	          VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created_at, last_login_at`
+
+	err := q.QueryRow(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email).
+		Scan(&identity.ID, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return ErrIdentityExists
+		}
+		return err
+	}
+	return nil
+}
+
+// scanIdentity scans user identity row selected with identityColumns
+func scanIdentity(row pgx.Row) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt)
+	return identity, err
+}
@@ -0,0 +1,142 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var ErrMFAEnabled = errors.New("two-factor authentication already enabled")
+
+// MFAPostgres is repository implementation for TOTP two-factor authentication and recovery codes
+// in PostgreSQL database
+type MFAPostgres struct {
+	db database.Database
+}
+
+// NewMFAPostgres creates new MFAPostgres instance with given database connection
+func NewMFAPostgres(db database.Database) *MFAPostgres {
+	return &MFAPostgres{db: db}
+}
+
+// GetTOTP retrieves TOTP state of user
+// Returns ErrNotFound if there is no user with such ID
+func (mp *MFAPostgres) GetTOTP(userID int) (models.TOTP, error) {
+	query := `SELECT COALESCE(totp_secret, ''), totp_enabled_at FROM users WHERE id = $1`
+	var totp models.TOTP
+	ctx := context.Background()
+
+	err := mp.db.GetPool().QueryRow(ctx, query, userID).Scan(&totp.Secret, &totp.EnabledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, ErrNotFound
+		}
+		return models.TOTP{}, err
+	}
+	return totp, nil
+}
+
+// SetPendingTOTP stores secret of TOTP enrolment that is not confirmed yet, replacing previous unconfirmed secret
+// Returns ErrMFAEnabled if user has already enabled two-factor authentication
+func (mp *MFAPostgres) SetPendingTOTP(userID int, secret string) error {
+	query := `UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2 AND totp_enabled_at IS NULL`
+	ctx := context.Background()
+
+	tag, err := mp.db.GetPool().Exec(ctx, query, secret, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAEnabled
+	}
+	return nil
+}
+
+// EnableTOTP confirms TOTP enrolment and replaces recovery codes of user with given hashes in single transaction
+// step is time step of code used for confirmation, it can't be used again
+// Returns ErrMFAEnabled if user has already enabled two-factor authentication
+func (mp *MFAPostgres) EnableTOTP(userID int, step int64, recoveryCodeHashes []string) error {
+	ctx := context.Background()
+
+	tx, err := mp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $1
+	                          WHERE id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`, step, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAEnabled
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.Exec(ctx, `INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`,
+			userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DisableTOTP turns two-factor authentication of user off and deletes their recovery codes
+func (mp *MFAPostgres) DisableTOTP(userID int) error {
+	ctx := context.Background()
+
+	tx, err := mp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+	                       WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep records that code of given time step has been used by user
+// Returns false if code of this or later step has already been used, so that code can't be replayed
+func (mp *MFAPostgres) UseTOTPStep(userID int, step int64) (bool, error) {
+	query := `UPDATE users SET totp_last_step = $1
+	          WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`
+	ctx := context.Background()
+
+	tag, err := mp.db.GetPool().Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode marks unused recovery code of user with given hash as used
+// Returns false if there is no such unused code
+func (mp *MFAPostgres) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	ctx := context.Background()
+
+	tag, err := mp.db.GetPool().Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
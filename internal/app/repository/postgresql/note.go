@@ -2,11 +2,46 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v4"
 	"rest-notes/internal/app/models"
 	"rest-notes/internal/app/repository/database"
 )
 
+var (
+	ErrNoteNotFound    = errors.New("note not found")
+	ErrVersionConflict = errors.New("note version conflict")
+)
+
+// noteColumns lists columns selected for every note query, in order expected by scanNote
+// Tags are aggregated into sorted array so that each note is returned by a single row
+const noteColumns = `id, user_id, notebook_id, title, description, lang, due_date,
+	COALESCE((SELECT array_agg(t.name ORDER BY t.name) FROM note_tags nt JOIN tags t ON t.id = nt.tag_id
+	          WHERE nt.note_id = notes.id), '{}') AS tags,
+	created_at, updated_at, deleted_at, version, spell_status`
+
+// noteSortExpressions maps sort field to SQL expression and type used to compare it with cursor value
+// Notes without due date are treated as due in infinite future, so they are always at the end of ascending list
+var noteSortExpressions = map[string]struct {
+	expr     string
+	cursorOf string
+}{
+	models.NoteSortDueDate:   {expr: `COALESCE(due_date, 'infinity'::timestamptz)`, cursorOf: "timestamptz"},
+	models.NoteSortCreatedAt: {expr: `created_at`, cursorOf: "timestamptz"},
+	models.NoteSortUpdatedAt: {expr: `updated_at`, cursorOf: "timestamptz"},
+	models.NoteSortTitle:     {expr: `title`, cursorOf: "text"},
+}
+
+// rowQuerier is implemented by both connection pool and transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 // NotePostgres is repository implementation for managing notes in PostgreSQL database
 type NotePostgres struct {
 	db database.Database
@@ -17,29 +52,118 @@ func NewNotePostgres(db database.Database) *NotePostgres {
 	return &NotePostgres{db: db}
 }
 
-// Create inserts new note into database and returns created note with its ID and timestamps
+// Create inserts new note with its tags and first revision into database in single transaction
+// and returns created note with its ID and timestamps
+// Spell check results of note are stored with it, note with pending spell status is queued for background check
 func (n *NotePostgres) Create(note models.Note) (models.Note, error) {
-	query := `INSERT INTO notes (user_id, title, description, due_date, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO notes (user_id, notebook_id, title, description, lang, due_date, spell_status, spelling_issues,
+	                             spell_checked_at, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $7::VARCHAR = 'checked' THEN NOW() END, NOW(), NOW())
+	          RETURNING id, created_at, updated_at, version`
 	ctx := context.Background()
 
+	issues, err := spellingIssuesArg(note)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	tx, err := n.db.GetPool().Begin(ctx)
+	if err != nil {
+		return models.Note{}, err
+	}
+	defer tx.Rollback(ctx)
+
 	// Execute query and scan returned ID, created_at, and updated_at into note object
-	err := n.db.GetPool().QueryRow(ctx, query, note.UserID, note.Title, note.Description, note.DueDate).
-		Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt)
+	err = tx.QueryRow(ctx, query, note.UserID, note.NotebookID, note.Title, note.Description, note.Lang, note.DueDate,
+		note.SpellStatus, issues).
+		Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt, &note.Version)
 	if err != nil {
 		return models.Note{}, err
 	}
+
+	if note.Tags == nil {
+		note.Tags = []string{}
+	}
+	if err = setNoteTags(ctx, tx, note.UserID, note.ID, note.Tags); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = insertRevision(ctx, tx, note, note.UserID); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = syncSpellJob(ctx, tx, note); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Note{}, err
+	}
 	return note, nil
 }
 
-// GetAll retrieves all notes for a given user from the database
-func (n *NotePostgres) GetAll(userID int) ([]models.Note, error) {
-	query := `SELECT id, user_id, title, description, due_date, created_at, updated_at FROM notes WHERE user_id = $1`
-	var notes []models.Note
+// GetAll retrieves one page of notes for a given user from the database
+// Notes are filtered and ordered according to filter, page starts right after filter.After cursor;
+// notes in trash are never returned
+func (n *NotePostgres) GetAll(userID int, filter models.NoteFilter) ([]models.Note, error) {
+	sort, ok := noteSortExpressions[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []interface{}{userID}
+	addArg := func(arg interface{}) string {
+		args = append(args, arg)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_date < "+addArg(*filter.DueBefore))
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_date > "+addArg(*filter.DueAfter))
+	}
+	if filter.Overdue {
+		conditions = append(conditions, "due_date < NOW()")
+	}
+	if filter.HasDueDate != nil {
+		if *filter.HasDueDate {
+			conditions = append(conditions, "due_date IS NOT NULL")
+		} else {
+			conditions = append(conditions, "due_date IS NULL")
+		}
+	}
+	if filter.NotebookID != nil {
+		conditions = append(conditions, "notebook_id = "+addArg(*filter.NotebookID))
+	}
+	if len(filter.Tags) > 0 {
+		// In "and" mode note must have every requested tag, in "or" mode any of them
+		tagged := fmt.Sprintf(`id IN (SELECT nt.note_id FROM note_tags nt JOIN tags t ON t.id = nt.tag_id
+		                              WHERE t.user_id = $1 AND t.name = ANY(%s)`, addArg(filter.Tags))
+		if filter.TagMode == models.TagModeAnd {
+			tagged += " GROUP BY nt.note_id HAVING COUNT(*) = " + addArg(len(filter.Tags))
+		}
+		conditions = append(conditions, tagged+")")
+	}
+
+	// Keyset condition: continue strictly after the last note of previous page
+	comparison, direction := ">", "ASC"
+	if filter.Order == models.SortDesc {
+		comparison, direction = "<", "DESC"
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			sort.expr, comparison, addArg(filter.After.Value), sort.cursorOf, addArg(filter.After.ID)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM notes WHERE %s ORDER BY %s %s, id %s LIMIT %s`,
+		noteColumns, strings.Join(conditions, " AND "), sort.expr, direction, direction, addArg(filter.Limit))
+	notes := []models.Note{}
 	ctx := context.Background()
 
 	// Execute query and iterate over result rows
-	rows, err := n.db.GetPool().Query(ctx, query, userID)
+	rows, err := n.db.GetPool().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -47,9 +171,7 @@ func (n *NotePostgres) GetAll(userID int) ([]models.Note, error) {
 
 	// Scan each row into Note object and append to notes slice
 	for rows.Next() {
-		var note models.Note
-		err = rows.Scan(
-			&note.ID, &note.UserID, &note.Title, &note.Description, &note.DueDate, &note.CreatedAt, &note.UpdatedAt)
+		note, err := scanNote(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -63,3 +185,348 @@ func (n *NotePostgres) GetAll(userID int) ([]models.Note, error) {
 
 	return notes, nil
 }
+
+// GetByID retrieves single note by its ID, including note in trash
+// Returns ErrNoteNotFound if there is no note with such ID
+func (n *NotePostgres) GetByID(id int) (models.Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes WHERE id = $1`
+	ctx := context.Background()
+
+	note, err := scanNote(n.db.GetPool().QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Note{}, ErrNoteNotFound
+		}
+		return models.Note{}, err
+	}
+	return note, nil
+}
+
+// Update overwrites notebook, title, description and due date of existing note and bumps its updated_at and version
+// Tags are replaced only if note.Tags is not nil; new revision of note is saved in the same transaction
+// Spell check results are replaced as well, note with pending spell status is queued for background check
+// note.Version is version client expects note to have, zero skips the check
+// Returns updated note, ErrNoteNotFound if note does not exist or is in trash
+// and ErrVersionConflict if note has been changed since expected version
+func (n *NotePostgres) Update(note models.Note) (models.Note, error) {
+	query := `UPDATE notes SET notebook_id = $1, title = $2, description = $3, lang = $4, due_date = $5,
+	                 spell_status = $6, spelling_issues = $7,
+	                 spell_checked_at = CASE WHEN $6::VARCHAR = 'checked' THEN NOW() END,
+	                 updated_at = NOW(), version = version + 1
+	          WHERE id = $8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9)
+	          RETURNING user_id, created_at, updated_at, version`
+	ctx := context.Background()
+
+	issues, err := spellingIssuesArg(note)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	tx, err := n.db.GetPool().Begin(ctx)
+	if err != nil {
+		return models.Note{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, note.NotebookID, note.Title, note.Description, note.Lang, note.DueDate,
+		note.SpellStatus, issues, note.ID, note.Version).
+		Scan(&note.UserID, &note.CreatedAt, &note.UpdatedAt, &note.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Note{}, missingNoteError(ctx, tx, note.ID)
+		}
+		return models.Note{}, err
+	}
+
+	if note.Tags != nil {
+		err = setNoteTags(ctx, tx, note.UserID, note.ID, note.Tags)
+	} else {
+		note.Tags, err = getNoteTags(ctx, tx, note.ID)
+	}
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	if err = insertRevision(ctx, tx, note, note.UserID); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = syncSpellJob(ctx, tx, note); err != nil {
+		return models.Note{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Note{}, err
+	}
+	return note, nil
+}
+
+// GetSpelling retrieves spell check status and results of note with last error of its background check
+// Returns ErrNoteNotFound if there is no note with such ID
+func (n *NotePostgres) GetSpelling(id int) (models.NoteSpelling, error) {
+	query := `SELECT n.spell_status, COALESCE(n.spelling_issues, '[]'::jsonb), n.spell_checked_at,
+	                 COALESCE(j.last_error, '')
+	          FROM notes n LEFT JOIN spell_jobs j ON j.note_id = n.id
+	          WHERE n.id = $1`
+	spelling := models.NoteSpelling{NoteID: id}
+	var issues []byte
+
+	ctx := context.Background()
+
+	err := n.db.GetPool().QueryRow(ctx, query, id).Scan(&spelling.Status, &issues, &spelling.CheckedAt, &spelling.Error)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.NoteSpelling{}, ErrNoteNotFound
+		}
+		return models.NoteSpelling{}, err
+	}
+
+	if err = json.Unmarshal(issues, &spelling.Issues); err != nil {
+		return models.NoteSpelling{}, err
+	}
+	return spelling, nil
+}
+
+// Move puts note into notebook if it still has expected version, zero version skips the check
+// nil notebookID moves note out of any notebook
+// Returns ErrNoteNotFound if note does not exist or is in trash and ErrVersionConflict on version mismatch
+func (n *NotePostgres) Move(id int, notebookID *int, version int) error {
+	query := `UPDATE notes SET notebook_id = $1, updated_at = NOW(), version = version + 1
+	          WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`
+	ctx := context.Background()
+
+	tag, err := n.db.GetPool().Exec(ctx, query, notebookID, id, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return missingNoteError(ctx, n.db.GetPool(), id)
+	}
+	return nil
+}
+
+// Delete moves note with given ID to trash if it still has expected version, zero version skips the check
+// Returns ErrNoteNotFound if note does not exist or is already in trash and ErrVersionConflict on version mismatch
+func (n *NotePostgres) Delete(id, version int) error {
+	query := `UPDATE notes SET deleted_at = NOW(), version = version + 1
+	          WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+	ctx := context.Background()
+
+	tag, err := n.db.GetPool().Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return missingNoteError(ctx, n.db.GetPool(), id)
+	}
+	return nil
+}
+
+// Restore moves note with given ID out of trash
+// Returns ErrNoteNotFound if note does not exist or is not in trash
+func (n *NotePostgres) Restore(id int) error {
+	query := `UPDATE notes SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+	ctx := context.Background()
+
+	tag, err := n.db.GetPool().Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoteNotFound
+	}
+	return nil
+}
+
+// GetTrash retrieves notes of user that are in trash, most recently deleted first
+func (n *NotePostgres) GetTrash(userID int) ([]models.Note, error) {
+	query := `SELECT ` + noteColumns + ` FROM notes WHERE user_id = $1 AND deleted_at IS NOT NULL
+	          ORDER BY deleted_at DESC, id DESC`
+	notes := []models.Note{}
+	ctx := context.Background()
+
+	rows, err := n.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// PurgeTrash permanently deletes notes that were moved to trash before given time
+// Returns number of deleted notes
+func (n *NotePostgres) PurgeTrash(deletedBefore time.Time) (int64, error) {
+	query := `DELETE FROM notes WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	ctx := context.Background()
+
+	tag, err := n.db.GetPool().Exec(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeletePermanently removes note with given ID from database, whether it is in trash or not
+// Note must have expected version, zero version skips the check
+// Returns ErrNoteNotFound if note does not exist and ErrVersionConflict on version mismatch
+func (n *NotePostgres) DeletePermanently(id, version int) error {
+	query := `DELETE FROM notes WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	ctx := context.Background()
+
+	tag, err := n.db.GetPool().Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err = n.db.GetPool().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM notes WHERE id = $1)`, id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return ErrNoteNotFound
+	}
+	return nil
+}
+
+// missingNoteError explains why conditional write to active note changed nothing:
+// ErrVersionConflict if note is still there, ErrNoteNotFound if it is gone or in trash
+func missingNoteError(ctx context.Context, q rowQuerier, id int) error {
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM notes WHERE id = $1 AND deleted_at IS NULL)`, id).
+		Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNoteNotFound
+}
+
+// scanNote scans row selected with noteColumns into Note object
+func scanNote(row pgx.Row) (models.Note, error) {
+	var note models.Note
+	err := row.Scan(noteScanTargets(&note)...)
+	return note, err
+}
+
+// noteScanTargets returns destinations for columns listed in noteColumns
+func noteScanTargets(note *models.Note) []interface{} {
+	return []interface{}{
+		&note.ID, &note.UserID, &note.NotebookID, &note.Title, &note.Description, &note.Lang, &note.DueDate, &note.Tags,
+		&note.CreatedAt, &note.UpdatedAt, &note.DeletedAt, &note.Version, &note.SpellStatus,
+	}
+}
+
+// setNoteTags replaces tags of note with given names, creating missing tags for user
+func setNoteTags(ctx context.Context, tx pgx.Tx, userID, noteID int, tags []string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM note_tags WHERE note_id = $1`, noteID)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO tags (user_id, name) SELECT $1, unnest($2::text[])
+	                       ON CONFLICT (user_id, name) DO NOTHING`, userID, tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO note_tags (note_id, tag_id)
+	                       SELECT $1, id FROM tags WHERE user_id = $2 AND name = ANY($3)`, noteID, userID, tags)
+	return err
+}
+
+// getNoteTags returns sorted names of note's tags
+func getNoteTags(ctx context.Context, tx pgx.Tx, noteID int) ([]string, error) {
+	query := `SELECT t.name FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE nt.note_id = $1 ORDER BY t.name`
+	tags := []string{}
+
+	rows, err := tx.Query(ctx, query, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Markers that ts_headline puts around matched words, control characters cannot be confused with HTML
+// or with markup typed by user
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// Options of ts_headline for whole highlighted title and for fragments of description
+const (
+	titleHeadlineOptions   = `StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `", HighlightAll=true`
+	snippetHeadlineOptions = `StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `", ` +
+		`MaxFragments=3, FragmentDelimiter=" ... "`
+)
+
+// Search finds user's notes matching full-text query, ordered by relevance
+// Query is parsed with both russian and simple configurations so that english words are found too;
+// matched words in title and description fragments are wrapped in HighlightStart and HighlightStop markers,
+// text around them is returned as is and must be escaped before markers are turned into markup
+func (n *NotePostgres) Search(userID int, query string, limit int) ([]models.NoteSearchResult, error) {
+	sqlQuery := `WITH q AS (
+	                 SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('simple', $2) AS query
+	             )
+	             SELECT ` + noteColumns + `,
+	                    ts_rank(search_vector, q.query) AS rank,
+	                    ts_headline('russian', title, q.query, $4),
+	                    ts_headline('russian', coalesce(description, ''), q.query, $5)
+	             FROM notes, q
+	             WHERE user_id = $1 AND deleted_at IS NULL AND search_vector @@ q.query
+	             ORDER BY rank DESC, id DESC
+	             LIMIT $3`
+	results := []models.NoteSearchResult{}
+	ctx := context.Background()
+
+	rows, err := n.db.GetPool().Query(ctx, sqlQuery, userID, query, limit, titleHeadlineOptions, snippetHeadlineOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.NoteSearchResult
+		err = rows.Scan(append(noteScanTargets(&result.Note), &result.Rank, &result.TitleHighlight, &result.Snippet)...)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var ErrNotebookNotFound = errors.New("notebook not found")
+
+// notebookSubtreeQuery selects IDs of notebook given as $1 and all notebooks nested in it
+const notebookSubtreeQuery = `WITH RECURSIVE subtree AS (
+	                              SELECT id FROM notebooks WHERE id = $1
+	                              UNION ALL
+	                              SELECT nb.id FROM notebooks nb JOIN subtree s ON nb.parent_id = s.id
+	                          )
+	                          SELECT id FROM subtree`
+
+// NotebookPostgres is repository implementation for managing notebooks in PostgreSQL database
+type NotebookPostgres struct {
+	db database.Database
+}
+
+// NewNotebookPostgres creates new NotebookPostgres instance with given database connection
+func NewNotebookPostgres(db database.Database) *NotebookPostgres {
+	return &NotebookPostgres{db: db}
+}
+
+// Create inserts new notebook into database and returns it with its ID and timestamps
+func (np *NotebookPostgres) Create(notebook models.Notebook) (models.Notebook, error) {
+	query := `INSERT INTO notebooks (user_id, parent_id, name, created_at, updated_at)
+	          VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	ctx := context.Background()
+
+	err := np.db.GetPool().QueryRow(ctx, query, notebook.UserID, notebook.ParentID, notebook.Name).
+		Scan(&notebook.ID, &notebook.CreatedAt, &notebook.UpdatedAt)
+	if err != nil {
+		return models.Notebook{}, err
+	}
+	return notebook, nil
+}
+
+// GetAll retrieves all notebooks of user ordered by name
+func (np *NotebookPostgres) GetAll(userID int) ([]models.Notebook, error) {
+	query := `SELECT id, user_id, parent_id, name, created_at, updated_at FROM notebooks
+	          WHERE user_id = $1 ORDER BY name, id`
+	notebooks := []models.Notebook{}
+	ctx := context.Background()
+
+	rows, err := np.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notebook models.Notebook
+		err = rows.Scan(&notebook.ID, &notebook.UserID, &notebook.ParentID, &notebook.Name,
+			&notebook.CreatedAt, &notebook.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notebooks = append(notebooks, notebook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notebooks, nil
+}
+
+// GetByID retrieves single notebook by its ID
+// Returns ErrNotebookNotFound if there is no notebook with such ID
+func (np *NotebookPostgres) GetByID(id int) (models.Notebook, error) {
+	query := `SELECT id, user_id, parent_id, name, created_at, updated_at FROM notebooks WHERE id = $1`
+	var notebook models.Notebook
+	ctx := context.Background()
+
+	err := np.db.GetPool().QueryRow(ctx, query, id).Scan(&notebook.ID, &notebook.UserID, &notebook.ParentID,
+		&notebook.Name, &notebook.CreatedAt, &notebook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Notebook{}, ErrNotebookNotFound
+		}
+		return models.Notebook{}, err
+	}
+	return notebook, nil
+}
+
+// Update changes name and parent of notebook and bumps its updated_at
+// Returns ErrNotebookNotFound if notebook does not exist
+func (np *NotebookPostgres) Update(notebook models.Notebook) (models.Notebook, error) {
+	query := `UPDATE notebooks SET parent_id = $1, name = $2, updated_at = NOW()
+	          WHERE id = $3 RETURNING user_id, created_at, updated_at`
+	ctx := context.Background()
+
+	err := np.db.GetPool().QueryRow(ctx, query, notebook.ParentID, notebook.Name, notebook.ID).
+		Scan(&notebook.UserID, &notebook.CreatedAt, &notebook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Notebook{}, ErrNotebookNotFound
+		}
+		return models.Notebook{}, err
+	}
+	return notebook, nil
+}
+
+// IsDescendant reports whether notebook with given ID is ancestorID itself or is nested in it at any depth
+func (np *NotebookPostgres) IsDescendant(ancestorID, id int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM (` + notebookSubtreeQuery + `) s WHERE s.id = $2)`
+	var found bool
+	ctx := context.Background()
+
+	if err := np.db.GetPool().QueryRow(ctx, query, ancestorID, id).Scan(&found); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// Delete removes notebook using given mode in single transaction
+// In cascade mode notes of notebook and all nested notebooks are moved to trash,
+// in move-to-parent mode nested notebooks and notes are moved to parent of deleted notebook
+func (np *NotebookPostgres) Delete(id int, mode string) error {
+	ctx := context.Background()
+
+	tx, err := np.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	switch mode {
+	case models.NotebookDeleteCascade:
+		_, err = tx.Exec(ctx, `UPDATE notes SET deleted_at = COALESCE(deleted_at, NOW())
+		                       WHERE notebook_id IN (`+notebookSubtreeQuery+`)`, id)
+	case models.NotebookDeleteMoveToParent:
+		_, err = tx.Exec(ctx, `UPDATE notebooks SET parent_id = (SELECT parent_id FROM notebooks WHERE id = $1),
+		                       updated_at = NOW() WHERE parent_id = $1`, id)
+		if err == nil {
+			_, err = tx.Exec(ctx, `UPDATE notes SET notebook_id = (SELECT parent_id FROM notebooks WHERE id = $1),
+			                       updated_at = NOW() WHERE notebook_id = $1`, id)
+		}
+	default:
+		err = fmt.Errorf("unknown notebook delete mode %q", mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nested notebooks left in cascade mode are removed by foreign key
+	tag, err := tx.Exec(ctx, `DELETE FROM notebooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotebookNotFound
+	}
+
+	return tx.Commit(ctx)
+}
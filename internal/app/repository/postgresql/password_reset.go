@@ -0,0 +1,96 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var (
+	ErrResetTokenNotFound = errors.New("password reset token not found")
+	ErrResetTokenUsed     = errors.New("password reset token already used")
+)
+
+// PasswordResetPostgres is repository implementation for managing password reset tokens in PostgreSQL database
+type PasswordResetPostgres struct {
+	db database.Database
+}
+
+// NewPasswordResetPostgres creates new PasswordResetPostgres instance with given database connection
+func NewPasswordResetPostgres(db database.Database) *PasswordResetPostgres {
+	return &PasswordResetPostgres{db: db}
+}
+
+// Create stores new password reset token of user, unused tokens issued to user before stop working
+func (pp *PasswordResetPostgres) Create(userID int, tokenHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	tx, err := pp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO password_reset_tokens (user_id, token_hash, created_at, expires_at)
+	                       VALUES ($1, $2, NOW(), $3)`, userID, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetByHash retrieves password reset token by its hash
+// Returns ErrResetTokenNotFound if there is no such token
+func (pp *PasswordResetPostgres) GetByHash(tokenHash string) (models.PasswordResetToken, error) {
+	query := `SELECT id, user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash = $1`
+	var token models.PasswordResetToken
+	ctx := context.Background()
+
+	err := pp.db.GetPool().QueryRow(ctx, query, tokenHash).Scan(&token.ID, &token.UserID, &token.ExpiresAt,
+		&token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PasswordResetToken{}, ErrResetTokenNotFound
+		}
+		return models.PasswordResetToken{}, err
+	}
+	return token, nil
+}
+
+// Use marks password reset token as used and replaces password hash of its user in single transaction
+// Returns ErrResetTokenUsed if token has been used concurrently
+func (pp *PasswordResetPostgres) Use(token models.PasswordResetToken, passwordHash string) error {
+	ctx := context.Background()
+
+	tx, err := pp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`,
+		token.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrResetTokenUsed
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE users SET password = $1 WHERE id = $2`, passwordHash, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
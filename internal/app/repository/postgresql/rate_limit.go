@@ -0,0 +1,68 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"rest-notes/internal/app/ratelimit"
+	"rest-notes/internal/app/repository/database"
+)
+
+// RateLimitPostgres keeps token buckets of rate limiter in PostgreSQL database, so that limits are shared by replicas
+type RateLimitPostgres struct {
+	db database.Database
+}
+
+// NewRateLimitPostgres creates new RateLimitPostgres instance with given database connection
+func NewRateLimitPostgres(db database.Database) *RateLimitPostgres {
+	return &RateLimitPostgres{db: db}
+}
+
+// Take takes token from bucket of key, new bucket starts full
+// Bucket row is locked for update, so concurrent requests with the same key are counted one by one
+// Time is read with clock_timestamp(): NOW() is start of transaction, which is before update of bucket
+// by concurrent transaction that held the lock
+func (rp *RateLimitPostgres) Take(key string, rate float64, burst int) (bool, time.Duration, error) {
+	ctx := context.Background()
+
+	tx, err := rp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES ($1, $2, clock_timestamp())
+	                       ON CONFLICT (key) DO NOTHING`, key, float64(burst))
+	if err != nil {
+		return false, 0, err
+	}
+
+	var tokens, elapsed float64
+	err = tx.QueryRow(ctx, `SELECT tokens, EXTRACT(EPOCH FROM clock_timestamp() - updated_at)::DOUBLE PRECISION
+	                        FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`, key).Scan(&tokens, &elapsed)
+	if err != nil {
+		return false, 0, err
+	}
+
+	tokens, allowed, retryAfter := ratelimit.Take(tokens, time.Duration(elapsed*float64(time.Second)), rate, burst)
+
+	_, err = tx.Exec(ctx, `UPDATE rate_limit_buckets SET tokens = $1, updated_at = clock_timestamp() WHERE key = $2`,
+		tokens, key)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, 0, err
+	}
+	return allowed, retryAfter, nil
+}
+
+// DeleteIdle deletes buckets last updated before given time
+func (rp *RateLimitPostgres) DeleteIdle(before time.Time) error {
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < $1`
+	ctx := context.Background()
+
+	_, err := rp.db.GetPool().Exec(ctx, query, before)
+	return err
+}
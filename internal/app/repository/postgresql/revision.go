@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// GetRevisions retrieves all revisions of note, newest first
+func (n *NotePostgres) GetRevisions(noteID int) ([]models.NoteRevision, error) {
+	query := `SELECT note_id, revision, title, description, due_date, author_id, created_at
+	          FROM note_revisions WHERE note_id = $1 ORDER BY revision DESC`
+	revisions := []models.NoteRevision{}
+	ctx := context.Background()
+
+	rows, err := n.db.GetPool().Query(ctx, query, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.NoteRevision
+		err = rows.Scan(&revision.NoteID, &revision.Revision, &revision.Title, &revision.Description,
+			&revision.DueDate, &revision.AuthorID, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves single revision of note
+// Returns ErrRevisionNotFound if note has no such revision
+func (n *NotePostgres) GetRevision(noteID, revision int) (models.NoteRevision, error) {
+	query := `SELECT note_id, revision, title, description, due_date, author_id, created_at
+	          FROM note_revisions WHERE note_id = $1 AND revision = $2`
+	var rev models.NoteRevision
+	ctx := context.Background()
+
+	err := n.db.GetPool().QueryRow(ctx, query, noteID, revision).Scan(&rev.NoteID, &rev.Revision, &rev.Title,
+		&rev.Description, &rev.DueDate, &rev.AuthorID, &rev.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.NoteRevision{}, ErrRevisionNotFound
+		}
+		return models.NoteRevision{}, err
+	}
+	return rev, nil
+}
+
+// insertRevision saves current state of note as its next revision within transaction
+// Note row must already be locked by the transaction so that revision numbers do not clash
+func insertRevision(ctx context.Context, tx pgx.Tx, note models.Note, authorID int) error {
+	query := `INSERT INTO note_revisions (note_id, revision, title, description, due_date, author_id, created_at)
+	          SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, NOW()
+	          FROM note_revisions WHERE note_id = $1`
+	_, err := tx.Exec(ctx, query, note.ID, note.Title, note.Description, note.DueDate, authorID)
+	return err
+}
@@ -0,0 +1,144 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+)
+
+// SessionPostgres is repository implementation for managing login sessions and refresh tokens in PostgreSQL database
+type SessionPostgres struct {
+	db database.Database
+}
+
+// NewSessionPostgres creates new SessionPostgres instance with given database connection
+func NewSessionPostgres(db database.Database) *SessionPostgres {
+	return &SessionPostgres{db: db}
+}
+
+// Create inserts new session together with its first refresh token in single transaction
+func (sp *SessionPostgres) Create(session models.Session, refreshTokenHash string) error {
+	ctx := context.Background()
+
+	tx, err := sp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `INSERT INTO sessions (id, user_id, created_at, expires_at) VALUES ($1, $2, NOW(), $3)`,
+		session.ID, session.UserID, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO refresh_tokens (session_id, token_hash, created_at, expires_at)
+	                       VALUES ($1, $2, NOW(), $3)`, session.ID, refreshTokenHash, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRefreshToken retrieves refresh token by its hash together with state of its session
+// Returns ErrRefreshTokenNotFound if there is no such token
+func (sp *SessionPostgres) GetRefreshToken(tokenHash string) (models.RefreshToken, error) {
+	query := `SELECT rt.id, rt.session_id, s.user_id, rt.expires_at, rt.used_at, s.expires_at, s.revoked_at
+	          FROM refresh_tokens rt JOIN sessions s ON s.id = rt.session_id
+	          WHERE rt.token_hash = $1`
+	var token models.RefreshToken
+	ctx := context.Background()
+
+	err := sp.db.GetPool().QueryRow(ctx, query, tokenHash).Scan(&token.ID, &token.SessionID, &token.UserID,
+		&token.ExpiresAt, &token.UsedAt, &token.SessionExpiresAt, &token.SessionRevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RefreshToken{}, ErrRefreshTokenNotFound
+		}
+		return models.RefreshToken{}, err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken marks refresh token as used and issues its replacement in the same session
+// Session lifetime is extended to expiresAt
+// Returns ErrRefreshTokenUsed if token has been used concurrently
+func (sp *SessionPostgres) RotateRefreshToken(token models.RefreshToken, newTokenHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	tx, err := sp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`, token.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRefreshTokenUsed
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO refresh_tokens (session_id, token_hash, created_at, expires_at)
+	                       VALUES ($1, $2, NOW(), $3)`, token.SessionID, newTokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE sessions SET expires_at = $1 WHERE id = $2`, expiresAt, token.SessionID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// IsActive reports whether session exists, is not revoked and has not expired
+func (sp *SessionPostgres) IsActive(id string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`
+	var active bool
+	ctx := context.Background()
+
+	if err := sp.db.GetPool().QueryRow(ctx, query, id).Scan(&active); err != nil {
+		return false, err
+	}
+	return active, nil
+}
+
+// Revoke marks session as revoked, all its tokens stop working
+func (sp *SessionPostgres) Revoke(id string) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	ctx := context.Background()
+
+	_, err := sp.db.GetPool().Exec(ctx, query, id)
+	return err
+}
+
+// RevokeAllForUser marks all sessions of user as revoked
+func (sp *SessionPostgres) RevokeAllForUser(userID int) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	ctx := context.Background()
+
+	_, err := sp.db.GetPool().Exec(ctx, query, userID)
+	return err
+}
+
+// RevokeOthersForUser marks all sessions of user except session keepID as revoked
+func (sp *SessionPostgres) RevokeOthersForUser(userID int, keepID string) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+	ctx := context.Background()
+
+	_, err := sp.db.GetPool().Exec(ctx, query, userID, keepID)
+	return err
+}
@@ -0,0 +1,153 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var ErrNoSpellJobs = errors.New("no spell jobs ready to run")
+
+// SpellJobPostgres is repository implementation of background spell check queue in PostgreSQL database
+// Note has at most one job, job is deleted once its results are stored on note and kept with dead status
+// after last failed attempt
+type SpellJobPostgres struct {
+	db database.Database
+}
+
+// NewSpellJobPostgres creates new SpellJobPostgres instance with given database connection
+func NewSpellJobPostgres(db database.Database) *SpellJobPostgres {
+	return &SpellJobPostgres{db: db}
+}
+
+// Claim locks queued job that is due to run, or job whose worker lease has expired, for lease duration
+// Concurrent workers skip rows locked by each other, so every job is claimed by single worker
+// Returns ErrNoSpellJobs if there is nothing to do
+func (sj *SpellJobPostgres) Claim(lease time.Duration) (models.SpellJob, error) {
+	query := `UPDATE spell_jobs j SET status = 'processing', attempts = j.attempts + 1, locked_until = $1,
+	                 updated_at = NOW()
+	          FROM notes n
+	          WHERE j.id = (SELECT id FROM spell_jobs
+	                        WHERE (status = 'queued' AND run_at <= NOW())
+	                           OR (status = 'processing' AND locked_until < NOW())
+	                        ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED)
+	            AND n.id = j.note_id
+	          RETURNING j.id, j.note_id, n.user_id, n.title, n.description, n.lang, j.attempts, j.generation`
+	var job models.SpellJob
+
+	ctx := context.Background()
+
+	err := sj.db.GetPool().QueryRow(ctx, query, time.Now().Add(lease)).Scan(&job.ID, &job.NoteID, &job.UserID,
+		&job.Title, &job.Description, &job.Lang, &job.Attempts, &job.Generation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SpellJob{}, ErrNoSpellJobs
+		}
+		return models.SpellJob{}, err
+	}
+	return job, nil
+}
+
+// Complete stores spelling issues found by job on its note and removes job from queue
+// Nothing is stored if note has been queued again since job was claimed
+func (sj *SpellJobPostgres) Complete(job models.SpellJob, issues []models.SpellingIssue) error {
+	ctx := context.Background()
+
+	issuesJSON, err := json.Marshal(issues)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sj.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM spell_jobs WHERE id = $1 AND generation = $2`, job.ID, job.Generation)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE notes SET spell_status = 'checked', spelling_issues = $1, spell_checked_at = NOW()
+	                       WHERE id = $2`, issuesJSON, job.NoteID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Retry puts failed job back into queue to run again at runAt
+func (sj *SpellJobPostgres) Retry(job models.SpellJob, runAt time.Time, lastError string) error {
+	query := `UPDATE spell_jobs SET status = 'queued', run_at = $1, locked_until = NULL, last_error = $2,
+	                 updated_at = NOW()
+	          WHERE id = $3 AND generation = $4`
+	ctx := context.Background()
+
+	_, err := sj.db.GetPool().Exec(ctx, query, runAt, lastError, job.ID, job.Generation)
+	return err
+}
+
+// Fail moves job to dead letter state after its last attempt and marks spell check of its note as failed
+func (sj *SpellJobPostgres) Fail(job models.SpellJob, lastError string) error {
+	query := `UPDATE spell_jobs SET status = 'dead', locked_until = NULL, last_error = $1, updated_at = NOW()
+	          WHERE id = $2 AND generation = $3`
+	ctx := context.Background()
+
+	tx, err := sj.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, lastError, job.ID, job.Generation)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE notes SET spell_status = 'failed' WHERE id = $1`, job.NoteID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// syncSpellJob queues background spell check of note saved with pending spell status,
+// job already queued for note is restarted from scratch; notes with any other status have no job
+func syncSpellJob(ctx context.Context, tx pgx.Tx, note models.Note) error {
+	if note.SpellStatus != models.SpellStatusPending {
+		_, err := tx.Exec(ctx, `DELETE FROM spell_jobs WHERE note_id = $1`, note.ID)
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `INSERT INTO spell_jobs (note_id) VALUES ($1)
+	                        ON CONFLICT (note_id) DO UPDATE SET status = 'queued', generation = spell_jobs.generation + 1,
+	                            attempts = 0, run_at = NOW(), locked_until = NULL, last_error = NULL, updated_at = NOW()`,
+		note.ID)
+	return err
+}
+
+// spellingIssuesArg returns value stored in spelling_issues column of note: issues of checked note, NULL otherwise
+func spellingIssuesArg(note models.Note) ([]byte, error) {
+	if note.SpellStatus != models.SpellStatusChecked {
+		return nil, nil
+	}
+	issues := note.SpellingIssues
+	if issues == nil {
+		issues = []models.SpellingIssue{}
+	}
+	return json.Marshal(issues)
+}
@@ -0,0 +1,125 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var (
+	ErrSpellerSettingsNotFound = errors.New("speller settings not found")
+	ErrWordAlreadyExists       = errors.New("word already exists in dictionary")
+	ErrWordNotFound            = errors.New("word not found in dictionary")
+)
+
+// SpellerPostgres is repository implementation for managing user's speller settings and dictionary in PostgreSQL database
+type SpellerPostgres struct {
+	db database.Database
+}
+
+// NewSpellerPostgres creates new SpellerPostgres instance with given database connection
+func NewSpellerPostgres(db database.Database) *SpellerPostgres {
+	return &SpellerPostgres{db: db}
+}
+
+// GetSettings retrieves speller settings of user
+// Returns ErrSpellerSettingsNotFound if user has never saved settings
+func (sp *SpellerPostgres) GetSettings(userID int) (models.SpellerSettings, error) {
+	query := `SELECT spell_mode, ignore_digits, ignore_urls, find_repeat_words, ignore_capitalization
+	          FROM speller_settings WHERE user_id = $1`
+	var settings models.SpellerSettings
+
+	ctx := context.Background()
+
+	err := sp.db.GetPool().QueryRow(ctx, query, userID).Scan(&settings.SpellMode, &settings.IgnoreDigits,
+		&settings.IgnoreURLs, &settings.FindRepeatWords, &settings.IgnoreCapitalization)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SpellerSettings{}, ErrSpellerSettingsNotFound
+		}
+		return models.SpellerSettings{}, err
+	}
+
+	return settings, nil
+}
+
+// SaveSettings inserts or replaces speller settings of user
+func (sp *SpellerPostgres) SaveSettings(userID int, settings models.SpellerSettings) error {
+	query := `INSERT INTO speller_settings (user_id, spell_mode, ignore_digits, ignore_urls, find_repeat_words,
+	                                       ignore_capitalization, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	          ON CONFLICT (user_id) DO UPDATE SET spell_mode = EXCLUDED.spell_mode,
+	              ignore_digits = EXCLUDED.ignore_digits, ignore_urls = EXCLUDED.ignore_urls,
+	              find_repeat_words = EXCLUDED.find_repeat_words,
+	              ignore_capitalization = EXCLUDED.ignore_capitalization, updated_at = NOW()`
+
+	ctx := context.Background()
+
+	_, err := sp.db.GetPool().Exec(ctx, query, userID, settings.SpellMode, settings.IgnoreDigits,
+		settings.IgnoreURLs, settings.FindRepeatWords, settings.IgnoreCapitalization)
+	return err
+}
+
+// AddWord inserts word into user's dictionary
+// Returns ErrWordAlreadyExists if dictionary already has word with same normalized form
+func (sp *SpellerPostgres) AddWord(word models.DictionaryWord, normalizedWord string) (models.DictionaryWord, error) {
+	query := `INSERT INTO user_dictionary (user_id, word, normalized_word, created_at) VALUES ($1, $2, $3, NOW())
+	          ON CONFLICT (user_id, normalized_word) DO NOTHING RETURNING id, created_at`
+
+	ctx := context.Background()
+
+	err := sp.db.GetPool().QueryRow(ctx, query, word.UserID, word.Word, normalizedWord).Scan(&word.ID, &word.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.DictionaryWord{}, ErrWordAlreadyExists
+		}
+		return models.DictionaryWord{}, err
+	}
+
+	return word, nil
+}
+
+// GetWords retrieves all words of user's dictionary in alphabetical order
+func (sp *SpellerPostgres) GetWords(userID int) ([]models.DictionaryWord, error) {
+	query := `SELECT id, user_id, word, created_at FROM user_dictionary WHERE user_id = $1 ORDER BY normalized_word`
+
+	ctx := context.Background()
+
+	rows, err := sp.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := make([]models.DictionaryWord, 0)
+	for rows.Next() {
+		var word models.DictionaryWord
+		if err = rows.Scan(&word.ID, &word.UserID, &word.Word, &word.CreatedAt); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+
+	return words, rows.Err()
+}
+
+// DeleteWord removes word with given normalized form from user's dictionary
+// Returns ErrWordNotFound if dictionary has no such word
+func (sp *SpellerPostgres) DeleteWord(userID int, normalizedWord string) error {
+	query := `DELETE FROM user_dictionary WHERE user_id = $1 AND normalized_word = $2`
+
+	ctx := context.Background()
+
+	tag, err := sp.db.GetPool().Exec(ctx, query, userID, normalizedWord)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWordNotFound
+	}
+
+	return nil
+}
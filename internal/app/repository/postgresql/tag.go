@@ -0,0 +1,143 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"rest-notes/internal/app/models"
+	"rest-notes/internal/app/repository/database"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+)
+
+// uniqueViolationCode is PostgreSQL error code raised when unique constraint is violated
+const uniqueViolationCode = "23505"
+
+// TagPostgres is repository implementation for managing note tags in PostgreSQL database
+type TagPostgres struct {
+	db database.Database
+}
+
+// NewTagPostgres creates new TagPostgres instance with given database connection
+func NewTagPostgres(db database.Database) *TagPostgres {
+	return &TagPostgres{db: db}
+}
+
+// GetAll retrieves all tags of user together with number of notes marked with each tag
+// Notes in trash are not counted
+func (tp *TagPostgres) GetAll(userID int) ([]models.Tag, error) {
+	query := `SELECT t.id, t.user_id, t.name, COUNT(n.id)
+	          FROM tags t
+	          LEFT JOIN (note_tags nt JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL) ON nt.tag_id = t.id
+	          WHERE t.user_id = $1
+	          GROUP BY t.id
+	          ORDER BY t.name`
+	tags := []models.Tag{}
+	ctx := context.Background()
+
+	rows, err := tp.db.GetPool().Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag models.Tag
+		if err = rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.NoteCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetByID retrieves single tag by its ID
+// Returns ErrTagNotFound if there is no tag with such ID
+func (tp *TagPostgres) GetByID(id int) (models.Tag, error) {
+	query := `SELECT t.id, t.user_id, t.name,
+	                 (SELECT COUNT(*) FROM note_tags nt JOIN notes n ON n.id = nt.note_id
+	                  WHERE nt.tag_id = t.id AND n.deleted_at IS NULL)
+	          FROM tags t WHERE t.id = $1`
+	var tag models.Tag
+	ctx := context.Background()
+
+	err := tp.db.GetPool().QueryRow(ctx, query, id).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.NoteCount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Tag{}, ErrTagNotFound
+		}
+		return models.Tag{}, err
+	}
+	return tag, nil
+}
+
+// Rename changes name of tag
+// Returns ErrTagAlreadyExists if user already has tag with new name
+func (tp *TagPostgres) Rename(id int, name string) error {
+	query := `UPDATE tags SET name = $1 WHERE id = $2`
+	ctx := context.Background()
+
+	tag, err := tp.db.GetPool().Exec(ctx, query, name, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return ErrTagAlreadyExists
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// Merge moves notes of source tags to target tag and deletes source tags
+// Both operations are performed in single transaction
+func (tp *TagPostgres) Merge(sourceIDs []int, targetID int) error {
+	ctx := context.Background()
+
+	tx, err := tp.db.GetPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `INSERT INTO note_tags (note_id, tag_id)
+	                       SELECT note_id, $1 FROM note_tags WHERE tag_id = ANY($2)
+	                       ON CONFLICT DO NOTHING`, targetID, sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM tags WHERE id = ANY($1)`, sourceIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Delete removes tag and detaches it from all notes
+// Returns ErrTagNotFound if nothing was deleted
+func (tp *TagPostgres) Delete(id int) error {
+	query := `DELETE FROM tags WHERE id = $1`
+	ctx := context.Background()
+
+	tag, err := tp.db.GetPool().Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
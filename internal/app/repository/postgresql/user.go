@@ -10,7 +10,13 @@ import (
 	"rest-notes/internal/app/repository/database"
 )
 
-var ErrNotFound = errors.New("user not found")
+var (
+	ErrNotFound        = errors.New("user not found")
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+// userColumns lists columns selected for every user query, in order expected by scanUser
+const userColumns = `id, username, password, role, disabled_at, totp_enabled_at IS NOT NULL, created_at`
 
 // UserPostgres implements the UserRepo interface for PostgreSQL database operations related to users
 type UserPostgres struct {
@@ -23,13 +29,14 @@ func NewUserPostgres(db database.Database) *UserPostgres {
 }
 
 // Create inserts new user into the users table and returns error if operation fails
+// User without role gets default role
 func (up *UserPostgres) Create(user models.User) error {
-	query := `INSERT INTO users (username, password, created_at) 
-	          VALUES ($1, $2, NOW()) RETURNING id, created_at`
+	query := `INSERT INTO users (username, password, role, created_at)
+	          VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'user'), NOW()) RETURNING id, created_at`
 	ctx := context.Background()
 
 	// Execute query and scan returned ID, created_at, and updated_at into note object
-	err := up.db.GetPool().QueryRow(ctx, query, user.Name, user.Password).
+	err := up.db.GetPool().QueryRow(ctx, query, user.Name, user.Password, user.Role).
 		Scan(&user.ID, &user.CreatedAt)
 	if err != nil {
 		return err
@@ -38,15 +45,13 @@ func (up *UserPostgres) Create(user models.User) error {
 }
 
 // Get retrieves user from the users table by username and verifies provided password
-// Returns user and error if user is not found or if password is incorrect
+// Returns ErrNotFound if user is not found and ErrInvalidPassword if password is incorrect
 func (up *UserPostgres) Get(user models.User) (models.User, error) {
-	query := `SELECT id, username, password, created_at FROM users WHERE username = $1`
-	var dbUser models.User
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
 
 	ctx := context.Background()
 
-	err := up.db.GetPool().QueryRow(ctx, query, user.Name).
-		Scan(&dbUser.ID, &dbUser.Name, &dbUser.Password, &dbUser.CreatedAt)
+	dbUser, err := scanUser(up.db.GetPool().QueryRow(ctx, query, user.Name))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, ErrNotFound
@@ -57,8 +62,144 @@ func (up *UserPostgres) Get(user models.User) (models.User, error) {
 	// Compare provided password with hashed password stored in database
 	err = bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(user.Password))
 	if err != nil {
-		return models.User{}, errors.New("invalid password")
+		return models.User{}, ErrInvalidPassword
+	}
+
+	return dbUser, nil
+}
+
+// GetByID retrieves user from the users table by ID without checking password
+// Returns ErrNotFound if there is no user with such ID
+func (up *UserPostgres) GetByID(id int) (models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	ctx := context.Background()
+
+	dbUser, err := scanUser(up.db.GetPool().QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
+	}
+
+	return dbUser, nil
+}
+
+// GetByName retrieves user from the users table by username without checking password
+// Returns ErrNotFound if there is no user with such name
+func (up *UserPostgres) GetByName(name string) (models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
+
+	ctx := context.Background()
+
+	dbUser, err := scanUser(up.db.GetPool().QueryRow(ctx, query, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
 	}
 
 	return dbUser, nil
 }
+
+// GetAll retrieves all users ordered by ID
+func (up *UserPostgres) GetAll() ([]models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY id`
+	users := []models.User{}
+
+	ctx := context.Background()
+
+	rows, err := up.db.GetPool().Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// SetDisabled disables user or enables disabled user again
+// Returns ErrNotFound if there is no user with such ID
+func (up *UserPostgres) SetDisabled(id int, disabled bool) error {
+	query := `UPDATE users SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) END WHERE id = $2`
+	ctx := context.Background()
+
+	tag, err := up.db.GetPool().Exec(ctx, query, disabled, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRole changes role of user with given username
+// Returns ErrNotFound if there is no such user
+func (up *UserPostgres) SetRole(name, role string) error {
+	query := `UPDATE users SET role = $1 WHERE username = $2`
+	ctx := context.Background()
+
+	tag, err := up.db.GetPool().Exec(ctx, query, role, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdatePassword replaces password hash of user
+// Returns ErrNotFound if there is no user with such ID
+func (up *UserPostgres) UpdatePassword(id int, passwordHash string) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+	ctx := context.Background()
+
+	tag, err := up.db.GetPool().Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes user with all their notes, sessions and other data
+// Returns ErrNotFound if there is no user with such ID
+func (up *UserPostgres) Delete(id int) error {
+	query := `DELETE FROM users WHERE id = $1`
+	ctx := context.Background()
+
+	tag, err := up.db.GetPool().Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanUser scans row selected with userColumns into User object
+func scanUser(row pgx.Row) (models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Name, &user.Password, &user.Role, &user.DisabledAt, &user.MFAEnabled,
+		&user.CreatedAt)
+	return user, err
+}
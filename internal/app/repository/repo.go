@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"rest-notes/internal/app/models"
 	"rest-notes/internal/app/repository/database"
 	"rest-notes/internal/app/repository/postgresql"
@@ -10,24 +12,155 @@ import (
 type UserRepo interface {
 	Create(user models.User) error
 	Get(user models.User) (models.User, error)
+	GetByID(id int) (models.User, error)
+	GetByName(name string) (models.User, error)
+	GetAll() ([]models.User, error)
+	SetDisabled(id int, disabled bool) error
+	SetRole(name, role string) error
+	UpdatePassword(id int, passwordHash string) error
+	Delete(id int) error
 }
 
 // NoteRepo defines interface for note-related database operations
 type NoteRepo interface {
 	Create(note models.Note) (models.Note, error)
-	GetAll(userID int) ([]models.Note, error)
+	GetAll(userID int, filter models.NoteFilter) ([]models.Note, error)
+	GetByID(id int) (models.Note, error)
+	Update(note models.Note) (models.Note, error)
+	Move(id int, notebookID *int, version int) error
+	Delete(id, version int) error
+	Restore(id int) error
+	GetTrash(userID int) ([]models.Note, error)
+	PurgeTrash(deletedBefore time.Time) (int64, error)
+	DeletePermanently(id, version int) error
+	Search(userID int, query string, limit int) ([]models.NoteSearchResult, error)
+	GetRevisions(noteID int) ([]models.NoteRevision, error)
+	GetRevision(noteID, revision int) (models.NoteRevision, error)
+	GetSpelling(id int) (models.NoteSpelling, error)
+}
+
+// TagRepo defines interface for tag-related database operations
+type TagRepo interface {
+	GetAll(userID int) ([]models.Tag, error)
+	GetByID(id int) (models.Tag, error)
+	Rename(id int, name string) error
+	Merge(sourceIDs []int, targetID int) error
+	Delete(id int) error
+}
+
+// NotebookRepo defines interface for notebook-related database operations
+type NotebookRepo interface {
+	Create(notebook models.Notebook) (models.Notebook, error)
+	GetAll(userID int) ([]models.Notebook, error)
+	GetByID(id int) (models.Notebook, error)
+	Update(notebook models.Notebook) (models.Notebook, error)
+	IsDescendant(ancestorID, id int) (bool, error)
+	Delete(id int, mode string) error
+}
+
+// SessionRepo defines interface for login session and refresh token database operations
+type SessionRepo interface {
+	Create(session models.Session, refreshTokenHash string) error
+	GetRefreshToken(tokenHash string) (models.RefreshToken, error)
+	RotateRefreshToken(token models.RefreshToken, newTokenHash string, expiresAt time.Time) error
+	IsActive(id string) (bool, error)
+	Revoke(id string) error
+	RevokeAllForUser(userID int) error
+	RevokeOthersForUser(userID int, keepID string) error
+}
+
+// PasswordResetRepo defines interface for password reset token database operations
+type PasswordResetRepo interface {
+	Create(userID int, tokenHash string, expiresAt time.Time) error
+	GetByHash(tokenHash string) (models.PasswordResetToken, error)
+	Use(token models.PasswordResetToken, passwordHash string) error
+}
+
+// AccessTokenRepo defines interface for personal access token database operations
+type AccessTokenRepo interface {
+	Create(token models.PersonalAccessToken, tokenHash string) (models.PersonalAccessToken, error)
+	GetAll(userID int) ([]models.PersonalAccessToken, error)
+	GetByHash(tokenHash string) (models.PersonalAccessToken, error)
+	MarkUsed(id int) error
+	Revoke(id, userID int) error
+}
+
+// SpellerRepo defines interface for database operations on user's speller preferences and custom dictionary
+type SpellerRepo interface {
+	GetSettings(userID int) (models.SpellerSettings, error)
+	SaveSettings(userID int, settings models.SpellerSettings) error
+	AddWord(word models.DictionaryWord, normalizedWord string) (models.DictionaryWord, error)
+	GetWords(userID int) ([]models.DictionaryWord, error)
+	DeleteWord(userID int, normalizedWord string) error
+}
+
+// SpellJobRepo defines interface for database operations on background spell check queue
+type SpellJobRepo interface {
+	Claim(lease time.Duration) (models.SpellJob, error)
+	Complete(job models.SpellJob, issues []models.SpellingIssue) error
+	Retry(job models.SpellJob, runAt time.Time, lastError string) error
+	Fail(job models.SpellJob, lastError string) error
+}
+
+// MFARepo defines interface for database operations on TOTP two-factor authentication and recovery codes
+type MFARepo interface {
+	GetTOTP(userID int) (models.TOTP, error)
+	SetPendingTOTP(userID int, secret string) error
+	EnableTOTP(userID int, step int64, recoveryCodeHashes []string) error
+	DisableTOTP(userID int) error
+	UseTOTPStep(userID int, step int64) (bool, error)
+	UseRecoveryCode(userID int, codeHash string) (bool, error)
+}
+
+// IdentityRepo defines interface for database operations on external identities of users
+// and states of OpenID Connect logins
+type IdentityRepo interface {
+	CreateLoginState(state models.OIDCLoginState, stateHash string) error
+	TakeLoginState(stateHash string) (models.OIDCLoginState, error)
+	GetByProviderSubject(provider, subject string) (models.UserIdentity, error)
+	GetAll(userID int) ([]models.UserIdentity, error)
+	Create(identity models.UserIdentity) (models.UserIdentity, error)
+	CreateUser(user models.User, identity models.UserIdentity) (models.UserIdentity, error)
+	MarkUsed(id int) error
+	Delete(userID int, provider string) error
+}
+
+// RateLimitRepo defines interface for database operations on token buckets of rate limiter
+type RateLimitRepo interface {
+	Take(key string, rate float64, burst int) (bool, time.Duration, error)
+	DeleteIdle(before time.Time) error
 }
 
-// Repository combines UserRepo and NoteRepo interfaces into single struct
+// Repository combines all repository interfaces into single struct
 type Repository struct {
 	UserRepo
 	NoteRepo
+	TagRepo
+	NotebookRepo
+	SessionRepo
+	AccessTokenRepo
+	PasswordResetRepo
+	MFARepo
+	IdentityRepo
+	SpellerRepo
+	SpellJobRepo
+	RateLimitRepo
 }
 
-// New initializes and returns new Repository instance with PostgreSQL implementations for UserRepo and NoteRepo
+// New initializes and returns new Repository instance with PostgreSQL implementations of repositories
 func New(db database.Database) *Repository {
 	return &Repository{
-		UserRepo: postgresql.NewUserPostgres(db),
-		NoteRepo: postgresql.NewNotePostgres(db),
+		UserRepo:          postgresql.NewUserPostgres(db),
+		NoteRepo:          postgresql.NewNotePostgres(db),
+		TagRepo:           postgresql.NewTagPostgres(db),
+		NotebookRepo:      postgresql.NewNotebookPostgres(db),
+		SessionRepo:       postgresql.NewSessionPostgres(db),
+		AccessTokenRepo:   postgresql.NewAccessTokenPostgres(db),
+		PasswordResetRepo: postgresql.NewPasswordResetPostgres(db),
+		MFARepo:           postgresql.NewMFAPostgres(db),
+		IdentityRepo:      postgresql.NewIdentityPostgres(db),
+		SpellerRepo:       postgresql.NewSpellerPostgres(db),
+		SpellJobRepo:      postgresql.NewSpellJobPostgres(db),
+		RateLimitRepo:     postgresql.NewRateLimitPostgres(db),
 	}
 }
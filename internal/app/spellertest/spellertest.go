@@ -0,0 +1,235 @@
+// Package spellertest provides fake implementation of Yandex Speller API for development and tests
+// It implements checkText and checkTexts methods of JSON protocol and checks words against fixed word list
+package spellertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Error codes and options of Speller API
+const (
+	codeUnknownWord    = 1
+	codeRepeatWord     = 2
+	codeCapitalization = 3
+
+	optionIgnoreDigits         = 2
+	optionIgnoreURLs           = 4
+	optionFindRepeatWords      = 8
+	optionIgnoreCapitalization = 512
+)
+
+// maxSuggestions is maximum number of suggestions returned for misspelled word
+const maxSuggestions = 5
+
+// urlPattern matches URLs, e-mails and file paths skipped with optionIgnoreURLs
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\S+@\S+\.\S+|(?:/[\w.-]+){2,}`)
+
+// spellerError is single mistake in response of Speller API
+type spellerError struct {
+	Code int      `json:"code"`
+	Pos  int      `json:"pos"`
+	Row  int      `json:"row"`
+	Col  int      `json:"col"`
+	Len  int      `json:"len"`
+	Word string   `json:"word"`
+	S    []string `json:"s"`
+}
+
+// Speller checks texts against list of known words
+// Word is known in any case if it is listed in lower case, capitalized words like names must be capitalized in text
+type Speller struct {
+	words map[string]string
+}
+
+// New creates Speller that knows given words
+func New(words []string) *Speller {
+	s := &Speller{words: make(map[string]string, len(words))}
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		s.words[strings.ToLower(word)] = word
+	}
+	return s
+}
+
+// NewServer starts HTTP server serving Speller API for given words
+// Its URL can be used as speller URL of application, server must be closed by caller
+func NewServer(words []string) *httptest.Server {
+	return httptest.NewServer(New(words))
+}
+
+// ServeHTTP handles checkText and checkTexts requests, made with GET or POST under any path prefix
+func (s *Speller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := 0
+	if value := r.Form.Get("options"); value != "" {
+		var err error
+		if options, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "invalid options", http.StatusBadRequest)
+			return
+		}
+	}
+	texts := r.Form["text"]
+
+	var result interface{}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/checkText"):
+		if len(texts) != 1 {
+			http.Error(w, "exactly one text expected", http.StatusBadRequest)
+			return
+		}
+		result = s.check(texts[0], options)
+	case strings.HasSuffix(r.URL.Path, "/checkTexts"):
+		results := make([][]spellerError, len(texts))
+		for i, text := range texts {
+			results[i] = s.check(text, options)
+		}
+		result = results
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// check finds mistakes in text the way Speller API reports them, positions are counted in characters
+func (s *Speller) check(text string, options int) []spellerError {
+	runes := []rune(text)
+	if options&optionIgnoreURLs != 0 {
+		runes = []rune(urlPattern.ReplaceAllStringFunc(text, func(url string) string {
+			return strings.Repeat(" ", len([]rune(url)))
+		}))
+	}
+
+	mistakes := []spellerError{}
+	previous := ""
+	row, col := 0, 0
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			if runes[i] == '\n' {
+				row, col = row+1, 0
+			} else {
+				col++
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && (isWordRune(runes[i]) || isJoiner(runes, i)) {
+			i++
+		}
+		word := string(runes[start:i])
+		mistake := spellerError{Pos: start, Row: row, Col: col, Len: i - start, Word: word, S: []string{}}
+		col += i - start
+
+		lower := strings.ToLower(word)
+		if options&optionFindRepeatWords != 0 && lower == previous {
+			mistake.Code = codeRepeatWord
+			mistakes = append(mistakes, mistake)
+			continue
+		}
+		previous = lower
+
+		if options&optionIgnoreDigits != 0 && strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+
+		known, ok := s.words[lower]
+		switch {
+		case !ok:
+			mistake.Code = codeUnknownWord
+			mistake.S = s.suggest(lower)
+		case known != lower && word != known && strings.ToUpper(word) != word &&
+			options&optionIgnoreCapitalization == 0:
+			mistake.Code = codeCapitalization
+			mistake.S = []string{known}
+		default:
+			continue
+		}
+		mistakes = append(mistakes, mistake)
+	}
+
+	return mistakes
+}
+
+// suggest returns known words closest to misspelled word, at most two edits away
+func (s *Speller) suggest(word string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+
+	var candidates []candidate
+	for lower, known := range s.words {
+		if d := distance([]rune(word), []rune(lower)); d <= 2 {
+			candidates = append(candidates, candidate{word: known, distance: d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].word < candidates[j].word
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].word)
+	}
+	return suggestions
+}
+
+// distance calculates Levenshtein distance between two words
+func distance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// isWordRune reports whether rune is part of word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isJoiner reports whether hyphen or apostrophe at position i joins two parts of word, like in "кто-то" or "don't"
+func isJoiner(runes []rune, i int) bool {
+	if runes[i] != '-' && runes[i] != '\'' && runes[i] != '’' {
+		return false
+	}
+	return i > 0 && i+1 < len(runes) && isWordRune(runes[i-1]) && isWordRune(runes[i+1])
+}
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('russian', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(title, '')), 'C') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'D')
+) STORED;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX notes_search_vector_idx ON notes USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS notes_search_vector_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE notes DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
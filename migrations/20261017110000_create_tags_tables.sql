@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags (
+                      id SERIAL PRIMARY KEY,
+                      user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                      name VARCHAR(64) NOT NULL,
+                      created_at TIMESTAMPTZ DEFAULT NOW(),
+                      UNIQUE (user_id, name)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE note_tags (
+                           note_id INT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+                           tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+                           PRIMARY KEY (note_id, tag_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX note_tags_tag_id_idx ON note_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS note_tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
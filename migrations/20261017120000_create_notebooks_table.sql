@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notebooks (
+                           id SERIAL PRIMARY KEY,
+                           user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                           parent_id INT REFERENCES notebooks(id) ON DELETE CASCADE,
+                           name VARCHAR(255) NOT NULL,
+                           created_at TIMESTAMPTZ DEFAULT NOW(),
+                           updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX notebooks_parent_id_idx ON notebooks (parent_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE notes ADD COLUMN notebook_id INT REFERENCES notebooks(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX notes_notebook_id_idx ON notes (notebook_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notes DROP COLUMN IF EXISTS notebook_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notebooks;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS notes_deleted_at_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE notes DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
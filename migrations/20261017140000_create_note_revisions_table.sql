@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE note_revisions (
+                                id SERIAL PRIMARY KEY,
+                                note_id INT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+                                revision INT NOT NULL,
+                                title TEXT NOT NULL,
+                                description TEXT,
+                                due_date TIMESTAMPTZ,
+                                author_id INT REFERENCES users(id) ON DELETE SET NULL,
+                                created_at TIMESTAMPTZ DEFAULT NOW(),
+                                UNIQUE (note_id, revision)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION note_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'note revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER note_revisions_no_update BEFORE UPDATE ON note_revisions
+    FOR EACH ROW EXECUTE FUNCTION note_revisions_immutable();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO note_revisions (note_id, revision, title, description, due_date, author_id, created_at)
+SELECT id, 1, title, description, due_date, user_id, updated_at FROM notes;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS note_revisions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS note_revisions_immutable();
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE sessions (
+                          id VARCHAR(64) PRIMARY KEY,
+                          user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          created_at TIMESTAMPTZ DEFAULT NOW(),
+                          expires_at TIMESTAMPTZ NOT NULL,
+                          revoked_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens (
+                                id SERIAL PRIMARY KEY,
+                                session_id VARCHAR(64) NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+                                token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                created_at TIMESTAMPTZ DEFAULT NOW(),
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                used_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE personal_access_tokens (
+                                        id SERIAL PRIMARY KEY,
+                                        user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                        name VARCHAR(100) NOT NULL,
+                                        token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                        scopes TEXT[] NOT NULL DEFAULT '{}',
+                                        created_at TIMESTAMPTZ DEFAULT NOW(),
+                                        expires_at TIMESTAMPTZ,
+                                        last_used_at TIMESTAMPTZ,
+                                        revoked_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_access_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE speller_settings (
+                                  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                  spell_mode VARCHAR(10) NOT NULL DEFAULT 'reject'
+                                      CHECK (spell_mode IN ('reject', 'warn', 'off')),
+                                  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS speller_settings;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_dictionary (
+                                 id SERIAL PRIMARY KEY,
+                                 user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 word VARCHAR(100) NOT NULL,
+                                 normalized_word VARCHAR(100) NOT NULL,
+                                 created_at TIMESTAMPTZ DEFAULT NOW(),
+                                 UNIQUE (user_id, normalized_word)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_dictionary;
+-- +goose StatementEnd
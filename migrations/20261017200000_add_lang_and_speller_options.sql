@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes ADD COLUMN lang VARCHAR(20) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE speller_settings
+    ADD COLUMN ignore_digits BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN ignore_urls BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN find_repeat_words BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN ignore_capitalization BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE speller_settings
+    DROP COLUMN IF EXISTS ignore_digits,
+    DROP COLUMN IF EXISTS ignore_urls,
+    DROP COLUMN IF EXISTS find_repeat_words,
+    DROP COLUMN IF EXISTS ignore_capitalization;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE notes DROP COLUMN IF EXISTS lang;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes
+    ADD COLUMN spell_status VARCHAR(10) NOT NULL DEFAULT ''
+        CHECK (spell_status IN ('', 'pending', 'checked', 'failed')),
+    ADD COLUMN spelling_issues JSONB,
+    ADD COLUMN spell_checked_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE spell_jobs (
+                            id SERIAL PRIMARY KEY,
+                            note_id INT NOT NULL UNIQUE REFERENCES notes(id) ON DELETE CASCADE,
+                            status VARCHAR(10) NOT NULL DEFAULT 'queued'
+                                CHECK (status IN ('queued', 'processing', 'dead')),
+                            generation INT NOT NULL DEFAULT 1,
+                            attempts INT NOT NULL DEFAULT 0,
+                            run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                            locked_until TIMESTAMPTZ,
+                            last_error TEXT,
+                            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX spell_jobs_run_at_idx ON spell_jobs (run_at) WHERE status <> 'dead';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE speller_settings
+    DROP CONSTRAINT speller_settings_spell_mode_check,
+    ADD CONSTRAINT speller_settings_spell_mode_check CHECK (spell_mode IN ('reject', 'warn', 'off', 'async'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE speller_settings SET spell_mode = 'warn' WHERE spell_mode = 'async';
+ALTER TABLE speller_settings
+    DROP CONSTRAINT speller_settings_spell_mode_check,
+    ADD CONSTRAINT speller_settings_spell_mode_check CHECK (spell_mode IN ('reject', 'warn', 'off'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS spell_jobs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS spell_status,
+    DROP COLUMN IF EXISTS spelling_issues,
+    DROP COLUMN IF EXISTS spell_checked_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE password_reset_tokens (
+                                       id SERIAL PRIMARY KEY,
+                                       user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                       token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                       expires_at TIMESTAMPTZ NOT NULL,
+                                       used_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_reset_tokens;
+-- +goose StatementEnd
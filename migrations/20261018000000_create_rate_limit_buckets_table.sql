@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rate_limit_buckets (
+                                    key VARCHAR(255) PRIMARY KEY,
+                                    tokens DOUBLE PRECISION NOT NULL,
+                                    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limit_buckets;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64),
+    ADD COLUMN totp_enabled_at TIMESTAMPTZ,
+    ADD COLUMN totp_last_step BIGINT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE recovery_codes (
+                                id SERIAL PRIMARY KEY,
+                                user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                code_hash VARCHAR(64) NOT NULL,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                used_at TIMESTAMPTZ,
+                                UNIQUE (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS recovery_codes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_secret,
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_last_step;
+-- +goose StatementEnd
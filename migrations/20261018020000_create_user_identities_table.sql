@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_identities (
+                                 id SERIAL PRIMARY KEY,
+                                 user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 provider VARCHAR(64) NOT NULL,
+                                 subject VARCHAR(255) NOT NULL,
+                                 email VARCHAR(255) NOT NULL DEFAULT '',
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                 last_login_at TIMESTAMPTZ,
+                                 UNIQUE (provider, subject),
+                                 UNIQUE (user_id, provider)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE oidc_login_states (
+                                   id SERIAL PRIMARY KEY,
+                                   state_hash VARCHAR(64) NOT NULL UNIQUE,
+                                   provider VARCHAR(64) NOT NULL,
+                                   nonce VARCHAR(64) NOT NULL,
+                                   code_verifier VARCHAR(128) NOT NULL,
+                                   user_id INT REFERENCES users(id) ON DELETE CASCADE,
+                                   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                   expires_at TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX oidc_login_states_expires_at_idx ON oidc_login_states (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS oidc_login_states;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Registration used to save username as password, such passwords are replaced with invalid hash,
+-- so that owners of these accounts have to set new password with password reset
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET password = '!' WHERE password LIKE '$2_$%' AND crypt(username, password) = password;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Old passwords can't be restored
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd